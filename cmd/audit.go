@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"svn-ai-reviewer/internal/audit"
+)
+
+var (
+	auditLogPath  string
+	auditFile     string
+	auditUser     string
+	auditProvider string
+	auditModel    string
+	auditHash     string
+	auditSince    string
+	auditUntil    string
+	auditErrors   bool
+	auditLimit    int
+	auditFull     bool
+	auditJSON     bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查看 AI 请求审计日志",
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "按条件筛选并显示审计日志",
+	Long: `显示审计日志（含轮转文件）中的 AI 请求记录，按时间从旧到新排列。
+
+时间参数支持 2006-01-02、2006-01-02 15:04 格式，或 24h、30m 等相对时间。
+
+示例:
+  svn-reviewer audit show --file UserService --since 24h
+  svn-reviewer audit show --hash 3f2a9c --full
+  svn-reviewer audit show --errors --limit 20`,
+	Args: cobra.NoArgs,
+	RunE: runAuditShow,
+}
+
+func init() {
+	auditShowCmd.Flags().StringVar(&auditLogPath, "log", "", "审计日志路径（默认使用配置中的 audit.path）")
+	auditShowCmd.Flags().StringVar(&auditFile, "file", "", "文件名包含该字符串")
+	auditShowCmd.Flags().StringVar(&auditUser, "user", "", "用户")
+	auditShowCmd.Flags().StringVar(&auditProvider, "provider", "", "提供商")
+	auditShowCmd.Flags().StringVar(&auditModel, "model", "", "模型名称包含该字符串")
+	auditShowCmd.Flags().StringVar(&auditHash, "hash", "", "diff 或提示词哈希的前缀")
+	auditShowCmd.Flags().StringVar(&auditSince, "since", "", "起始时间")
+	auditShowCmd.Flags().StringVar(&auditUntil, "until", "", "结束时间")
+	auditShowCmd.Flags().BoolVar(&auditErrors, "errors", false, "只显示失败的请求")
+	auditShowCmd.Flags().IntVarP(&auditLimit, "limit", "n", 0, "只显示最后 N 条")
+	auditShowCmd.Flags().BoolVar(&auditFull, "full", false, "显示原始响应内容")
+	auditShowCmd.Flags().BoolVar(&auditJSON, "json", false, "按 JSONL 原样输出")
+
+	auditCmd.AddCommand(auditShowCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAuditShow(cmd *cobra.Command, args []string) error {
+	path := auditLogPath
+	if path == "" {
+		path = cfg.Audit.Path
+	}
+
+	since, err := parseAuditTime(auditSince)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	until, err := parseAuditTime(auditUntil)
+	if err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+
+	entries, err := audit.Read(path, func(e *audit.Entry) bool {
+		switch {
+		case auditFile != "" && !strings.Contains(e.File, auditFile):
+			return false
+		case auditUser != "" && e.User != auditUser:
+			return false
+		case auditProvider != "" && e.Provider != auditProvider:
+			return false
+		case auditModel != "" && !strings.Contains(e.Model, auditModel):
+			return false
+		case auditHash != "" && !strings.HasPrefix(e.DiffHash, auditHash) && !strings.HasPrefix(e.PromptHash, auditHash):
+			return false
+		case !since.IsZero() && e.Time.Before(since):
+			return false
+		case !until.IsZero() && e.Time.After(until):
+			return false
+		case auditErrors && e.Error == "" && e.Status == 200:
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if auditLimit > 0 && len(entries) > auditLimit {
+		entries = entries[len(entries)-auditLimit:]
+	}
+
+	if auditJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			enc.Encode(e)
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("没有符合条件的记录。")
+		return nil
+	}
+	for _, e := range entries {
+		printAuditEntry(e)
+	}
+	fmt.Printf("共 %d 条记录\n", len(entries))
+	return nil
+}
+
+func printAuditEntry(e *audit.Entry) {
+	status := "✅"
+	if e.Error != "" || e.Status != 200 {
+		status = "❌"
+	}
+	fmt.Printf("%s %s  %s  %s/%s  %s\n", status, e.Time.Local().Format("2006-01-02 15:04:05"), e.User, e.Provider, e.Model, e.File)
+	fmt.Printf("    耗时 %.1fs  状态 %d  tokens %d+%d=%d  diff %s  prompt %s\n",
+		float64(e.LatencyMS)/1000, e.Status,
+		e.Usage.PromptTokens, e.Usage.CompletionTokens, e.Usage.TotalTokens,
+		shortHash(e.DiffHash), shortHash(e.PromptHash))
+	if e.Error != "" {
+		fmt.Printf("    错误: %s\n", firstLine(e.Error))
+	}
+	if auditFull && e.Response != "" {
+		fmt.Println("    响应:")
+		for _, line := range strings.Split(e.Response, "\n") {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+	fmt.Println()
+}
+
+// parseAuditTime 解析绝对时间或相对时间（如 24h 表示 24 小时前）
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的时间: %s", value)
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
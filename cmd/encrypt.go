@@ -3,37 +3,73 @@ package cmd
 import (
 	"fmt"
 
+	"svn-ai-reviewer/internal/config"
 	"svn-ai-reviewer/internal/crypto"
 
 	"github.com/spf13/cobra"
 )
 
+var migrateSecrets bool
+
 var encryptCmd = &cobra.Command{
 	Use:   "encrypt [api-key]",
-	Short: "加密 API Key",
-	Long:  `使用 DES 加密算法加密 API Key，加密后的密文可以安全地写入配置文件中。`,
-	Args:  cobra.ExactArgs(1),
+	Short: "加密 API Key 或密码",
+	Long: `使用 AES-GCM 加密 API Key 或 SVN 密码，加密后的密文可以安全地写入配置文件中。
+
+密钥默认保存在 ~/.svn-ai-reviewer/key（首次加密时自动生成）；
+设置环境变量 SVN_AI_REVIEWER_PASSPHRASE 时改为从口令派生密钥。
+
+使用 --migrate 将配置文件中旧版 DES 加密的 api_key / password 重新加密。`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if migrateSecrets {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if migrateSecrets {
+			runMigrateSecrets()
+			return
+		}
+
 		apiKey := args[0]
-		
-		encrypted, err := crypto.EncryptAPIKey(apiKey)
+
+		encrypted, err := crypto.Encrypt(apiKey)
 		if err != nil {
 			fmt.Printf("❌ 加密失败: %v\n", err)
 			return
 		}
-		
+
 		fmt.Println("✅ 加密成功！")
 		fmt.Println()
-		fmt.Println("原始 API Key:")
-		fmt.Printf("  %s\n", apiKey)
-		fmt.Println()
 		fmt.Println("加密后的密文:")
 		fmt.Printf("  %s\n", encrypted)
 		fmt.Println()
-		fmt.Println("请将加密后的密文复制到配置文件的 api_key 字段中。")
+		fmt.Println("请将加密后的密文复制到配置文件的 api_key（或 online.password）字段中。")
+		fmt.Println("也可以不保存密文，使用 env:变量名 或 file:/路径 引用。")
 	},
 }
 
+func runMigrateSecrets() {
+	migrated, err := config.MigrateLegacySecrets(cfgFile)
+	if err != nil {
+		fmt.Printf("❌ 迁移失败: %v\n", err)
+		return
+	}
+	if len(migrated) == 0 {
+		fmt.Println("配置文件中没有旧版 DES 密文，无需迁移。")
+		return
+	}
+
+	fmt.Println("✅ 已重新加密以下字段:")
+	for _, field := range migrated {
+		fmt.Printf("  - %s\n", field)
+	}
+	fmt.Println()
+	fmt.Printf("原配置已备份到 %s.bak，其中仍是旧版密文，确认无误后请删除。\n", cfgFile)
+}
+
 func init() {
+	encryptCmd.Flags().BoolVar(&migrateSecrets, "migrate", false, "将配置文件中旧版 DES 密文迁移为新格式")
 	rootCmd.AddCommand(encryptCmd)
 }
@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,12 +22,27 @@ var (
 	searchPath  string
 	searchKeyword string
 	saveCredentials bool
+
+	searchAuthors    []string
+	searchSince      string
+	searchUntil      string
+	searchRegex      string
+	searchRevision   string
+	searchStopOnCopy bool
+	searchLimit      int
 )
 
 var onlineCmd = &cobra.Command{
 	Use:   "online",
 	Short: "在线审核SVN服务器上的指定版本",
-	Long:  `连接到SVN服务器，搜索并审核指定版本的代码变更。`,
+	Long: `连接到SVN服务器，搜索并审核指定版本的代码变更。
+
+版本范围、日期、关键词和作者条件会交给 SVN 服务器过滤，只下载符合条件的提交记录。
+
+示例:
+  svn-reviewer review online -p trunk --author zhangsan --since 2024-01-01
+  svn-reviewer review online -k "*NPE*" --until "2024-06-30 18:00" --regex "^fix"
+  svn-reviewer review online -p branches/release-1.2 --stop-on-copy -r 5000:4000`,
 	RunE:  runOnline,
 }
 
@@ -35,11 +52,22 @@ func init() {
 	onlineCmd.Flags().StringVar(&svnUsername, "username", "", "SVN用户名")
 	onlineCmd.Flags().StringVar(&svnPassword, "password", "", "SVN密码")
 	onlineCmd.Flags().StringVarP(&searchPath, "path", "p", "", "搜索路径（默认根目录）")
-	onlineCmd.Flags().StringVarP(&searchKeyword, "keyword", "k", "", "搜索关键词（搜索提交信息和作者）")
+	onlineCmd.Flags().StringVarP(&searchKeyword, "keyword", "k", "", "搜索关键词（匹配提交信息、作者、日期和变更路径，支持 * ? 通配符）")
+	onlineCmd.Flags().StringSliceVar(&searchAuthors, "author", nil, "只显示指定作者的提交（可指定多个，逗号分隔）")
+	onlineCmd.Flags().StringVar(&searchSince, "since", "", "起始日期，如 2024-01-01 或 \"2024-01-01 09:00\"")
+	onlineCmd.Flags().StringVar(&searchUntil, "until", "", "截止日期（只写日期时包含当天）")
+	onlineCmd.Flags().StringVar(&searchRegex, "regex", "", "提交信息需要匹配的正则表达式")
+	onlineCmd.Flags().StringVarP(&searchRevision, "revision", "r", "", "版本范围，如 5000:4000、5000 或 HEAD:4000")
+	onlineCmd.Flags().BoolVar(&searchStopOnCopy, "stop-on-copy", false, "遇到复制（创建分支、标签）时停止，只显示分支上的提交")
+	onlineCmd.Flags().IntVar(&searchLimit, "limit", 100, "最多显示的提交记录数")
 	onlineCmd.Flags().BoolVar(&saveCredentials, "save", false, "保存SVN凭据")
 }
 
 func runOnline(cmd *cobra.Command, args []string) error {
+	if err := resolveSecrets(); err != nil {
+		return err
+	}
+
 	// 尝试从配置文件加载凭据
 	if svnURL == "" {
 		if cfg.Online.URL != "" {
@@ -71,6 +99,13 @@ func runOnline(cmd *cobra.Command, args []string) error {
 
 	// 创建在线SVN客户端
 	svnClient := svn.NewOnlineClient(cfg.SVN.Command, svnURL, svnUsername, svnPassword)
+	svnClient.SetConfigDir(cfg.SVN.ConfigDir)
+	svnClient.SetEncoding(cfg.SVN.Encoding)
+	svnClient.SetLogCache(cfg.SVN.LogCache.CacheDir())
+	if err := svnClient.SetBackend(cfg.SVN.Backend); err != nil {
+		fmt.Printf("⚠️  %v，改用 svn 命令行\n", err)
+	}
+	defer svnClient.Close()
 
 	// 测试连接
 	fmt.Println("正在测试SVN服务器连接...")
@@ -87,16 +122,24 @@ func runOnline(cmd *cobra.Command, args []string) error {
 		if err := config.SaveConfig(cfgFile, cfg); err != nil {
 			fmt.Printf("⚠️  保存凭据失败: %v\n", err)
 		} else {
-			fmt.Println("✓ SVN凭据已保存到配置文件")
+			fmt.Println("✓ SVN凭据已保存到配置文件（密码已加密）")
 		}
 	}
 
 	// 搜索日志
+	query, err := buildLogQuery()
+	if err != nil {
+		return err
+	}
 	fmt.Println("\n正在搜索SVN提交记录...")
-	entries, _, err := svnClient.SearchLog(searchPath, searchKeyword, 100, 0)
+	page, err := svnClient.SearchLog(query)
 	if err != nil {
 		return fmt.Errorf("搜索日志失败: %w", err)
 	}
+	entries := page.Entries
+	if page.Offline {
+		fmt.Println("⚠️  无法连接SVN服务器，以下结果来自本地日志缓存")
+	}
 
 	if len(entries) == 0 {
 		fmt.Println("没有找到匹配的提交记录。")
@@ -105,6 +148,9 @@ func runOnline(cmd *cobra.Command, args []string) error {
 
 	// 显示搜索结果
 	fmt.Printf("\n找到 %d 条提交记录:\n", len(entries))
+	if page.HasMore {
+		fmt.Printf("（还有更早的记录，可以用 -r %d:1 继续查看）\n", page.Next-1)
+	}
 	for i, entry := range entries {
 		fmt.Printf("  [%d] r%d | %s | %s\n", i+1, entry.Revision, entry.Author, entry.Date[:19])
 		msg := strings.ReplaceAll(entry.Message, "\n", " ")
@@ -155,6 +201,9 @@ func runOnline(cmd *cobra.Command, args []string) error {
 		allFiles = append(allFiles, files...)
 	}
 
+	// 按 ignore、.svnreviewignore 和 svn:ignore 等规则过滤
+	allFiles = svnClient.FilterIgnored(allFiles, cfg.Ignore)
+
 	if len(allFiles) == 0 {
 		fmt.Println("选中的版本没有文件变更。")
 		return nil
@@ -164,7 +213,14 @@ func runOnline(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\n检测到 %d 个变更文件:\n", len(allFiles))
 	for i, file := range allFiles {
 		statusDesc := getStatusDesc(file.Status)
-		fmt.Printf("  [%d] %s %s (r%d)\n", i+1, statusDesc, file.Path, file.Revision)
+		fmt.Printf("  [%d] %s %s (r%d)", i+1, statusDesc, file.Path, file.Revision)
+		if file.CopyFromPath != "" {
+			fmt.Printf(" <- %s@%d", file.CopyFromPath, file.CopyFromRev)
+		}
+		if file.PropMods {
+			fmt.Printf(" [属性修改]")
+		}
+		fmt.Println()
 	}
 
 	// 选择要审核的文件
@@ -195,7 +251,7 @@ func runOnline(cmd *cobra.Command, args []string) error {
 	}
 
 	// 创建AI客户端
-	aiClient, err := ai.NewClient(&cfg.AI)
+	aiClient, err := ai.NewReviewClient(cfg)
 	if err != nil {
 		return fmt.Errorf("创建AI客户端失败: %w", err)
 	}
@@ -218,6 +274,7 @@ func runOnline(cmd *cobra.Command, args []string) error {
 		fileReview := report.FileReview{
 			FileName: fmt.Sprintf("%s (r%d)", file.Path, file.Revision),
 			Status:   file.Status,
+			Revision: file.Revision,
 		}
 
 		// 删除的文件直接跳过
@@ -232,29 +289,19 @@ func runOnline(cmd *cobra.Command, args []string) error {
 		// 对于新增文件，获取完整内容（纯文本，不带diff格式）
 		if file.Status == "A" {
 			fmt.Printf("  ℹ️  新增文件，获取完整内容\n")
-			content, err := svnClient.GetFileContentAtRevision(file.Revision, file.Path)
+			content, enc, err := svnClient.GetFileContentAtRevision(file.Revision, file.Path)
 			if err != nil {
-				fmt.Printf("  ⚠️  获取文件内容失败: %v\n", err)
-				fmt.Printf("  ℹ️  尝试使用整个版本的diff作为备选\n")
-				
-				// 备选方案：使用整个版本的diff
-				fullDiff, err2 := svnClient.GetRevisionDiff(file.Revision, "")
-				if err2 == nil && strings.TrimSpace(fullDiff) != "" {
-					diff = fullDiff
-					fmt.Printf("  ℹ️  使用整个版本的diff\n\n")
-				} else {
-					fmt.Printf("  ❌  无法获取文件内容\n\n")
-					fileReview.Error = err
-					htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
-					continue
-				}
-			} else {
-				// 直接使用纯文本内容，不添加任何前缀
-				diff = content
+				fmt.Printf("  ❌  获取文件内容失败: %v\n\n", err)
+				fileReview.Error = err
+				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+				continue
 			}
+			// 直接使用纯文本内容，不添加任何前缀
+			diff = content
+			file.Encoding = enc
 		} else {
-			// 对于修改的文件，获取diff
-			diff, err = svnClient.GetRevisionDiff(file.Revision, file.Path)
+			// 对于修改的文件，获取该文件的diff
+			diff, file.Encoding, err = svnClient.GetRevisionDiff(file.Revision, file.Path)
 			if err != nil {
 				fmt.Printf("  ⚠️  获取文件差异失败: %v\n\n", err)
 				fileReview.Error = err
@@ -263,27 +310,35 @@ func runOnline(cmd *cobra.Command, args []string) error {
 			}
 
 			if strings.TrimSpace(diff) == "" {
-				fmt.Printf("  ⚠️  警告: 未能提取到文件差异内容\n")
-				fmt.Printf("      文件路径: %s\n", file.Path)
-				fmt.Printf("      这可能是路径匹配问题，将尝试获取整个版本的差异\n\n")
-				
-				// 尝试获取整个版本的diff作为备选
-				fullDiff, err2 := svnClient.GetRevisionDiff(file.Revision, "")
-				if err2 == nil && strings.TrimSpace(fullDiff) != "" {
-					diff = fullDiff
-					fmt.Printf("  ℹ️  使用整个版本的差异内容\n\n")
-				} else {
-					fmt.Printf("  ℹ️  跳过审核（无差异内容）\n\n")
-					continue
+				// 例如只复制没有修改内容
+				fmt.Printf("  ℹ️  该版本中文件内容没有变化，跳过审核\n\n")
+				continue
+			} else if contextEnabled() {
+				diff, err = svnClient.WithRevisionContext(file.Revision, file.Path, diff, cfg.Context.ContextOptions())
+				if err != nil {
+					fmt.Printf("  ⚠️  获取上下文失败，仅使用差异内容: %v\n", err)
 				}
 			}
 		}
 
-		// 调用AI审核
-		result, err := aiClient.Review(ctx, file.Path, diff, cfg.ReviewPrompt)
+		// 保存 diff 内容到报告
+		fileReview.Diff = diff
+		fileReview.Encoding = file.Encoding
+
+		// 调用AI审核，外部检查工具需要修改的文件的完整内容时再获取
+		revision, filePath := file.Revision, file.Path
+		fileCtx := ai.WithContentLoader(ctx, func() (string, error) {
+			content, _, err := svnClient.GetFileContentAtRevision(revision, filePath)
+			return content, err
+		})
+		result, err := aiClient.Review(fileCtx, file.Path, diff, cfg.ReviewPrompt)
 		if err != nil {
 			fmt.Printf("  ❌ 审核失败: %v\n\n", err)
 			fileReview.Error = err
+			fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+		} else if result.Skipped != "" {
+			fmt.Printf("  ℹ️  未发送给 AI，已跳过\n\n")
+			fileReview.Result = result
 		} else {
 			fmt.Printf("  ✅ 审核完成\n\n")
 			fileReview.Result = result
@@ -292,6 +347,9 @@ func runOnline(cmd *cobra.Command, args []string) error {
 		htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
 	}
 
+	// 跨文件整体审核（按版本分组）
+	runChangesetReview(ctx, aiClient, htmlReport)
+
 	// 生成HTML报告
 	fmt.Println("正在生成HTML报告...")
 	reportPath, err := report.GenerateHTML(htmlReport, cfg.Report.OutputDir)
@@ -313,3 +371,70 @@ func runOnline(cmd *cobra.Command, args []string) error {
 	fmt.Println("\n所有文件审核完成！")
 	return nil
 }
+
+// buildLogQuery 根据命令行参数生成日志搜索条件
+func buildLogQuery() (svn.LogQuery, error) {
+	query := svn.LogQuery{
+		Path:       searchPath,
+		Keyword:    searchKeyword,
+		StopOnCopy: searchStopOnCopy,
+		Limit:      searchLimit,
+	}
+	for _, author := range searchAuthors {
+		if author = strings.TrimSpace(author); author != "" {
+			query.Authors = append(query.Authors, author)
+		}
+	}
+
+	var err error
+	if query.Since, err = svn.ParseLogDate(searchSince, false); err != nil {
+		return query, err
+	}
+	if query.Until, err = svn.ParseLogDate(searchUntil, true); err != nil {
+		return query, err
+	}
+	if searchRegex != "" {
+		if query.Message, err = regexp.Compile(searchRegex); err != nil {
+			return query, fmt.Errorf("正则表达式无效: %w", err)
+		}
+	}
+	if searchRevision != "" {
+		if query.StartRev, query.EndRev, err = parseRevisionRange(searchRevision); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// parseRevisionRange 解析 N、N:M 形式的版本范围，HEAD 表示最新版本
+func parseRevisionRange(value string) (int, int, error) {
+	parse := func(s string) (int, error) {
+		s = strings.TrimPrefix(strings.TrimSpace(s), "r")
+		if strings.EqualFold(s, "HEAD") {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("版本范围 %q 无效（示例: 5000:4000）", value)
+		}
+		return n, nil
+	}
+
+	start, end, found := strings.Cut(value, ":")
+	startRev, err := parse(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return startRev, startRev, nil
+	}
+	endRev, err := parse(end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if endRev == 0 {
+		// 4000:HEAD
+		return 0, startRev, nil
+	}
+	return startRev, endRev, nil
+}
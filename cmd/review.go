@@ -16,6 +16,9 @@ var (
 	workDir       string
 	selectedFiles []string
 	interactive   bool
+	changeset     bool
+	fullContext   bool
+	changelists   []string
 )
 
 var reviewCmd = &cobra.Command{
@@ -30,15 +33,27 @@ func init() {
 	reviewCmd.Flags().StringVarP(&workDir, "dir", "d", ".", "SVN 工作目录路径")
 	reviewCmd.Flags().StringSliceVarP(&selectedFiles, "files", "f", nil, "指定要审核的文件（逗号分隔）")
 	reviewCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "交互式选择文件")
+	reviewCmd.Flags().StringSliceVar(&changelists, "changelist", nil, "只审核指定 SVN 变更列表中的文件（可重复或逗号分隔）")
+	reviewCmd.PersistentFlags().BoolVar(&fullContext, "context", false, "为每个变更块附加所在函数（或周围若干行）的完整代码")
+	reviewCmd.PersistentFlags().BoolVar(&changeset, "changeset", false, "逐文件审核后再进行一次跨文件的整体变更审核")
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
+	if err := resolveSecrets(); err != nil {
+		return err
+	}
+
 	// 创建 SVN 客户端
 	svnClient := svn.NewClient(cfg.SVN.Command, workDir)
+	svnClient.SetEncoding(cfg.SVN.Encoding)
 
 	// 获取变更文件
-	fmt.Println("正在扫描 SVN 变更...")
-	changes, err := svnClient.GetChangedFiles(cfg.Ignore)
+	if len(changelists) > 0 {
+		fmt.Printf("正在扫描 SVN 变更（变更列表: %s）...\n", strings.Join(changelists, ", "))
+	} else {
+		fmt.Println("正在扫描 SVN 变更...")
+	}
+	changes, err := svnClient.GetChangedFiles(cfg.Ignore, changelists...)
 	if err != nil {
 		return fmt.Errorf("获取变更文件失败: %w", err)
 	}
@@ -52,7 +67,14 @@ func runReview(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\n检测到 %d 个变更文件:\n", len(changes))
 	for i, change := range changes {
 		statusDesc := getStatusDesc(change.Status)
-		fmt.Printf("  [%d] %s %s\n", i+1, statusDesc, change.Path)
+		fmt.Printf("  [%d] %s %s", i+1, statusDesc, change.Path)
+		if change.PropMods && !change.TextMods {
+			fmt.Printf(" [仅属性修改]")
+		}
+		if change.Changelist != "" {
+			fmt.Printf(" (变更列表: %s)", change.Changelist)
+		}
+		fmt.Println()
 	}
 
 	// 选择要审核的文件
@@ -77,14 +99,15 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	// 创建 AI 客户端
-	aiClient, err := ai.NewClient(&cfg.AI)
+	aiClient, err := ai.NewReviewClient(cfg)
 	if err != nil {
 		return fmt.Errorf("创建 AI 客户端失败: %w", err)
 	}
 
 	// 审核每个文件
 	fmt.Printf("\n开始审核 %d 个文件...\n\n", len(filesToReview))
-	ctx := context.Background()
+	// 外部检查工具直接检查工作副本中的文件
+	ctx := ai.WithLocalDir(context.Background(), workDir)
 
 	// 创建报告
 	htmlReport := &report.Report{
@@ -102,15 +125,24 @@ func runReview(cmd *cobra.Command, args []string) error {
 			Status:   change.Status,
 		}
 
+		// 冲突、缺失等条目无法审核，直接记录到报告
+		if problem := change.Problem(); problem != nil {
+			fmt.Printf("  ⛔ %v\n\n", problem)
+			fileReview.Error = problem
+			fileReview.Blocking = change.Conflicted
+			htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			continue
+		}
+
 		// 获取文件差异
 		var diff string
 		var skipReview bool
 		if change.Status == "D" {
 			// 删除的文件，只显示删除信息
 			diff = fmt.Sprintf("文件已删除: %s", change.Path)
-		} else if change.Status == "A" || change.Status == "?" {
-			// 新增文件或未受控文件，获取完整内容
-			content, err := svnClient.GetFileContent(change.Path)
+		} else if change.Status == "A" || change.Status == "R" || change.Status == "?" {
+			// 新增、替换或未受控文件，获取完整内容
+			content, enc, err := svnClient.GetFileContent(change.Path)
 			if err != nil {
 				fmt.Printf("  ⚠️  获取文件内容失败: %v\n\n", err)
 				fileReview.Error = err
@@ -120,11 +152,14 @@ func runReview(cmd *cobra.Command, args []string) error {
 			statusDesc := "新增文件"
 			if change.Status == "?" {
 				statusDesc = "未受控文件（尚未加入版本控制）"
+			} else if change.Status == "R" {
+				statusDesc = "替换文件"
 			}
 			diff = fmt.Sprintf("%s，完整内容:\n%s", statusDesc, content)
+			change.Encoding = enc
 		} else {
 			// 修改的文件，获取 diff
-			d, err := svnClient.GetFileDiff(change.Path)
+			d, enc, err := svnClient.GetFileDiff(change.Path)
 			if err != nil {
 				fmt.Printf("  ⚠️  获取文件差异失败: %v\n\n", err)
 				fileReview.Error = err
@@ -134,8 +169,14 @@ func runReview(cmd *cobra.Command, args []string) error {
 			if strings.TrimSpace(d) == "" {
 				fmt.Printf("  ℹ️  文件无差异内容\n\n")
 				skipReview = true
+			} else if contextEnabled() {
+				d, err = svnClient.WithWorkingCopyContext(change.Path, d, cfg.Context.ContextOptions())
+				if err != nil {
+					fmt.Printf("  ⚠️  获取上下文失败，仅使用差异内容: %v\n", err)
+				}
 			}
 			diff = d
+			change.Encoding = enc
 		}
 
 		if strings.TrimSpace(diff) == "" || skipReview {
@@ -143,11 +184,19 @@ func runReview(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
+		// 保存 diff 内容到报告
+		fileReview.Diff = diff
+		fileReview.Encoding = change.Encoding
+
 		// 调用 AI 审核
 		result, err := aiClient.Review(ctx, change.Path, diff, cfg.ReviewPrompt)
 		if err != nil {
 			fmt.Printf("  ❌ 审核失败: %v\n\n", err)
 			fileReview.Error = err
+			fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+		} else if result.Skipped != "" {
+			fmt.Printf("  ℹ️  未发送给 AI，已跳过\n\n")
+			fileReview.Result = result
 		} else {
 			fmt.Printf("  ✅ 审核完成\n\n")
 			fileReview.Result = result
@@ -156,6 +205,9 @@ func runReview(cmd *cobra.Command, args []string) error {
 		htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
 	}
 
+	// 跨文件整体审核
+	runChangesetReview(ctx, aiClient, htmlReport)
+
 	// 生成 HTML 报告
 	fmt.Println("正在生成 HTML 报告...")
 	reportPath, err := report.GenerateHTML(htmlReport, cfg.Report.OutputDir)
@@ -178,6 +230,33 @@ func runReview(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// contextEnabled 是否为差异附加完整上下文
+func contextEnabled() bool {
+	return fullContext || cfg.Context.Enabled
+}
+
+// runChangesetReview 在逐文件审核完成后，按版本对文件分组进行跨文件整体审核
+func runChangesetReview(ctx context.Context, aiClient ai.Client, htmlReport *report.Report) {
+	if !changeset && !cfg.Changeset.Enabled {
+		return
+	}
+
+	for _, group := range report.ChangesetGroups(htmlReport.Reviews) {
+		fmt.Printf("正在进行整体变更审核: %s (%d 个文件)\n", group.Title, len(group.Files))
+		result, err := ai.ReviewChangeset(ctx, aiClient, group.Title, group.Files, &cfg.Changeset)
+		if err != nil {
+			fmt.Printf("  ❌ 整体审核失败: %v\n\n", err)
+		} else {
+			fmt.Printf("  ✅ 整体审核完成\n\n")
+		}
+		htmlReport.Changesets = append(htmlReport.Changesets, report.ChangesetReview{
+			Title:  group.Title,
+			Result: result,
+			Error:  err,
+		})
+	}
+}
+
 func getStatusDesc(status string) string {
 	switch status {
 	case "A":
@@ -186,6 +265,14 @@ func getStatusDesc(status string) string {
 		return "[修改]"
 	case "D":
 		return "[删除]"
+	case "R":
+		return "[替换]"
+	case "C":
+		return "[冲突]"
+	case "!":
+		return "[缺失]"
+	case "~":
+		return "[阻塞]"
 	case "?":
 		return "[未受控]"
 	default:
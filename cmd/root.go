@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"svn-ai-reviewer/internal/config"
@@ -17,6 +16,9 @@ var rootCmd = &cobra.Command{
 	Use:   "svn-reviewer",
 	Short: "SVN 代码审核工具",
 	Long:  `一个基于 AI 的 SVN 代码变更审核工具，支持自动检测变更并提供改进建议。`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	},
 }
 
 func Execute() error {
@@ -24,15 +26,23 @@ func Execute() error {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "config.yaml", "配置文件路径")
 }
 
-func initConfig() {
+// initConfig 加载配置文件，密钥不在这里解析，见 resolveSecrets
+func initConfig() error {
 	var err error
 	cfg, err = config.LoadConfig(cfgFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "加载配置文件失败: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("加载配置文件失败: %w", err)
 	}
+	return nil
+}
+
+// resolveSecrets 解析 API Key 和在线模式密码，只在需要这些值的命令中调用
+func resolveSecrets() error {
+	if err := cfg.ResolveSecrets(); err != nil {
+		return fmt.Errorf("解析配置中的密钥失败: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"svn-ai-reviewer/gui"
+)
+
+var (
+	serveListen    string
+	serveHost      string
+	servePort      int
+	serveBasePath  string
+	serveTLSCert   string
+	serveTLSKey    string
+	serveNoBrowser bool
+	serveUsersFile string
+)
+
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Aliases: []string{"gui"},
+	Short:   "启动网页端服务",
+	Long: `启动网页端服务。不带任何参数运行程序时也会启动网页端（只监听 localhost:8080，不需要登录）。
+
+监听地址、URL 前缀、HTTPS 证书和登录方式读取配置文件中的 server 部分，命令行参数优先。
+供团队共用时请同时启用登录（server.auth.mode 设为 local 或 ldap）。
+
+按 Ctrl+C 停止服务时会等待正在运行的审核任务完成，再次按 Ctrl+C 取消这些任务。
+
+示例:
+  svn-reviewer serve --host 0.0.0.0 --port 9000 --no-browser
+  svn-reviewer serve --base-path /svn-review --tls-cert server.crt --tls-key server.key`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var serveUserAddCmd = &cobra.Command{
+	Use:   "useradd <username>",
+	Short: "添加网页端本地用户或修改密码",
+	Long: `向本地用户文件（server.auth.users_file）添加用户，已存在时修改密码。
+密码从标准输入读取，例如:
+  echo 'p@ssw0rd' | svn-reviewer serve useradd zhangsan`,
+	Args: cobra.ExactArgs(1),
+	RunE: runServeUserAdd,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "监听地址，如 0.0.0.0:8080（默认使用 server.listen 或 localhost:8080）")
+	serveCmd.Flags().StringVar(&serveHost, "host", "", "监听的主机地址，覆盖监听地址中的主机部分")
+	serveCmd.Flags().IntVar(&servePort, "port", 0, "监听端口，覆盖监听地址中的端口部分")
+	serveCmd.Flags().StringVar(&serveBasePath, "base-path", "", "URL 前缀，部署在反向代理的子路径下时使用，如 /svn-review")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "HTTPS 证书文件（需同时指定 --tls-key）")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "HTTPS 私钥文件")
+	serveCmd.Flags().BoolVar(&serveNoBrowser, "no-browser", false, "启动后不自动打开浏览器")
+	serveCmd.PersistentFlags().StringVar(&serveUsersFile, "users-file", "", "本地用户文件（默认使用 server.auth.users_file）")
+
+	serveCmd.AddCommand(serveUserAddCmd)
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	authCfg := cfg.Server.Auth
+	if serveUsersFile != "" {
+		authCfg.UsersFile = serveUsersFile
+	}
+	auth, err := gui.NewAuthenticator(&authCfg)
+	if err != nil {
+		return err
+	}
+
+	listen, err := serveAddress()
+	if err != nil {
+		return err
+	}
+
+	basePath := cfg.Server.BasePath
+	if cmd.Flags().Changed("base-path") {
+		basePath = serveBasePath
+	}
+	tlsCert, tlsKey := cfg.Server.TLSCert, cfg.Server.TLSKey
+	if serveTLSCert != "" || serveTLSKey != "" {
+		tlsCert, tlsKey = serveTLSCert, serveTLSKey
+	}
+
+	server := gui.NewServer(gui.Options{
+		Listen:      listen,
+		SessionTTL:  time.Duration(cfg.Server.SessionTTL) * time.Minute,
+		Auth:        auth,
+		OpenBrowser: !serveNoBrowser,
+		MaxJobs:     cfg.Server.MaxJobs,
+		JobsDir:     cfg.Server.JobsDir,
+		BasePath:    basePath,
+		TLSCert:     tlsCert,
+		TLSKey:      tlsKey,
+		ReportsDir:  cfg.Report.OutputDir,
+		// 供团队共用时只允许访问配置的目录，未配置时为启动时的当前目录
+		AllowedRoots: allowedRoots(),
+		ConfigFiles:  cfg.Server.ConfigFiles,
+		SecureCookie: cfg.Server.SecureCookie,
+	})
+	return server.Start()
+}
+
+// allowedRoots 网页上可以访问的目录，未配置时只允许当前目录
+func allowedRoots() []string {
+	if len(cfg.Server.AllowedRoots) > 0 {
+		return cfg.Server.AllowedRoots
+	}
+	return []string{"."}
+}
+
+// serveAddress 合并配置和命令行参数得到监听地址
+func serveAddress() (string, error) {
+	listen := cfg.Server.Listen
+	if serveListen != "" {
+		listen = serveListen
+	}
+	if listen == "" {
+		listen = "localhost:8080"
+	}
+	if serveHost == "" && servePort == 0 {
+		return listen, nil
+	}
+
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("监听地址 %q 无效: %w", listen, err)
+	}
+	if serveHost != "" {
+		host = serveHost
+	}
+	if servePort != 0 {
+		if servePort < 0 || servePort > 65535 {
+			return "", fmt.Errorf("端口 %d 无效", servePort)
+		}
+		port = strconv.Itoa(servePort)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func runServeUserAdd(cmd *cobra.Command, args []string) error {
+	path := cfg.Server.Auth.UsersFile
+	if serveUsersFile != "" {
+		path = serveUsersFile
+	}
+	if path == "" {
+		return fmt.Errorf("请在配置文件中设置 server.auth.users_file 或使用 --users-file 指定用户文件")
+	}
+
+	fmt.Fprintf(os.Stderr, "请输入用户 %s 的密码: ", args[0])
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("读取密码失败: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	if err := gui.SetLocalUser(path, args[0], password); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Printf("✅ 已保存用户 %s 到 %s\n", args[0], path)
+	return nil
+}
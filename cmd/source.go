@@ -1,18 +1,210 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"svn-ai-reviewer/internal/ai"
+	"svn-ai-reviewer/internal/report"
+	"svn-ai-reviewer/internal/source"
+)
+
+var (
+	sourceFilters  []string
+	sourceMaxFiles int
+	sourceExcludes []string
+	sourceAudit    bool
 )
 
 var sourceCmd = &cobra.Command{
-	Use:   "source",
+	Use:   "source [PATH...]",
 	Short: "源代码审核模式",
-	Long:  `直接输入目录或文件路径进行代码审核`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// 源代码模式通过GUI界面操作
-	},
+	Long: `直接输入目录或文件路径进行代码审核，不需要 SVN 变更。
+
+扫描目录时与网页端源代码模式相同：跳过 .svn 目录，应用配置文件的 ignore、
+目录下的 .svnreviewignore，以及目录是工作副本时的 svn:ignore、svn:global-ignores。
+不指定路径时扫描当前目录。
+
+--audit 为项目审计模式：按 Maven/Gradle/npm 模块、Go 包或目录对文件分组，
+报告顶部显示模块树，每个目录汇总平均评分、问题数和主要问题。
+审计模式下不指定 --max-files 时不限制文件数。
+
+示例:
+  svn-reviewer source src --filter "*.java"
+  svn-reviewer source src/main web --filter "*.go" --filter "*.ts" --exclude "**/testdata/"
+  svn-reviewer source . --max-files 500
+  svn-reviewer source . --audit --filter "*.java"`,
+	RunE: runSource,
 }
 
 func init() {
 	rootCmd.AddCommand(sourceCmd)
+	sourceCmd.Flags().StringSliceVar(&sourceFilters, "filter", nil, "只审核匹配的文件，如 *.go、src/*.java（可重复或逗号分隔）")
+	sourceCmd.Flags().IntVar(&sourceMaxFiles, "max-files", 100, "最多审核的文件数，0 表示不限制")
+	sourceCmd.Flags().StringSliceVar(&sourceExcludes, "exclude", nil, "额外忽略的文件或目录，写法同 ignore，相对扫描的目录（可重复或逗号分隔）")
+	sourceCmd.Flags().BoolVar(&sourceAudit, "audit", false, "项目审计：按模块分组，报告中显示模块树和各目录的汇总")
+}
+
+func runSource(cmd *cobra.Command, args []string) error {
+	if err := resolveSecrets(); err != nil {
+		return err
+	}
+
+	paths := args
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if sourceAudit && !cmd.Flags().Changed("max-files") {
+		sourceMaxFiles = 0
+	}
+
+	// 扫描文件，多个路径共用最大文件数
+	fmt.Println("正在扫描源代码文件...")
+	var files []string
+	var modules []report.Module
+	seen := map[string]bool{}
+	truncated := false
+	for _, root := range paths {
+		matcher, err := source.Matcher(cfg.SVN.Command, root, cfg.Ignore, sourceExcludes)
+		if err != nil {
+			return err
+		}
+		opts := source.Options{Filters: sourceFilters, Ignore: matcher}
+		if sourceMaxFiles > 0 {
+			opts.MaxFiles = sourceMaxFiles - len(files)
+			if opts.MaxFiles <= 0 {
+				truncated = true
+				break
+			}
+		}
+		found, more, err := source.Scan(root, opts)
+		if err != nil {
+			return fmt.Errorf("扫描 %s 失败: %w", root, err)
+		}
+		truncated = truncated || more
+		var added []string
+		for _, f := range found {
+			if !seen[f] {
+				seen[f] = true
+				added = append(added, f)
+			}
+		}
+
+		if !sourceAudit {
+			files = append(files, added...)
+			continue
+		}
+		// 审计模式按模块排列文件，扫描多个路径时模块路径以扫描路径开头
+		for _, m := range source.Modules(root, added) {
+			if len(paths) > 1 {
+				m.Path = path.Join(filepath.ToSlash(root), m.Path)
+			}
+			files = append(files, m.Files...)
+			modules = append(modules, m)
+		}
+	}
+
+	if len(files) == 0 {
+		fmt.Println("没有找到需要审核的文件。")
+		return nil
+	}
+
+	// 显示文件列表
+	fmt.Printf("\n找到 %d 个文件:\n", len(files))
+	for i, f := range files {
+		fmt.Printf("  [%d] %s\n", i+1, f)
+	}
+	if sourceAudit {
+		fmt.Printf("按模块分组: %d 个模块\n", len(modules))
+	}
+	if truncated {
+		fmt.Printf("⚠️  文件数超过 %d 个，其余文件未审核（可使用 --max-files 调整）\n", sourceMaxFiles)
+	}
+
+	// 创建 AI 客户端
+	aiClient, err := ai.NewReviewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("创建 AI 客户端失败: %w", err)
+	}
+
+	fmt.Printf("\n开始审核 %d 个文件...\n\n", len(files))
+	ctx := ai.WithLocalDir(context.Background(), "")
+
+	htmlReport := &report.Report{
+		Title:       "源代码审核报告",
+		GeneratedAt: time.Now(),
+		WorkDir:     strings.Join(paths, ", "),
+		Reviews:     make([]report.FileReview, 0),
+		Modules:     modules,
+	}
+	if sourceAudit {
+		htmlReport.Title = "项目审计报告"
+	}
+
+	for i, f := range files {
+		fmt.Printf("[%d/%d] 正在审核: %s\n", i+1, len(files), f)
+
+		fileReview := report.FileReview{
+			FileName: f,
+			Status:   "源代码",
+		}
+
+		content, enc, err := source.ReadFile(f, cfg.SVN.Encoding)
+		if err != nil {
+			fmt.Printf("  ❌ 读取文件失败: %v\n\n", err)
+			fileReview.Error = err
+			htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			continue
+		}
+		if strings.TrimSpace(content) == "" {
+			fmt.Printf("  ℹ️  文件为空，跳过审核\n\n")
+			continue
+		}
+
+		// 保存文件内容到报告
+		fileReview.Diff = content
+		fileReview.Encoding = enc
+
+		// 调用 AI 审核
+		result, err := aiClient.Review(ctx, f, content, cfg.ReviewPrompt)
+		if err != nil {
+			fmt.Printf("  ❌ 审核失败: %v\n\n", err)
+			fileReview.Error = err
+			fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+		} else if result.Skipped != "" {
+			fmt.Printf("  ℹ️  未发送给 AI，已跳过\n\n")
+			fileReview.Result = result
+		} else {
+			fmt.Printf("  ✅ 审核完成\n\n")
+			fileReview.Result = result
+		}
+
+		htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+	}
+
+	// 生成 HTML 报告
+	fmt.Println("正在生成 HTML 报告...")
+	reportPath, err := report.GenerateHTML(htmlReport, cfg.Report.OutputDir)
+	if err != nil {
+		return fmt.Errorf("生成报告失败: %w", err)
+	}
+
+	fmt.Printf("✅ 报告已生成: %s\n", reportPath)
+
+	// 自动打开浏览器
+	if cfg.Report.AutoOpen {
+		fmt.Println("正在打开浏览器...")
+		if err := report.OpenInBrowser(reportPath); err != nil {
+			fmt.Printf("⚠️  自动打开浏览器失败: %v\n", err)
+			fmt.Printf("请手动打开: %s\n", reportPath)
+		}
+	}
+
+	fmt.Println("\n所有文件审核完成！")
+	return nil
 }
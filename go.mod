@@ -4,6 +4,8 @@ go 1.21
 
 require (
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/crypto v0.31.0
+	golang.org/x/text v0.21.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
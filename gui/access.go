@@ -0,0 +1,167 @@
+package gui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// accessPolicy 限制网页上可以使用的目录和配置文件
+// 网页端供团队共用时，请求中的工作副本目录、源代码路径、配置文件路径都由浏览器提交，不加限制会读取服务器上的任意文件
+type accessPolicy struct {
+	roots   []string // 允许访问的目录，为空时不限制（本机使用）
+	configs []string // 允许加载的配置文件，为空时为当前目录的 config.yaml 和 config 目录下的 yaml 文件
+}
+
+// errForbidden 请求的路径不在允许范围内
+type errForbidden struct {
+	path string
+}
+
+func (e *errForbidden) Error() string {
+	return fmt.Sprintf("不允许访问 %s，请在 server.allowed_roots 中配置允许的目录", e.path)
+}
+
+// checkPath 检查路径是否在允许的目录下，符号链接按实际指向的位置判断
+func (a *accessPolicy) checkPath(path string) error {
+	if len(a.roots) == 0 {
+		return nil
+	}
+	target, err := realPath(path)
+	if err != nil {
+		return err
+	}
+	for _, root := range a.roots {
+		dir, err := realPath(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return &errForbidden{path: path}
+}
+
+// realPath 返回绝对路径，存在时解析符号链接
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// configFiles 返回网页上可以选择的配置文件
+func (a *accessPolicy) configFiles() []string {
+	if len(a.configs) > 0 {
+		var configs []string
+		for _, path := range a.configs {
+			if _, err := os.Stat(path); err == nil {
+				configs = append(configs, path)
+			}
+		}
+		return configs
+	}
+
+	var configs []string
+
+	// 检查根目录的 config.yaml
+	if _, err := os.Stat("config.yaml"); err == nil {
+		configs = append(configs, "config.yaml")
+	}
+
+	// 检查 config 目录下的所有 yaml 文件
+	if entries, err := os.ReadDir("config"); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				name := entry.Name()
+				if strings.HasSuffix(strings.ToLower(name), ".yaml") || strings.HasSuffix(strings.ToLower(name), ".yml") {
+					configs = append(configs, "config/"+name)
+				}
+			}
+		}
+	}
+	return configs
+}
+
+// checkConfig 只允许加载 configFiles 中列出的配置文件
+func (a *accessPolicy) checkConfig(path string) error {
+	for _, allowed := range a.configFiles() {
+		if filepath.Clean(allowed) == filepath.Clean(path) {
+			return nil
+		}
+	}
+	return &errForbidden{path: path}
+}
+
+// respondAccessError 路径不允许访问时返回 403，其他错误返回 400
+func respondAccessError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if _, ok := err.(*errForbidden); ok {
+		status = http.StatusForbidden
+	}
+	respondJSON(w, map[string]interface{}{"error": err.Error()}, status)
+}
+
+var plainOwnerName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+
+// reportOwnerDir 登录用户的报告子目录，未启用登录时为空（报告直接放在报告目录下）
+// 用户名含有路径分隔符等字符时使用哈希，避免不同用户映射到同一目录
+func reportOwnerDir(user string) string {
+	if user == "" {
+		return ""
+	}
+	if plainOwnerName.MatchString(user) {
+		return user
+	}
+	sum := sha256.Sum256([]byte(user))
+	return "user-" + hex.EncodeToString(sum[:8])
+}
+
+// reportsFS 报告目录的文件系统，不提供审计日志（*.jsonl 及轮转后的 *.jsonl.N）
+// 旧配置或自定义配置可能把审计日志放在报告目录中，日志含有发送给 AI 的代码和原始响应，不能通过 /reports/ 访问
+type reportsFS struct {
+	http.FileSystem
+}
+
+func (fsys reportsFS) Open(name string) (http.File, error) {
+	if isAuditLog(name) {
+		return nil, os.ErrNotExist
+	}
+	f, err := fsys.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return reportsFile{f}, nil
+}
+
+// reportsFile 目录列表中同样不显示审计日志
+type reportsFile struct {
+	http.File
+}
+
+func (f reportsFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+	kept := infos[:0]
+	for _, info := range infos {
+		if !isAuditLog(info.Name()) {
+			kept = append(kept, info)
+		}
+	}
+	return kept, err
+}
+
+func isAuditLog(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	return strings.HasSuffix(base, ".jsonl") || strings.Contains(base, ".jsonl.")
+}
@@ -0,0 +1,152 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+// errInvalidCredentials 用户名或密码错误（不区分用户不存在和密码错误）
+var errInvalidCredentials = errors.New("用户名或密码错误")
+
+// Authenticator 校验用户名和密码
+type Authenticator interface {
+	Authenticate(username, password string) error
+}
+
+// NewAuthenticator 根据配置创建认证方式，mode 为 none 或空时返回 nil（不需要登录）
+func NewAuthenticator(cfg *config.AuthConfig) (Authenticator, error) {
+	switch cfg.Mode {
+	case "", "none":
+		return nil, nil
+	case "local":
+		if cfg.UsersFile == "" {
+			return nil, fmt.Errorf("auth.mode 为 local 时必须配置 users_file")
+		}
+		return &localAuth{path: cfg.UsersFile}, nil
+	case "ldap":
+		if cfg.LDAP.URL == "" || cfg.LDAP.BindDN == "" {
+			return nil, fmt.Errorf("auth.mode 为 ldap 时必须配置 ldap.url 和 ldap.bind_dn")
+		}
+		timeout := time.Duration(cfg.LDAP.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return &ldapAuth{
+			url:                cfg.LDAP.URL,
+			bindDN:             cfg.LDAP.BindDN,
+			insecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			timeout:            timeout,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的认证方式: %s (支持: none, local, ldap)", cfg.Mode)
+	}
+}
+
+// UsersFile 本地用户文件
+//
+//	users:
+//	  - username: zhangsan
+//	    password_hash: $2a$10$...
+type UsersFile struct {
+	Users []LocalUser `yaml:"users"`
+}
+
+// LocalUser 本地用户，密码使用 bcrypt 保存
+type LocalUser struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"`
+}
+
+// localAuth 使用本地用户文件认证，每次登录时重新读取文件，修改用户无需重启服务
+type localAuth struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (a *localAuth) Authenticate(username, password string) error {
+	a.mu.Lock()
+	users, err := LoadUsersFile(a.path)
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users.Users {
+		if u.Username == username {
+			if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+				return errInvalidCredentials
+			}
+			return nil
+		}
+	}
+	// 用户不存在时同样计算一次哈希，避免通过响应时间判断用户是否存在
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	return errInvalidCredentials
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// LoadUsersFile 读取本地用户文件，文件不存在时返回空列表
+func LoadUsersFile(path string) (*UsersFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &UsersFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用户文件失败: %w", err)
+	}
+	var users UsersFile
+	if err := yaml.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("解析用户文件失败: %w", err)
+	}
+	return &users, nil
+}
+
+// SetLocalUser 添加用户或修改已有用户的密码
+func SetLocalUser(path, username, password string) error {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return fmt.Errorf("用户名和密码不能为空")
+	}
+
+	users, err := LoadUsersFile(path)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("计算密码哈希失败: %w", err)
+	}
+
+	found := false
+	for i := range users.Users {
+		if users.Users[i].Username == username {
+			users.Users[i].PasswordHash = string(hash)
+			found = true
+		}
+	}
+	if !found {
+		users.Users = append(users.Users, LocalUser{Username: username, PasswordHash: string(hash)})
+	}
+
+	data, err := yaml.Marshal(users)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
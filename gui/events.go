@@ -0,0 +1,166 @@
+package gui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志流事件类型
+const (
+	eventLog         = "log"          // 日志文本
+	eventProgress    = "progress"     // 开始审核某个文件
+	eventFileDone    = "file-done"    // 文件审核结束（完成、失败或跳过）
+	eventReportReady = "report-ready" // 报告已生成
+	eventJobState    = "job-state"    // 任务状态变化
+)
+
+// streamEvent 日志流中的一条事件，ID 在同一个流内递增，用于断线重连后补发
+type streamEvent struct {
+	ID   int64
+	Type string
+	Data string
+}
+
+// broadcaster 把事件分发给所有订阅者，并保留最近的事件供重连的浏览器补发
+// 订阅者只接收“有新事件”的通知，再按自己的进度读取历史，不会因为某个订阅者读得慢而丢失或抢走其他订阅者的消息
+type broadcaster struct {
+	mu      sync.Mutex
+	nextID  int64
+	history []streamEvent
+	limit   int
+	subs    map[chan struct{}]struct{}
+}
+
+func newBroadcaster(limit int) *broadcaster {
+	return &broadcaster{
+		nextID: 1,
+		limit:  limit,
+		subs:   make(map[chan struct{}]struct{}),
+	}
+}
+
+// publish 发布事件，data 为字符串时原样发送，否则编码为 JSON
+func (b *broadcaster) publish(typ string, data interface{}) {
+	text, ok := data.(string)
+	if !ok {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return
+		}
+		text = string(raw)
+	}
+
+	b.mu.Lock()
+	b.history = append(b.history, streamEvent{ID: b.nextID, Type: typ, Data: text})
+	b.nextID++
+	if len(b.history) > b.limit {
+		b.history = append(b.history[:0:0], b.history[len(b.history)-b.limit:]...)
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// 已有未处理的通知
+		}
+	}
+	b.mu.Unlock()
+}
+
+// since 返回 ID 大于 lastID 的事件
+func (b *broadcaster) since(lastID int64) []streamEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, e := range b.history {
+		if e.ID > lastID {
+			return append([]streamEvent(nil), b.history[i:]...)
+		}
+	}
+	return nil
+}
+
+// lastID 返回最后一条事件的 ID
+func (b *broadcaster) lastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID - 1
+}
+
+// subscribe 订阅新事件通知，返回的函数用于取消订阅
+func (b *broadcaster) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+// sseKeepAlive 没有事件时发送注释行的间隔，防止代理断开空闲连接
+const sseKeepAlive = 30 * time.Second
+
+// serveEvents 以 SSE 格式输出事件流
+// 浏览器重连时通过 Last-Event-ID 请求头（或 last_event_id 参数）补发断开期间的事件；
+// 没有提供时 replay 为 true 则从头发送所有保留的事件，否则只发送新事件
+func serveEvents(w http.ResponseWriter, r *http.Request, b *broadcaster, replay bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID := int64(0)
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" {
+		lastID, _ = strconv.ParseInt(lastEventID, 10, 64)
+	} else if !replay {
+		lastID = b.lastID()
+	}
+
+	notify, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for _, e := range b.since(lastID) {
+			writeEvent(w, e)
+			lastID = e.ID
+		}
+		flusher.Flush()
+
+		select {
+		case <-notify:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e streamEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\n", e.ID, e.Type)
+	for _, line := range strings.Split(e.Data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	fmt.Fprint(w, "\n")
+}
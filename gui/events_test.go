@@ -0,0 +1,164 @@
+package gui
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcasterSince(t *testing.T) {
+	b := newBroadcaster(3)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		b.publish(eventLog, msg)
+	}
+	b.publish(eventJobState, map[string]string{"state": "done"})
+
+	tests := []struct {
+		name   string
+		lastID int64
+		want   []int64
+	}{
+		{"从头补发只有保留的事件", 0, []int64{3, 4, 5}},
+		{"断线后补发", 3, []int64{4, 5}},
+		{"没有新事件", 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := b.since(tt.lastID)
+			if len(events) != len(tt.want) {
+				t.Fatalf("since(%d) = %+v, want IDs %v", tt.lastID, events, tt.want)
+			}
+			for i, e := range events {
+				if e.ID != tt.want[i] {
+					t.Errorf("since(%d)[%d].ID = %d, want %d", tt.lastID, i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := b.lastID(); got != 5 {
+		t.Errorf("lastID() = %d, want 5", got)
+	}
+	if e := b.since(4)[0]; e.Type != eventJobState || e.Data != `{"state":"done"}` {
+		t.Errorf("JSON event = %+v", e)
+	}
+}
+
+func TestBroadcasterSubscribers(t *testing.T) {
+	b := newBroadcaster(10)
+	// 两个订阅者各自按进度读取，慢的订阅者不会让另一个丢失消息
+	notifyA, unsubA := b.subscribe()
+	notifyB, unsubB := b.subscribe()
+	defer unsubB()
+
+	b.publish(eventLog, "1")
+	b.publish(eventLog, "2")
+
+	for name, ch := range map[string]<-chan struct{}{"A": notifyA, "B": notifyB} {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Errorf("subscriber %s was not notified", name)
+		}
+	}
+	if n := len(b.since(0)); n != 2 {
+		t.Errorf("since(0) = %d events, want 2", n)
+	}
+
+	unsubA()
+	b.publish(eventLog, "3")
+	select {
+	case <-notifyA:
+		t.Error("unsubscribed subscriber was notified")
+	default:
+	}
+}
+
+func TestServeEventsReplay(t *testing.T) {
+	tests := []struct {
+		name        string
+		replay      bool
+		lastEventID string
+		want        []string
+		notWant     []string
+	}{
+		{"补发所有事件", true, "", []string{"id: 1\n", "id: 2\n", "data: line1\ndata: line2\n"}, nil},
+		{"只发送新事件", false, "", nil, []string{"id: 1\n", "id: 2\n"}},
+		{"按 Last-Event-ID 补发", false, "1", []string{"id: 2\n"}, []string{"id: 1\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBroadcaster(10)
+			b.publish(eventLog, "first")
+			b.publish(eventLog, "line1\nline2")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequest(http.MethodGet, "/api/events", nil).WithContext(ctx)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			rec := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				serveEvents(rec, req, b, tt.replay)
+				close(done)
+			}()
+			// 处理函数补发完历史事件后进入等待，再结束请求
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			<-done
+
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, "retry: 3000\n\n") {
+				t.Errorf("body does not start with retry: %q", body)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(body, s) {
+					t.Errorf("body does not contain %q:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %q:\n%s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestServeEventsLive(t *testing.T) {
+	b := newBroadcaster(10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(w, r, b, false)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		b.publish(eventProgress, "started")
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	deadline := time.AfterFunc(5*time.Second, func() { resp.Body.Close() })
+	defer deadline.Stop()
+	for scanner.Scan() {
+		if scanner.Text() == "event: "+eventProgress {
+			return
+		}
+	}
+	t.Fatal("live event not received")
+}
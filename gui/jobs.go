@@ -0,0 +1,597 @@
+package gui
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"svn-ai-reviewer/internal/audit"
+	"svn-ai-reviewer/internal/report"
+)
+
+// JobState 审核任务状态
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// finished 任务是否已结束
+func (s JobState) finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// 单个文件的审核状态
+const (
+	fileWaiting = "pending"
+	fileRunning = "running"
+	fileDone    = "done"
+	fileFailed  = "failed"
+	fileSkipped = "skipped"
+)
+
+// maxJobHistory 保留的已结束任务数量，超过时删除最早的任务记录
+const maxJobHistory = 200
+
+// maxJobEvents 每个任务的事件流保留的事件数量
+const maxJobEvents = 2000
+
+// JobFile 任务中单个文件的审核进度和结果
+type JobFile struct {
+	Path   string `json:"path"`
+	State  string `json:"state"`
+	Score  int    `json:"score,omitempty"`
+	Issues int    `json:"issues,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Job 一次后台审核任务
+type Job struct {
+	ID         string     `json:"id"`
+	Mode       string     `json:"mode"` // "local", "online" or "source"
+	Title      string     `json:"title"`
+	User       string     `json:"user,omitempty"`
+	State      JobState   `json:"state"`
+	Total      int        `json:"total"`
+	Completed  int        `json:"completed"` // 已结束（完成、失败或跳过）的文件数
+	Files      []JobFile  `json:"files,omitempty"`
+	ReportURL  string     `json:"report_url,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// jobFunc 任务的执行函数，返回报告 URL。ctx 在任务被取消时结束
+type jobFunc func(ctx context.Context, h *jobHandle) (string, error)
+
+// jobManager 管理后台审核任务：排队、并发限制、取消，并把任务记录保存到磁盘，服务重启后仍可查看
+type jobManager struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	streams map[string]*jobStream // 本次服务启动后提交的任务
+	dir     string
+	slots   chan struct{}
+	running sync.WaitGroup // 未结束的任务，停止服务时等待
+}
+
+// jobStream 任务的取消函数和事件流，只保存在内存中
+type jobStream struct {
+	cancel  context.CancelFunc // 任务结束后为 nil
+	events  *broadcaster       // 任务自己的事件流，任务结束后仍保留供查看
+	forward *broadcaster       // 同时转发到提交任务的标签页的日志流
+}
+
+func newJobManager(dir string, maxRunning int) *jobManager {
+	m := &jobManager{
+		jobs:    make(map[string]*Job),
+		streams: make(map[string]*jobStream),
+		dir:     dir,
+		slots:   make(chan struct{}, maxRunning),
+	}
+	if err := m.load(); err != nil {
+		log.Printf("⚠️  读取审核任务记录失败: %v", err)
+	}
+	return m
+}
+
+// load 读取保存的任务记录，上次服务退出时未结束的任务标记为失败
+func (m *jobManager) load() error {
+	entries, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.ID == "" {
+			log.Printf("⚠️  跳过无效的任务记录 %s", e.Name())
+			continue
+		}
+		if !job.State.finished() {
+			now := time.Now()
+			job.State = JobFailed
+			job.Error = "服务重启，任务中断"
+			job.FinishedAt = &now
+			for i := range job.Files {
+				if job.Files[i].State == fileWaiting || job.Files[i].State == fileRunning {
+					job.Files[i].State = fileSkipped
+				}
+			}
+			m.save(&job)
+		}
+		m.jobs[job.ID] = &job
+	}
+	return nil
+}
+
+// submit 创建任务并在后台执行，返回任务的副本。任务的事件同时转发到 forward（可以为 nil）
+// release 不为空时在任务结束后调用（包括排队期间被取消、run 没有执行的情况），用于释放任务占用的资源
+func (m *jobManager) submit(mode, title, user string, files []string, forward *broadcaster, run jobFunc, release func()) *Job {
+	job := &Job{
+		ID:        newJobID(),
+		Mode:      mode,
+		Title:     title,
+		User:      user,
+		State:     JobQueued,
+		Total:     len(files),
+		CreatedAt: time.Now(),
+	}
+	for _, f := range files {
+		job.Files = append(job.Files, JobFile{Path: f, State: fileWaiting})
+	}
+
+	ctx, cancel := context.WithCancel(audit.WithUser(context.Background(), user))
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.streams[job.ID] = &jobStream{
+		cancel:  cancel,
+		events:  newBroadcaster(maxJobEvents),
+		forward: forward,
+	}
+	m.pruneLocked()
+	snapshot := m.snapshotLocked(job)
+	m.mu.Unlock()
+	m.save(snapshot)
+	m.emitState(snapshot)
+
+	m.running.Add(1)
+	go m.run(ctx, job.ID, run, release)
+	return snapshot
+}
+
+func (m *jobManager) run(ctx context.Context, id string, run jobFunc, release func()) {
+	defer m.running.Done()
+	if release != nil {
+		defer release()
+	}
+	defer func() {
+		m.mu.Lock()
+		if st := m.streams[id]; st != nil && st.cancel != nil {
+			st.cancel()
+			st.cancel = nil
+		}
+		m.mu.Unlock()
+	}()
+
+	// 等待空闲的执行位置，排队期间可以被取消
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		m.emit(id, eventLog, "⏹ 审核已取消")
+		m.emitState(m.update(id, func(j *Job) { j.finish(JobCancelled, "") }))
+		return
+	}
+
+	m.emitState(m.update(id, func(j *Job) {
+		now := time.Now()
+		j.State = JobRunning
+		j.StartedAt = &now
+	}))
+
+	reportURL, err := run(ctx, &jobHandle{m: m, id: id})
+
+	if ctx.Err() != nil {
+		m.emit(id, eventLog, "⏹ 审核已取消")
+	}
+	m.emitState(m.update(id, func(j *Job) {
+		j.ReportURL = reportURL
+		switch {
+		case ctx.Err() != nil:
+			j.finish(JobCancelled, "")
+		case err != nil:
+			j.finish(JobFailed, err.Error())
+		default:
+			j.finish(JobDone, "")
+		}
+	}))
+}
+
+// emit 向任务的事件流和提交任务的标签页的日志流发布事件
+func (m *jobManager) emit(id, typ string, data interface{}) {
+	m.mu.Lock()
+	st := m.streams[id]
+	m.mu.Unlock()
+	if st == nil {
+		return
+	}
+	st.events.publish(typ, data)
+	if st.forward != nil {
+		st.forward.publish(typ, data)
+	}
+}
+
+// jobStateEvent job-state 事件的内容
+type jobStateEvent struct {
+	JobID     string   `json:"job_id"`
+	State     JobState `json:"state"`
+	Total     int      `json:"total"`
+	Completed int      `json:"completed"`
+	ReportURL string   `json:"report_url,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func newJobStateEvent(job *Job) jobStateEvent {
+	return jobStateEvent{
+		JobID:     job.ID,
+		State:     job.State,
+		Total:     job.Total,
+		Completed: job.Completed,
+		ReportURL: job.ReportURL,
+		Error:     job.Error,
+	}
+}
+
+func (m *jobManager) emitState(job *Job) {
+	if job != nil {
+		m.emit(job.ID, eventJobState, newJobStateEvent(job))
+	}
+}
+
+// finish 结束任务，未处理的文件标记为跳过
+func (j *Job) finish(state JobState, errMsg string) {
+	now := time.Now()
+	j.State = state
+	j.Error = errMsg
+	j.FinishedAt = &now
+	for i := range j.Files {
+		if j.Files[i].State == fileWaiting || j.Files[i].State == fileRunning {
+			j.Files[i].State = fileSkipped
+		}
+	}
+}
+
+// cancel 取消排队中或运行中的任务，任务不存在或已结束时返回 false
+func (m *jobManager) cancel(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.streams[id]
+	if st == nil || st.cancel == nil {
+		return false
+	}
+	st.cancel()
+	return true
+}
+
+// cancelQueued 取消所有排队中的任务，返回取消的数量
+func (m *jobManager) cancelQueued() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for id, job := range m.jobs {
+		if st := m.streams[id]; job.State == JobQueued && st != nil && st.cancel != nil {
+			st.cancel()
+			n++
+		}
+	}
+	return n
+}
+
+// cancelAll 取消所有未结束的任务
+func (m *jobManager) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, st := range m.streams {
+		if st.cancel != nil {
+			st.cancel()
+		}
+	}
+}
+
+// runningCount 返回正在运行的任务数量
+func (m *jobManager) runningCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, job := range m.jobs {
+		if job.State == JobRunning {
+			n++
+		}
+	}
+	return n
+}
+
+// wait 等待所有任务结束
+func (m *jobManager) wait() {
+	m.running.Wait()
+}
+
+// events 返回任务的事件流。服务重启前的任务没有保留事件，只返回一条记录最终状态的事件
+func (m *jobManager) events(id string) *broadcaster {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st := m.streams[id]; st != nil {
+		return st.events
+	}
+	job := m.jobs[id]
+	if job == nil {
+		return nil
+	}
+	b := newBroadcaster(1)
+	b.publish(eventJobState, newJobStateEvent(job))
+	return b
+}
+
+// get 返回任务的副本
+func (m *jobManager) get(id string) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := m.jobs[id]
+	if job == nil {
+		return nil
+	}
+	return m.snapshotLocked(job)
+}
+
+// list 按创建时间倒序返回用户的任务列表（不含文件明细），all 为 true 时返回所有用户的任务
+func (m *jobManager) list(user string, all bool) []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if !all && job.User != user {
+			continue
+		}
+		j := *job
+		j.Files = nil
+		jobs = append(jobs, &j)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].CreatedAt.After(jobs[b].CreatedAt)
+	})
+	return jobs
+}
+
+// update 修改任务并保存到磁盘，返回修改后的副本
+func (m *jobManager) update(id string, fn func(*Job)) *Job {
+	m.mu.Lock()
+	job := m.jobs[id]
+	if job == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	fn(job)
+	job.Completed = 0
+	for _, f := range job.Files {
+		if f.State != fileWaiting && f.State != fileRunning {
+			job.Completed++
+		}
+	}
+	snapshot := m.snapshotLocked(job)
+	m.mu.Unlock()
+	m.save(snapshot)
+	return snapshot
+}
+
+func (m *jobManager) snapshotLocked(job *Job) *Job {
+	j := *job
+	j.Files = append([]JobFile(nil), job.Files...)
+	return &j
+}
+
+// pruneLocked 删除超出保留数量的已结束任务，调用方需持有锁
+func (m *jobManager) pruneLocked() {
+	var finished []*Job
+	for _, job := range m.jobs {
+		if job.State.finished() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxJobHistory {
+		return
+	}
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].CreatedAt.Before(finished[b].CreatedAt)
+	})
+	for _, job := range finished[:len(finished)-maxJobHistory] {
+		delete(m.jobs, job.ID)
+		delete(m.streams, job.ID)
+		os.Remove(m.path(job.ID))
+	}
+}
+
+func (m *jobManager) save(job *Job) {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		log.Printf("⚠️  创建任务记录目录失败: %v", err)
+		return
+	}
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return
+	}
+	// 先写临时文件再改名，避免进程退出时留下不完整的记录
+	tmp := m.path(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("⚠️  保存任务记录失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, m.path(job.ID)); err != nil {
+		log.Printf("⚠️  保存任务记录失败: %v", err)
+	}
+}
+
+func (m *jobManager) path(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+// jobHandle 供任务执行函数输出日志、汇报单个文件的进度
+type jobHandle struct {
+	m  *jobManager
+	id string
+}
+
+// fileEvent progress 和 file-done 事件的内容
+type fileEvent struct {
+	JobID     string `json:"job_id"`
+	Index     int    `json:"index"`
+	Total     int    `json:"total"`
+	Completed int    `json:"completed"`
+	JobFile
+}
+
+// log 输出任务日志
+func (h *jobHandle) log(format string, args ...interface{}) {
+	h.m.emit(h.id, eventLog, fmt.Sprintf(format, args...))
+}
+
+// reportReady 报告已生成
+func (h *jobHandle) reportReady(url string) {
+	h.m.emit(h.id, eventReportReady, map[string]string{"job_id": h.id, "url": url})
+}
+
+// fileStarted 第 i 个文件开始审核
+func (h *jobHandle) fileStarted(i int) {
+	h.setFile(i, eventProgress, func(f *JobFile) { f.State = fileRunning })
+}
+
+// fileSkipped 第 i 个文件没有需要审核的内容
+func (h *jobHandle) fileSkipped(i int) {
+	h.setFile(i, eventFileDone, func(f *JobFile) { f.State = fileSkipped })
+}
+
+// fileFinished 根据审核结果记录第 i 个文件的状态
+func (h *jobHandle) fileFinished(i int, review report.FileReview) {
+	h.setFile(i, eventFileDone, func(f *JobFile) {
+		switch {
+		case review.Error != nil:
+			f.State = fileFailed
+			f.Error = review.Error.Error()
+			if review.Result != nil && review.Result.ReviewData != nil {
+				f.Issues = len(review.Result.ReviewData.Issues)
+			}
+		case review.Result == nil || review.Result.Skipped != "":
+			f.State = fileSkipped
+		default:
+			f.State = fileDone
+			if data := review.Result.ReviewData; data != nil {
+				f.Score = data.Score
+				f.Issues = len(data.Issues)
+			}
+		}
+	})
+}
+
+func (h *jobHandle) setFile(i int, typ string, fn func(*JobFile)) {
+	job := h.m.update(h.id, func(j *Job) {
+		if i >= 0 && i < len(j.Files) {
+			fn(&j.Files[i])
+		}
+	})
+	if job == nil || i < 0 || i >= len(job.Files) {
+		return
+	}
+	h.m.emit(h.id, typ, fileEvent{
+		JobID:     job.ID,
+		Index:     i,
+		Total:     job.Total,
+		Completed: job.Completed,
+		JobFile:   job.Files[i],
+	})
+}
+
+// handleJobs 任务接口:
+//
+//	GET  /api/jobs             任务列表
+//	GET  /api/jobs/{id}        任务详情（含每个文件的进度）
+//	GET  /api/jobs/{id}/events 任务的事件流（SSE），从头发送任务的所有事件
+//	POST /api/jobs/{id}/cancel 取消任务
+//
+// 启用登录时只能查看和取消自己的任务
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	sess := r.Context().Value(sessionKey{}).(*session)
+	all := s.opts.Auth == nil
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/jobs"), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		respondJSON(w, map[string]interface{}{
+			"success": true,
+			"jobs":    s.jobs.list(sess.user, all),
+		}, http.StatusOK)
+		return
+	}
+
+	id, action, _ := strings.Cut(rest, "/")
+	job := s.jobs.get(id)
+	if job == nil || (!all && job.User != sess.user) {
+		respondJSON(w, map[string]interface{}{"error": "任务不存在"}, http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		respondJSON(w, map[string]interface{}{"success": true, "job": job}, http.StatusOK)
+	case action == "events" && r.Method == http.MethodGet:
+		serveEvents(w, r, s.jobs.events(id), true)
+	case action == "cancel" && r.Method == http.MethodPost:
+		if !s.jobs.cancel(id) {
+			respondJSON(w, map[string]interface{}{"error": "任务已结束"}, http.StatusConflict)
+			return
+		}
+		respondJSON(w, map[string]interface{}{"success": true, "message": "已请求取消任务"}, http.StatusOK)
+	case action == "" || action == "events" || action == "cancel":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("生成任务 ID 失败: %v", err))
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
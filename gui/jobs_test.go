@@ -0,0 +1,197 @@
+package gui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitJob 等待任务进入指定状态
+func waitJob(t *testing.T, m *jobManager, id string, state JobState) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job := m.get(id); job != nil && job.State == state {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach state %s, got %+v", id, state, m.get(id))
+	return nil
+}
+
+func TestJobManagerRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       jobFunc
+		wantState JobState
+		wantError string
+		wantFiles []string
+	}{
+		{
+			name: "完成",
+			run: func(ctx context.Context, h *jobHandle) (string, error) {
+				h.fileStarted(0)
+				h.fileSkipped(0)
+				h.fileStarted(1)
+				h.fileSkipped(1)
+				return "/reports/a.html", nil
+			},
+			wantState: JobDone,
+			wantFiles: []string{fileSkipped, fileSkipped},
+		},
+		{
+			name: "失败时未处理的文件标记为跳过",
+			run: func(ctx context.Context, h *jobHandle) (string, error) {
+				h.fileStarted(0)
+				return "", errors.New("创建AI客户端失败")
+			},
+			wantState: JobFailed,
+			wantError: "创建AI客户端失败",
+			wantFiles: []string{fileSkipped, fileSkipped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m := newJobManager(dir, 1)
+			released := make(chan struct{})
+			job := m.submit("local", "本地审核", "zhangsan", []string{"a.go", "b.go"}, nil, tt.run, func() { close(released) })
+			m.wait()
+
+			got := waitJob(t, m, job.ID, tt.wantState)
+			if got.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", got.Error, tt.wantError)
+			}
+			if got.Completed != len(tt.wantFiles) {
+				t.Errorf("Completed = %d, want %d", got.Completed, len(tt.wantFiles))
+			}
+			for i, f := range got.Files {
+				if f.State != tt.wantFiles[i] {
+					t.Errorf("Files[%d].State = %q, want %q", i, f.State, tt.wantFiles[i])
+				}
+			}
+			select {
+			case <-released:
+			default:
+				t.Error("release was not called")
+			}
+
+			// 任务记录保存到磁盘，服务重启后仍可查看
+			reloaded := newJobManager(dir, 1).get(job.ID)
+			if reloaded == nil || reloaded.State != tt.wantState || reloaded.User != "zhangsan" {
+				t.Errorf("reloaded job = %+v", reloaded)
+			}
+		})
+	}
+}
+
+func TestJobManagerCancel(t *testing.T) {
+	m := newJobManager(t.TempDir(), 1)
+
+	started := make(chan struct{})
+	running := m.submit("online", "运行中", "", []string{"a.go"}, nil, func(ctx context.Context, h *jobHandle) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, nil)
+	<-started
+
+	// 只有一个执行位置，第二个任务排队
+	queuedRan := false
+	released := make(chan struct{})
+	queued := m.submit("online", "排队中", "", []string{"b.go"}, nil, func(ctx context.Context, h *jobHandle) (string, error) {
+		queuedRan = true
+		return "", nil
+	}, func() { close(released) })
+	waitJob(t, m, queued.ID, JobQueued)
+
+	if n := m.cancelQueued(); n != 1 {
+		t.Errorf("cancelQueued() = %d, want 1", n)
+	}
+	waitJob(t, m, queued.ID, JobCancelled)
+	// 排队期间取消的任务不执行，但仍然释放占用的资源
+	<-released
+	if queuedRan {
+		t.Error("cancelled queued job was run")
+	}
+
+	if !m.cancel(running.ID) {
+		t.Error("cancel(running) = false, want true")
+	}
+	waitJob(t, m, running.ID, JobCancelled)
+	m.wait()
+
+	if m.cancel(running.ID) {
+		t.Error("cancel(finished) = true, want false")
+	}
+	if m.cancel("missing") {
+		t.Error("cancel(missing) = true, want false")
+	}
+}
+
+func TestJobManagerLoadInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	job := Job{
+		ID:    "interrupted",
+		State: JobRunning,
+		Total: 2,
+		Files: []JobFile{{Path: "a.go", State: fileDone}, {Path: "b.go", State: fileRunning}},
+	}
+	data, _ := json.Marshal(job)
+	if err := os.WriteFile(filepath.Join(dir, "interrupted.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// 无效的记录跳过
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600)
+
+	m := newJobManager(dir, 1)
+	got := m.get("interrupted")
+	if got == nil {
+		t.Fatal("interrupted job not loaded")
+	}
+	if got.State != JobFailed || got.Error == "" {
+		t.Errorf("State = %q, Error = %q, want failed with error", got.State, got.Error)
+	}
+	if got.Files[0].State != fileDone || got.Files[1].State != fileSkipped {
+		t.Errorf("Files = %+v", got.Files)
+	}
+	if len(m.list("", true)) != 1 {
+		t.Errorf("list() = %v, want 1 job", m.list("", true))
+	}
+
+	// 已结束的任务只返回一条记录最终状态的事件
+	events := m.events("interrupted").since(0)
+	if len(events) != 1 || events[0].Type != eventJobState {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestJobManagerList(t *testing.T) {
+	m := newJobManager(t.TempDir(), 2)
+	done := func(ctx context.Context, h *jobHandle) (string, error) { return "", nil }
+	m.submit("local", "a", "zhangsan", nil, nil, done, nil)
+	m.submit("local", "b", "lisi", nil, nil, done, nil)
+	m.wait()
+
+	tests := []struct {
+		user string
+		all  bool
+		want int
+	}{
+		{"zhangsan", false, 1},
+		{"lisi", false, 1},
+		{"wangwu", false, 0},
+		{"wangwu", true, 2},
+	}
+	for _, tt := range tests {
+		if got := m.list(tt.user, tt.all); len(got) != tt.want {
+			t.Errorf("list(%q, %v) = %d jobs, want %d", tt.user, tt.all, len(got), tt.want)
+		}
+	}
+}
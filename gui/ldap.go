@@ -0,0 +1,246 @@
+package gui
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ldapAuth 使用 LDAP 简单绑定（simple bind）认证：用登录的用户名和密码绑定成功即认为认证通过
+// 只实现了绑定所需的最小 BER 编解码，不依赖第三方库
+type ldapAuth struct {
+	url                string
+	bindDN             string
+	insecureSkipVerify bool
+	timeout            time.Duration
+}
+
+// LDAP 结果码
+const (
+	ldapSuccess            = 0
+	ldapInvalidCredentials = 49
+)
+
+// BER 标签
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	ldapBindReq    = 0x60 // [APPLICATION 0] 构造类型
+	ldapBindResp   = 0x61 // [APPLICATION 1] 构造类型
+	ldapUnbindReq  = 0x42 // [APPLICATION 2] 基本类型
+	ldapAuthSimple = 0x80 // [0] 基本类型
+)
+
+func (a *ldapAuth) Authenticate(username, password string) error {
+	// 空密码的简单绑定在很多服务器上会被当作匿名绑定而成功，必须拒绝
+	if username == "" || password == "" {
+		return errInvalidCredentials
+	}
+	for _, r := range username {
+		if r < 0x20 || r == 0x7f {
+			return errInvalidCredentials
+		}
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return fmt.Errorf("连接 LDAP 服务器失败: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(a.timeout))
+
+	dn := strings.ReplaceAll(a.bindDN, "{username}", escapeDN(username))
+	if _, err := conn.Write(encodeBindRequest(1, dn, password)); err != nil {
+		return fmt.Errorf("发送 LDAP 绑定请求失败: %w", err)
+	}
+
+	code, message, err := readBindResponse(bufio.NewReader(conn))
+	if err != nil {
+		return fmt.Errorf("读取 LDAP 绑定响应失败: %w", err)
+	}
+
+	// 礼貌地结束会话，忽略错误
+	conn.Write(berTLV(berSequence, append(berInt(2), ldapUnbindReq, 0x00)))
+
+	switch code {
+	case ldapSuccess:
+		return nil
+	case ldapInvalidCredentials:
+		return errInvalidCredentials
+	default:
+		return fmt.Errorf("LDAP 绑定失败（结果码 %d）: %s", code, message)
+	}
+}
+
+func (a *ldapAuth) dial() (net.Conn, error) {
+	u, err := url.Parse(a.url)
+	if err != nil {
+		return nil, fmt.Errorf("LDAP 地址无效: %w", err)
+	}
+	dialer := &net.Dialer{Timeout: a.timeout}
+
+	switch u.Scheme {
+	case "ldap":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		return dialer.Dial("tcp", host)
+	case "ldaps":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		return tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: a.insecureSkipVerify,
+		})
+	default:
+		return nil, fmt.Errorf("不支持的 LDAP 地址: %s（支持 ldap:// 和 ldaps://）", a.url)
+	}
+}
+
+// escapeDN 按 RFC 4514 转义 DN 中的属性值
+func escapeDN(value string) string {
+	var sb strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`\,+"<>;=`, r),
+			i == 0 && (r == '#' || r == ' '),
+			i == len(value)-1 && r == ' ':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// encodeBindRequest 编码 LDAPv3 简单绑定请求
+func encodeBindRequest(messageID int, dn, password string) []byte {
+	var bind []byte
+	bind = append(bind, berInt(3)...)
+	bind = append(bind, berTLV(berOctetString, []byte(dn))...)
+	bind = append(bind, berTLV(ldapAuthSimple, []byte(password))...)
+
+	msg := berInt(messageID)
+	msg = append(msg, berTLV(ldapBindReq, bind)...)
+	return berTLV(berSequence, msg)
+}
+
+// readBindResponse 解析绑定响应，返回结果码和诊断信息
+func readBindResponse(r *bufio.Reader) (int, string, error) {
+	tag, msg, err := readTLV(r)
+	if err != nil {
+		return 0, "", err
+	}
+	if tag != berSequence {
+		return 0, "", fmt.Errorf("无效的 LDAP 消息")
+	}
+
+	// messageID
+	br := bufio.NewReader(bytes.NewReader(msg))
+	if tag, _, err = readTLV(br); err != nil || tag != berInteger {
+		return 0, "", fmt.Errorf("无效的 LDAP 消息 ID")
+	}
+	tag, resp, err := readTLV(br)
+	if err != nil {
+		return 0, "", err
+	}
+	if tag != ldapBindResp {
+		return 0, "", fmt.Errorf("意外的 LDAP 响应类型: 0x%02x", tag)
+	}
+
+	rr := bufio.NewReader(bytes.NewReader(resp))
+	tag, code, err := readTLV(rr)
+	if err != nil || tag != berEnumerated {
+		return 0, "", fmt.Errorf("无效的 LDAP 结果码")
+	}
+	if _, _, err := readTLV(rr); err != nil { // matchedDN
+		return 0, "", err
+	}
+	_, diagnostic, _ := readTLV(rr)
+	return decodeInt(code), string(diagnostic), nil
+}
+
+func berTLV(tag byte, value []byte) []byte {
+	out := []byte{tag}
+	n := len(value)
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	case n <= 0xffff:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, value...)
+}
+
+func berInt(v int) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(v)}, b...)
+		v >>= 8
+		if v == 0 && b[0] < 0x80 {
+			break
+		}
+	}
+	return berTLV(berInteger, b)
+}
+
+func decodeInt(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// maxLDAPMessage 绑定响应的长度上限，防止异常的服务器导致分配过大的内存
+const maxLDAPMessage = 1 << 20
+
+func readTLV(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 3 {
+			return 0, nil, fmt.Errorf("不支持的 BER 长度编码")
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			c, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = length<<8 | int(c)
+		}
+	}
+	if length > maxLDAPMessage {
+		return 0, nil, fmt.Errorf("LDAP 消息过长")
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return 0, nil, err
+	}
+	return tag, value, nil
+}
@@ -0,0 +1,243 @@
+package gui
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type sessionKey struct{}
+
+// requireLogin 确保请求属于一个会话：未启用登录时自动创建匿名会话，启用登录时未登录的请求跳转到登录页
+func (s *Server) requireLogin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := s.sessions.get(r)
+		if sess == nil {
+			if s.opts.Auth != nil {
+				s.unauthorized(w, r)
+				return
+			}
+			sess = s.sessions.create(w, r, "")
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, sess)))
+	}
+}
+
+// withWorkspace 找到标签页的工作区后调用处理函数，处理期间持有工作区的锁
+func (s *Server) withWorkspace(handler func(*workspace, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return s.requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		ws := s.tabWorkspace(r)
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		handler(ws, w, r)
+	})
+}
+
+// withWorkspaceStream 同 withWorkspace，但不持有工作区的锁，用于日志流等长连接
+func (s *Server) withWorkspaceStream(handler func(*workspace, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return s.requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		handler(s.tabWorkspace(r), w, r)
+	})
+}
+
+// tabWorkspace 按标签页 ID（请求头 X-Tab-ID 或参数 tab）找到请求所属会话的工作区
+func (s *Server) tabWorkspace(r *http.Request) *workspace {
+	sess := r.Context().Value(sessionKey{}).(*session)
+	tabID := r.Header.Get("X-Tab-ID")
+	if tabID == "" {
+		tabID = r.URL.Query().Get("tab")
+	}
+	return s.sessions.workspace(sess, tabID)
+}
+
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		respondJSON(w, map[string]interface{}{"error": "请先登录", "login": true}, http.StatusUnauthorized)
+		return
+	}
+	// r.RequestURI 是去掉 URL 前缀之前的原始地址
+	http.Redirect(w, r, s.opts.BasePath+"/login?next="+url.QueryEscape(r.RequestURI), http.StatusFound)
+}
+
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Auth == nil {
+		http.Redirect(w, r, s.opts.BasePath+"/", http.StatusFound)
+		return
+	}
+	tmpl, err := template.ParseFS(templates, "templates/login.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl.Execute(w, pageData{BasePath: s.opts.BasePath})
+}
+
+// handleSessionScript 所有页面共用的会话脚本（登录页之前也需要访问，不做登录检查）
+func handleSessionScript(w http.ResponseWriter, r *http.Request) {
+	data, err := templates.ReadFile("templates/session.js")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Write(data)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.opts.Auth == nil {
+		respondJSON(w, map[string]interface{}{"success": true}, http.StatusOK)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+
+	ip := clientIP(r)
+	if !s.limiter.allow(ip) {
+		respondJSON(w, map[string]interface{}{"error": "登录失败次数过多，请稍后再试"}, http.StatusTooManyRequests)
+		return
+	}
+
+	if err := s.opts.Auth.Authenticate(req.Username, req.Password); err != nil {
+		s.limiter.fail(ip)
+		log.Printf("登录失败: 用户 %q，来源 %s: %v", req.Username, ip, err)
+		msg := errInvalidCredentials.Error()
+		if err != errInvalidCredentials {
+			msg = "认证服务不可用，请联系管理员"
+		}
+		respondJSON(w, map[string]interface{}{"error": msg}, http.StatusUnauthorized)
+		return
+	}
+	s.limiter.reset(ip)
+
+	// 登录后更换会话 ID，防止会话固定攻击
+	if old := s.sessions.get(r); old != nil {
+		s.sessions.remove(w, old)
+	}
+	s.sessions.create(w, r, req.Username)
+	log.Printf("用户 %s 已登录，来源 %s", req.Username, ip)
+
+	respondJSON(w, map[string]interface{}{"success": true, "user": req.Username}, http.StatusOK)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if sess := s.sessions.get(r); sess != nil {
+		s.sessions.remove(w, sess)
+	}
+	respondJSON(w, map[string]interface{}{"success": true}, http.StatusOK)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	sess := r.Context().Value(sessionKey{}).(*session)
+	respondJSON(w, map[string]interface{}{
+		"success": true,
+		"user":    sess.user,
+		"auth":    s.opts.Auth != nil,
+	}, http.StatusOK)
+}
+
+// loginLimiter 限制同一来源的连续登录失败次数
+type loginLimiter struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+type loginFailures struct {
+	count int
+	first time.Time
+}
+
+const (
+	maxLoginFailures   = 5
+	loginFailureWindow = 15 * time.Minute
+)
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{failures: make(map[string]*loginFailures)}
+}
+
+func (l *loginLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f := l.failures[ip]
+	if f == nil {
+		return true
+	}
+	if time.Since(f.first) > loginFailureWindow {
+		delete(l.failures, ip)
+		return true
+	}
+	return f.count < maxLoginFailures
+}
+
+func (l *loginLimiter) fail(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f := l.failures[ip]
+	if f == nil || time.Since(f.first) > loginFailureWindow {
+		f = &loginFailures{first: time.Now()}
+		l.failures[ip] = f
+	}
+	f.count++
+}
+
+func (l *loginLimiter) reset(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, ip)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// browserHost 返回在浏览器中访问监听地址时使用的主机名
+func browserHost(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// isLoopback 判断监听地址是否只能从本机访问
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -7,31 +7,51 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"svn-ai-reviewer/internal/ai"
 	"svn-ai-reviewer/internal/config"
 	"svn-ai-reviewer/internal/report"
+	"svn-ai-reviewer/internal/source"
 	"svn-ai-reviewer/internal/svn"
 )
 
 //go:embed templates/*
 var templates embed.FS
 
+// Options 网页端服务选项
+type Options struct {
+	Listen       string        // 监听地址，默认 localhost:8080
+	SessionTTL   time.Duration // 会话空闲超时，默认 12 小时
+	Auth         Authenticator // 登录认证方式，为 nil 时不需要登录
+	OpenBrowser  bool          // 启动后自动打开浏览器
+	MaxJobs      int           // 同时运行的审核任务数，默认 2
+	JobsDir      string        // 审核任务记录目录，默认 jobs
+	BasePath     string        // URL 前缀，部署在反向代理的子路径下时使用，如 /svn-review
+	TLSCert      string        // HTTPS 证书文件，和 TLSKey 同时配置时启用 HTTPS
+	TLSKey       string        // HTTPS 私钥文件
+	ReportsDir   string        // 通过 /reports/ 提供访问的报告目录，默认 reports
+	AllowedRoots []string      // 网页上可以访问的工作副本和源代码目录，为空时不限制
+	ConfigFiles  []string      // 网页上可以加载的配置文件，为空时为 config.yaml 和 config 目录下的 yaml 文件
+	SecureCookie bool          // 会话 Cookie 总是设置 Secure，反向代理终止 HTTPS 时使用
+}
+
 type Server struct {
-	cfg         *config.Config
-	changes     []svn.FileChange
-	logEntries  []svn.LogEntry
-	svnClient   *svn.Client
-	mode        string // "local", "online" or "source"
-	logChannel  chan string // SSE日志通道
-	sourceFiles []SourceFile // 源代码模式的文件列表
+	opts     Options
+	sessions *sessionStore
+	limiter  *loginLimiter
+	jobs     *jobManager
+	access   *accessPolicy
 }
 
 type SourceFile struct {
@@ -39,49 +59,182 @@ type SourceFile struct {
 	Path  string `json:"path"`
 }
 
-func NewServer() *Server {
+func NewServer(opts Options) *Server {
+	if opts.Listen == "" {
+		opts.Listen = "localhost:8080"
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 12 * time.Hour
+	}
+	if opts.MaxJobs <= 0 {
+		opts.MaxJobs = 2
+	}
+	if opts.JobsDir == "" {
+		opts.JobsDir = "jobs"
+	}
+	if opts.ReportsDir == "" {
+		opts.ReportsDir = "reports"
+	}
+	opts.BasePath = NormalizeBasePath(opts.BasePath)
+	jobs := newJobManager(opts.JobsDir, opts.MaxJobs)
+	access := &accessPolicy{roots: opts.AllowedRoots, configs: opts.ConfigFiles}
+	sessions := newSessionStore(opts.SessionTTL, opts.BasePath+"/", opts.ReportsDir, access, jobs)
+	sessions.secure = opts.SecureCookie
 	return &Server{
-		logChannel: make(chan string, 100),
+		opts:     opts,
+		sessions: sessions,
+		limiter:  newLoginLimiter(),
+		jobs:     jobs,
+		access:   access,
 	}
 }
 
 func (s *Server) Start() error {
-	http.HandleFunc("/", s.handleIndex)
-	http.HandleFunc("/online", s.handleOnlineIndex)
-	http.HandleFunc("/source", s.handleSourceIndex)
-	http.HandleFunc("/api/list-configs", s.handleListConfigs)
-	http.HandleFunc("/api/load-config", s.handleLoadConfig)
-	http.HandleFunc("/api/scan", s.handleScan)
-	http.HandleFunc("/api/review", s.handleReview)
-	http.HandleFunc("/api/diff", s.handleDiff) // 查看文件变更
-	http.HandleFunc("/api/online/connect", s.handleOnlineConnect)
-	http.HandleFunc("/api/online/search", s.handleOnlineSearch)
-	http.HandleFunc("/api/online/files", s.handleOnlineFiles)
-	http.HandleFunc("/api/online/review", s.handleOnlineReview)
-	http.HandleFunc("/api/online/diff", s.handleOnlineDiff) // 在线模式查看变更
-	http.HandleFunc("/api/source/scan", s.handleSourceScan)
-	http.HandleFunc("/api/source/content", s.handleSourceContent)
-	http.HandleFunc("/api/source/review", s.handleSourceReview)
-	http.HandleFunc("/api/logs", s.handleLogs) // SSE日志流
-	
-	// 提供静态文件服务 - 报告目录
-	http.Handle("/reports/", http.StripPrefix("/reports/", http.FileServer(http.Dir("reports"))))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", s.handleLoginPage)
+	mux.HandleFunc("/api/login", s.handleLogin)
+	mux.HandleFunc("/api/logout", s.handleLogout)
+	mux.HandleFunc("/api/me", s.requireLogin(s.handleMe))
+	mux.HandleFunc("/static/session.js", handleSessionScript)
+
+	mux.HandleFunc("/", s.requireLogin(s.handleIndex))
+	mux.HandleFunc("/online", s.requireLogin(s.handleOnlineIndex))
+	mux.HandleFunc("/source", s.requireLogin(s.handleSourceIndex))
+	mux.HandleFunc("/api/list-configs", s.requireLogin(s.handleListConfigs))
+	mux.HandleFunc("/api/load-config", s.withWorkspace((*workspace).handleLoadConfig))
+	mux.HandleFunc("/api/scan", s.withWorkspace((*workspace).handleScan))
+	mux.HandleFunc("/api/review", s.withWorkspace((*workspace).handleReview))
+	mux.HandleFunc("/api/diff", s.withWorkspace((*workspace).handleDiff)) // 查看文件变更
+	mux.HandleFunc("/api/online/connect", s.withWorkspace((*workspace).handleOnlineConnect))
+	mux.HandleFunc("/api/online/search", s.withWorkspace((*workspace).handleOnlineSearch))
+	mux.HandleFunc("/api/online/files", s.withWorkspace((*workspace).handleOnlineFiles))
+	mux.HandleFunc("/api/online/review", s.withWorkspace((*workspace).handleOnlineReview))
+	mux.HandleFunc("/api/online/diff", s.withWorkspace((*workspace).handleOnlineDiff)) // 在线模式查看变更
+	mux.HandleFunc("/api/source/scan", s.withWorkspace((*workspace).handleSourceScan))
+	mux.HandleFunc("/api/source/content", s.withWorkspace((*workspace).handleSourceContent))
+	mux.HandleFunc("/api/source/review", s.withWorkspace((*workspace).handleSourceReview))
+	mux.HandleFunc("/api/logs", s.withWorkspaceStream((*workspace).handleLogs)) // SSE日志流
+	mux.HandleFunc("/api/jobs", s.requireLogin(s.handleJobs))
+	mux.HandleFunc("/api/jobs/", s.requireLogin(s.handleJobs))
+
+	// 提供静态文件服务 - 报告目录，启用登录时只能访问自己的报告
+	mux.HandleFunc("/reports/", s.requireLogin(s.handleReports))
+
+	// 部署在子路径下时，去掉 URL 前缀后再交给各处理函数
+	var handler http.Handler = mux
+	if base := s.opts.BasePath; base != "" {
+		root := http.NewServeMux()
+		root.Handle(base+"/", http.StripPrefix(base, mux))
+		root.Handle(base, http.RedirectHandler(base+"/", http.StatusMovedPermanently))
+		handler = root
+	}
+
+	useTLS := s.opts.TLSCert != "" && s.opts.TLSKey != ""
+	if (s.opts.TLSCert != "") != (s.opts.TLSKey != "") {
+		return fmt.Errorf("启用 HTTPS 需要同时配置证书和私钥")
+	}
+
+	// 先监听端口，端口被占用等错误可以在打开浏览器之前报告
+	addr := s.opts.Listen
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", addr, err)
+	}
 
-	addr := "localhost:8080"
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	baseURL := scheme + "://" + browserHost(addr) + s.opts.BasePath
 	fmt.Printf("🚀 SVN 代码审核工具已启动\n")
-	fmt.Printf("📱 本地模式: http://%s\n", addr)
-	fmt.Printf("📱 在线模式: http://%s/online\n", addr)
-	fmt.Printf("📱 源代码模式: http://%s/source\n", addr)
-	fmt.Printf("📊 报告目录: http://%s/reports/\n", addr)
+	fmt.Printf("📱 本地模式: %s/\n", baseURL)
+	fmt.Printf("📱 在线模式: %s/online\n", baseURL)
+	fmt.Printf("📱 源代码模式: %s/source\n", baseURL)
+	fmt.Printf("📊 报告目录: %s/reports/\n", baseURL)
+	if s.opts.Auth == nil && !isLoopback(addr) {
+		fmt.Printf("⚠️  监听地址 %s 可以被其他机器访问，但未启用登录（server.auth.mode）\n", addr)
+	}
 	fmt.Println("按 Ctrl+C 停止服务器")
 
 	// 自动打开浏览器
+	if s.opts.OpenBrowser {
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			openBrowser(baseURL + "/")
+		}()
+	}
+
+	// 服务停止时结束日志流等长连接
+	streams, closeStreams := context.WithCancel(context.Background())
+	defer closeStreams()
+	srv := &http.Server{
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return streams },
+	}
+
+	errCh := make(chan error, 1)
 	go func() {
-		time.Sleep(500 * time.Millisecond)
-		openBrowser("http://" + addr)
+		if useTLS {
+			errCh <- srv.ServeTLS(listener, s.opts.TLSCert, s.opts.TLSKey)
+		} else {
+			errCh <- srv.Serve(listener)
+		}
 	}()
 
-	return http.ListenAndServe(addr, nil)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-signals:
+	}
+
+	// 停止接受新请求，等待正在运行的审核任务完成；再次按 Ctrl+C 则取消任务
+	fmt.Println("\n正在停止服务...")
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- srv.Shutdown(context.Background()) }()
+
+	if n := s.jobs.cancelQueued(); n > 0 {
+		fmt.Printf("已取消 %d 个排队中的审核任务\n", n)
+	}
+	if n := s.jobs.runningCount(); n > 0 {
+		fmt.Printf("等待 %d 个正在运行的审核任务完成，再次按 Ctrl+C 取消这些任务\n", n)
+		waitDone := make(chan struct{})
+		go func() {
+			s.jobs.wait()
+			close(waitDone)
+		}()
+		select {
+		case <-waitDone:
+		case <-signals:
+			fmt.Println("正在取消审核任务...")
+			s.jobs.cancelAll()
+			<-waitDone
+		}
+	}
+
+	closeStreams()
+	if err := <-shutdownDone; err != nil {
+		return fmt.Errorf("停止服务失败: %w", err)
+	}
+	fmt.Println("服务已停止")
+	return nil
+}
+
+// NormalizeBasePath 规范化 URL 前缀：以 / 开头、不以 / 结尾，根路径返回空字符串
+func NormalizeBasePath(base string) string {
+	base = strings.Trim(strings.TrimSpace(base), "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// pageData 页面模板数据
+type pageData struct {
+	BasePath string
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -90,7 +243,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	tmpl.Execute(w, nil)
+	tmpl.Execute(w, pageData{BasePath: s.opts.BasePath})
 }
 
 func (s *Server) handleOnlineIndex(w http.ResponseWriter, r *http.Request) {
@@ -99,7 +252,7 @@ func (s *Server) handleOnlineIndex(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	tmpl.Execute(w, nil)
+	tmpl.Execute(w, pageData{BasePath: s.opts.BasePath})
 }
 
 func (s *Server) handleSourceIndex(w http.ResponseWriter, r *http.Request) {
@@ -108,7 +261,7 @@ func (s *Server) handleSourceIndex(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	tmpl.Execute(w, nil)
+	tmpl.Execute(w, pageData{BasePath: s.opts.BasePath})
 }
 
 func (s *Server) handleListConfigs(w http.ResponseWriter, r *http.Request) {
@@ -117,32 +270,35 @@ func (s *Server) handleListConfigs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var configs []string
+	respondJSON(w, map[string]interface{}{
+		"success": true,
+		"configs": s.access.configFiles(),
+	}, http.StatusOK)
+}
 
-	// 检查根目录的 config.yaml
-	if _, err := os.Stat("config.yaml"); err == nil {
-		configs = append(configs, "config.yaml")
+// handleReports 提供报告目录的访问
+// 启用登录时报告按用户保存在子目录中（见 finishReport），只能访问自己目录下的报告，与 /api/jobs 一致
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	sess := r.Context().Value(sessionKey{}).(*session)
+	if s.opts.Auth == nil {
+		http.StripPrefix("/reports/", http.FileServer(reportsFS{http.Dir(s.opts.ReportsDir)})).ServeHTTP(w, r)
+		return
 	}
 
-	// 检查 config 目录下的所有 yaml 文件
-	if entries, err := os.ReadDir("config"); err == nil {
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				name := entry.Name()
-				if strings.HasSuffix(strings.ToLower(name), ".yaml") || strings.HasSuffix(strings.ToLower(name), ".yml") {
-					configs = append(configs, "config/"+name)
-				}
-			}
-		}
+	prefix := "/reports/" + reportOwnerDir(sess.user) + "/"
+	if r.URL.Path == strings.TrimSuffix(prefix, "/") || r.URL.Path == "/reports/" {
+		http.Redirect(w, r, s.opts.BasePath+prefix, http.StatusFound)
+		return
 	}
-
-	respondJSON(w, map[string]interface{}{
-		"success": true,
-		"configs": configs,
-	}, http.StatusOK)
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	dir := filepath.Join(s.opts.ReportsDir, reportOwnerDir(sess.user))
+	http.StripPrefix(prefix, http.FileServer(reportsFS{http.Dir(dir)})).ServeHTTP(w, r)
 }
 
-func (s *Server) handleLoadConfig(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleLoadConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -156,13 +312,22 @@ func (s *Server) handleLoadConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := ws.access.checkConfig(req.ConfigPath); err != nil {
+		respondAccessError(w, err)
+		return
+	}
+
 	cfg, err := config.LoadConfig(req.ConfigPath)
 	if err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
 		return
 	}
+	if err := cfg.ResolveSecrets(); err != nil {
+		respondJSON(w, map[string]interface{}{"error": "解析配置中的密钥失败: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
-	s.cfg = cfg
+	ws.cfg = cfg
 	respondJSON(w, map[string]interface{}{
 		"success": true,
 		"message": "配置加载成功",
@@ -173,19 +338,20 @@ func (s *Server) handleLoadConfig(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
-func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleScan(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
-		WorkDir string `json:"work_dir"`
+		WorkDir     string   `json:"work_dir"`
+		Changelists []string `json:"changelists"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
@@ -195,39 +361,55 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 	if req.WorkDir == "" {
 		req.WorkDir = "."
 	}
+	if err := ws.access.checkPath(req.WorkDir); err != nil {
+		respondAccessError(w, err)
+		return
+	}
 
-	svnClient := svn.NewClient(s.cfg.SVN.Command, req.WorkDir)
-	changes, err := svnClient.GetChangedFiles(s.cfg.Ignore)
+	svnClient := svn.NewClient(ws.cfg.SVN.Command, req.WorkDir)
+	svnClient.SetEncoding(ws.cfg.SVN.Encoding)
+	changes, err := svnClient.GetChangedFiles(ws.cfg.Ignore, req.Changelists...)
 	if err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
-	s.changes = changes
+	// 工作副本中所有的变更列表，供前端选择
+	changelistNames, err := svnClient.ListChangelists()
+	if err != nil {
+		changelistNames = []string{}
+	}
+
+	ws.changes = changes
 
 	// 初始化为空数组而不是 nil，确保 JSON 序列化时返回 [] 而不是 null
 	files := make([]map[string]interface{}, 0)
 	for i, change := range changes {
 		files = append(files, map[string]interface{}{
-			"index":  i,
-			"path":   change.Path,
-			"status": change.Status,
+			"index":      i,
+			"path":       change.Path,
+			"status":     change.Status,
+			"prop_mods":  change.PropMods,
+			"text_mods":  change.TextMods,
+			"conflicted": change.Conflicted,
+			"changelist": change.Changelist,
 		})
 	}
 
 	respondJSON(w, map[string]interface{}{
-		"success": true,
-		"files":   files,
+		"success":     true,
+		"files":       files,
+		"changelists": changelistNames,
 	}, http.StatusOK)
 }
 
-func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
@@ -244,12 +426,16 @@ func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
 	if req.WorkDir == "" {
 		req.WorkDir = "."
 	}
+	if err := ws.access.checkPath(req.WorkDir); err != nil {
+		respondAccessError(w, err)
+		return
+	}
 
 	// 获取选中的文件
 	var filesToReview []svn.FileChange
 	for _, idx := range req.Indices {
-		if idx >= 0 && idx < len(s.changes) {
-			filesToReview = append(filesToReview, s.changes[idx])
+		if idx >= 0 && idx < len(ws.changes) {
+			filesToReview = append(filesToReview, ws.changes[idx])
 		}
 	}
 
@@ -258,18 +444,27 @@ func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paths := make([]string, len(filesToReview))
+	for i, change := range filesToReview {
+		paths[i] = change.Path
+	}
+
 	// 在后台执行审核
-	go func() {
-		s.sendLog("开始审核 %d 个文件...", len(filesToReview))
-		
-		svnClient := svn.NewClient(s.cfg.SVN.Command, req.WorkDir)
-		aiClient, err := ai.NewClient(&s.cfg.AI)
+	// 后台任务使用启动时的配置，之后标签页重新加载配置不影响正在运行的任务
+	cfg := ws.cfg
+	job := ws.startJob("local", "本地审核: "+req.WorkDir, paths, func(ctx context.Context, h *jobHandle) (string, error) {
+		h.log("开始审核 %d 个文件...", len(filesToReview))
+
+		svnClient := svn.NewClient(cfg.SVN.Command, req.WorkDir)
+		svnClient.SetEncoding(cfg.SVN.Encoding)
+		aiClient, err := ai.NewReviewClient(cfg)
 		if err != nil {
-			s.sendLog("❌ 创建AI客户端失败: %v", err)
-			return
+			h.log("❌ 创建AI客户端失败: %v", err)
+			return "", fmt.Errorf("创建AI客户端失败: %w", err)
 		}
+		// 外部检查工具直接检查工作副本中的文件
+		ctx = ai.WithLocalDir(ctx, req.WorkDir)
 
-		ctx := context.Background()
 		htmlReport := &report.Report{
 			Title:       "SVN 代码审核报告",
 			GeneratedAt: time.Now(),
@@ -278,88 +473,113 @@ func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for i, change := range filesToReview {
-			s.sendLog("[%d/%d] 正在审核: %s", i+1, len(filesToReview), change.Path)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			h.log("[%d/%d] 正在审核: %s", i+1, len(filesToReview), change.Path)
+			h.fileStarted(i)
 			fileReview := report.FileReview{
 				FileName: change.Path,
 				Status:   change.Status,
 			}
 
+			// 冲突、缺失等条目无法审核，直接记录到报告
+			if problem := change.Problem(); problem != nil {
+				h.log("  ⛔ %v", problem)
+				fileReview.Error = problem
+				fileReview.Blocking = change.Conflicted
+				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+				h.fileFinished(i, fileReview)
+				continue
+			}
+
 			var diff string
 			var skipReview bool
 
 			if change.Status == "D" {
 				diff = fmt.Sprintf("文件已删除: %s", change.Path)
-			} else if change.Status == "A" || change.Status == "?" {
-				content, err := svnClient.GetFileContent(change.Path)
+			} else if change.Status == "A" || change.Status == "R" || change.Status == "?" {
+				content, enc, err := svnClient.GetFileContent(change.Path)
 				if err != nil {
-					s.sendLog("  ⚠️  获取文件内容失败: %v", err)
+					h.log("  ⚠️  获取文件内容失败: %v", err)
 					fileReview.Error = err
 					htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+					h.fileFinished(i, fileReview)
 					continue
 				}
 				statusDesc := "新增文件"
 				if change.Status == "?" {
 					statusDesc = "未受控文件（尚未加入版本控制）"
+				} else if change.Status == "R" {
+					statusDesc = "替换文件"
 				}
 				diff = fmt.Sprintf("%s，完整内容:\n%s", statusDesc, content)
+				change.Encoding = enc
 			} else {
-				d, err := svnClient.GetFileDiff(change.Path)
+				d, enc, err := svnClient.GetFileDiff(change.Path)
 				if err != nil {
-					s.sendLog("  ⚠️  获取文件差异失败: %v", err)
+					h.log("  ⚠️  获取文件差异失败: %v", err)
 					fileReview.Error = err
 					htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+					h.fileFinished(i, fileReview)
 					continue
 				}
 				if strings.TrimSpace(d) == "" {
 					skipReview = true
+				} else if cfg.Context.Enabled {
+					d, err = svnClient.WithWorkingCopyContext(change.Path, d, cfg.Context.ContextOptions())
+					if err != nil {
+						h.log("  ⚠️  获取上下文失败，仅使用差异内容: %v", err)
+					}
 				}
 				diff = d
+				change.Encoding = enc
 			}
 
 			if strings.TrimSpace(diff) == "" || skipReview {
-				s.sendLog("  ℹ️  文件无差异内容，跳过审核")
+				h.log("  ℹ️  文件无差异内容，跳过审核")
+				h.fileSkipped(i)
 				continue
 			}
 
 			// 保存 diff 内容到报告
 			fileReview.Diff = diff
+			fileReview.Encoding = change.Encoding
 
-			result, err := aiClient.Review(ctx, change.Path, diff, s.cfg.ReviewPrompt)
+			result, err := aiClient.Review(ctx, change.Path, diff, cfg.ReviewPrompt)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			if err != nil {
-				s.sendLog("  ❌ 审核失败: %v", err)
+				h.log("  ❌ 审核失败: %v", err)
 				fileReview.Error = err
+				fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+			} else if result.Skipped != "" {
+				h.log("  🚫 未发送给 AI: %s", result.Skipped)
+				fileReview.Result = result
 			} else {
-				s.sendLog("  ✅ 审核完成")
+				h.log("  ✅ 审核完成")
 				fileReview.Result = result
 			}
 
-				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			h.fileFinished(i, fileReview)
 		}
 
-		// 生成报告
-		s.sendLog("正在生成HTML报告...")
-		reportPath, err := report.GenerateHTML(htmlReport, s.cfg.Report.OutputDir)
-		if err != nil {
-			s.sendLog("❌ 生成报告失败: %v", err)
-			return
+		// 跨文件整体审核
+		runChangesetReview(ctx, h, cfg, aiClient, htmlReport)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
 		}
 
-		absPath, _ := filepath.Abs(reportPath)
-		s.sendLog("✅ 报告已生成: %s", absPath)
-
-		// 发送报告URL到前端，由前端打开
-		// 将文件路径转换为HTTP URL
-		reportFileName := filepath.Base(reportPath)
-		reportURL := "http://localhost:8080/reports/" + reportFileName
-		s.sendLog("REPORT_URL:" + reportURL)
-
-		s.sendLog("所有文件审核完成！")
-	}()
+		return ws.finishReport(h, htmlReport)
+	}, nil)
 
 	// 立即返回，审核在后台进行
 	respondJSON(w, map[string]interface{}{
 		"success": true,
 		"message": "审核已开始，请查看日志",
+		"job_id":  job.ID,
 	}, http.StatusOK)
 }
 
@@ -385,7 +605,7 @@ func openBrowser(url string) {
 }
 
 
-func (s *Server) handleOnlineConnect(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleOnlineConnect(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -409,47 +629,77 @@ func (s *Server) handleOnlineConnect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 创建在线SVN客户端（用户名密码可以为空，支持file://协议）
-	svnClient := svn.NewOnlineClient("svn", req.URL, req.Username, req.Password)
+	svnCommand, svnBackend, svnConfigDir, logCacheDir, svnEncoding := "svn", "", "", "", ""
+	if ws.cfg != nil {
+		svnCommand, svnBackend, svnConfigDir = ws.cfg.SVN.Command, ws.cfg.SVN.Backend, ws.cfg.SVN.ConfigDir
+		logCacheDir, svnEncoding = ws.cfg.SVN.LogCache.CacheDir(), ws.cfg.SVN.Encoding
+	}
+	svnClient := svn.NewOnlineClient(svnCommand, req.URL, req.Username, req.Password)
+	svnClient.SetConfigDir(svnConfigDir)
+	svnClient.SetEncoding(svnEncoding)
+	svnClient.SetLogCache(logCacheDir)
+	if err := svnClient.SetBackend(svnBackend); err != nil {
+		ws.sendLog("⚠️  %v，改用 svn 命令行", err)
+	}
 	
-	// 测试连接
+	// 测试连接，服务器暂时无法连接但有本地日志缓存时仍然可以搜索提交记录
+	message, offline := "连接成功", false
 	if err := svnClient.TestConnection(); err != nil {
-		respondJSON(w, map[string]interface{}{"error": "连接失败: " + err.Error()}, http.StatusBadRequest)
-		return
+		if !svnClient.LogCacheAvailable() {
+			svnClient.Close()
+			respondJSON(w, map[string]interface{}{"error": "连接失败: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		message, offline = "无法连接服务器，使用本地日志缓存（只能搜索提交记录，审核需要连接服务器）", true
+		ws.sendLog("⚠️  %v", err)
 	}
 
-	s.svnClient = svnClient
-	s.mode = "online"
+	// 旧连接可能仍被运行中的在线审核任务使用，只释放工作区的引用
+	if ws.svnClient != nil {
+		ws.svnClient.release()
+	}
+	ws.svnClient = newOnlineClient(svnClient)
+	ws.mode = "online"
 
 	// 保存凭据
-	if req.Save && s.cfg != nil {
-		s.cfg.Online.URL = req.URL
-		s.cfg.Online.Username = req.Username
-		s.cfg.Online.Password = req.Password
+	if req.Save && ws.cfg != nil {
+		ws.cfg.Online.URL = req.URL
+		ws.cfg.Online.Username = req.Username
+		ws.cfg.Online.Password = req.Password
 		// 这里可以选择保存到配置文件
 	}
 
 	respondJSON(w, map[string]interface{}{
 		"success": true,
-		"message": "连接成功",
+		"message": message,
+		"offline": offline,
 	}, http.StatusOK)
 }
 
-func (s *Server) handleOnlineSearch(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleOnlineSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.svnClient == nil {
+	if ws.svnClient == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先连接SVN服务器"}, http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
-		Path    string `json:"path"`
-		Keyword string `json:"keyword"`
-		Limit   int    `json:"limit"`
-		Offset  int    `json:"offset"`
+		Path       string `json:"path"`
+		Keyword    string `json:"keyword"`
+		Author     string `json:"author"` // 多个作者用逗号分隔
+		Since      string `json:"since"`
+		Until      string `json:"until"`
+		Regex      string `json:"regex"`
+		StartRev   int    `json:"start_rev"`
+		EndRev     int    `json:"end_rev"`
+		StopOnCopy bool   `json:"stop_on_copy"`
+		Before     int    `json:"before"` // 翻页游标，取上一页返回的 next
+		Limit      int    `json:"limit"`
+		Offset     int    `json:"offset"` // 已加载的条数，用于给返回的记录编号
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
@@ -460,20 +710,50 @@ func (s *Server) handleOnlineSearch(w http.ResponseWriter, r *http.Request) {
 		req.Limit = 100
 	}
 
-	// 关键词现在用于搜索提交信息和作者
-	entries, hasMore, err := s.svnClient.SearchLog(req.Path, req.Keyword, req.Limit, req.Offset)
+	query := svn.LogQuery{
+		Path:       req.Path,
+		Keyword:    req.Keyword,
+		StartRev:   req.StartRev,
+		EndRev:     req.EndRev,
+		StopOnCopy: req.StopOnCopy,
+		Before:     req.Before,
+		Limit:      req.Limit,
+	}
+	for _, author := range strings.Split(req.Author, ",") {
+		if author = strings.TrimSpace(author); author != "" {
+			query.Authors = append(query.Authors, author)
+		}
+	}
+	var err error
+	if query.Since, err = svn.ParseLogDate(req.Since, false); err == nil {
+		query.Until, err = svn.ParseLogDate(req.Until, true)
+	}
+	if err == nil && req.Regex != "" {
+		if query.Message, err = regexp.Compile(req.Regex); err != nil {
+			err = fmt.Errorf("正则表达式无效: %w", err)
+		}
+	}
+	if err != nil {
+		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	page, err := ws.svnClient.SearchLog(query)
 	if err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
-	s.logEntries = entries
+	if req.Before == 0 {
+		ws.logEntries = nil
+	}
+	ws.logEntries = append(ws.logEntries, page.Entries...)
 
 	// 初始化为空数组而不是 nil，确保 JSON 序列化时返回 [] 而不是 null
 	logs := make([]map[string]interface{}, 0)
-	for i, entry := range entries {
+	for i, entry := range page.Entries {
 		logs = append(logs, map[string]interface{}{
-			"index":    i,
+			"index":    req.Offset + i,
 			"revision": entry.Revision,
 			"author":   entry.Author,
 			"date":     entry.Date,
@@ -484,18 +764,20 @@ func (s *Server) handleOnlineSearch(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, map[string]interface{}{
 		"success": true,
 		"logs":    logs,
-		"hasMore": hasMore,
+		"hasMore": page.HasMore,
+		"next":    page.Next,
+		"offline": page.Offline,
 		"offset":  req.Offset,
 	}, http.StatusOK)
 }
 
-func (s *Server) handleOnlineFiles(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleOnlineFiles(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.svnClient == nil {
+	if ws.svnClient == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先连接SVN服务器"}, http.StatusBadRequest)
 		return
 	}
@@ -510,23 +792,35 @@ func (s *Server) handleOnlineFiles(w http.ResponseWriter, r *http.Request) {
 
 	var allFiles []svn.FileChange
 	for _, rev := range req.Revisions {
-		files, err := s.svnClient.GetRevisionFiles(rev)
+		files, err := ws.svnClient.GetRevisionFiles(rev)
 		if err != nil {
 			continue
 		}
 		allFiles = append(allFiles, files...)
 	}
 
-	s.changes = allFiles
+	// 按 ignore、.svnreviewignore 和 svn:ignore 等规则过滤
+	var ignorePatterns []string
+	if ws.cfg != nil {
+		ignorePatterns = ws.cfg.Ignore
+	}
+	allFiles = ws.svnClient.FilterIgnored(allFiles, ignorePatterns)
+
+	ws.changes = allFiles
 
 	// 初始化为空数组而不是 nil，确保 JSON 序列化时返回 [] 而不是 null
 	files := make([]map[string]interface{}, 0)
 	for i, change := range allFiles {
 		files = append(files, map[string]interface{}{
-			"index":    i,
-			"path":     change.Path,
-			"status":   change.Status,
-			"revision": change.Revision,
+			"index":         i,
+			"path":          change.Path,
+			"status":        change.Status,
+			"revision":      change.Revision,
+			"action":        change.Action,
+			"kind":          change.NodeKind,
+			"prop_mods":     change.PropMods,
+			"copyfrom_path": change.CopyFromPath,
+			"copyfrom_rev":  change.CopyFromRev,
 		})
 	}
 
@@ -536,18 +830,18 @@ func (s *Server) handleOnlineFiles(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
-func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
 
-	if s.svnClient == nil {
+	if ws.svnClient == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先连接SVN服务器"}, http.StatusBadRequest)
 		return
 	}
@@ -563,8 +857,8 @@ func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 	// 获取选中的文件
 	var filesToReview []svn.FileChange
 	for _, idx := range req.Indices {
-		if idx >= 0 && idx < len(s.changes) {
-			filesToReview = append(filesToReview, s.changes[idx])
+		if idx >= 0 && idx < len(ws.changes) {
+			filesToReview = append(filesToReview, ws.changes[idx])
 		}
 	}
 
@@ -573,17 +867,23 @@ func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paths := make([]string, len(filesToReview))
+	for i, file := range filesToReview {
+		paths[i] = fmt.Sprintf("%s (r%d)", file.Path, file.Revision)
+	}
+
 	// 在后台执行审核
-	go func() {
-		s.sendLog("开始审核 %d 个文件...", len(filesToReview))
-		
-		aiClient, err := ai.NewClient(&s.cfg.AI)
+	// 后台任务使用启动时的配置和连接，不再读取工作区；任务持有连接的引用，结束后释放
+	cfg, svnClient := ws.cfg, ws.svnClient.acquire()
+	job := ws.startJob("online", "在线审核: "+cfg.Online.URL, paths, func(ctx context.Context, h *jobHandle) (string, error) {
+		h.log("开始审核 %d 个文件...", len(filesToReview))
+
+		aiClient, err := ai.NewReviewClient(cfg)
 		if err != nil {
-			s.sendLog("❌ 创建AI客户端失败: %v", err)
-			return
+			h.log("❌ 创建AI客户端失败: %v", err)
+			return "", fmt.Errorf("创建AI客户端失败: %w", err)
 		}
 
-		ctx := context.Background()
 		htmlReport := &report.Report{
 			Title:       "SVN 在线代码审核报告",
 			GeneratedAt: time.Now(),
@@ -592,7 +892,11 @@ func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for i, file := range filesToReview {
-			s.sendLog("[%d/%d] 正在审核: %s (r%d)", i+1, len(filesToReview), file.Path, file.Revision)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			h.log("[%d/%d] 正在审核: %s (r%d)", i+1, len(filesToReview), file.Path, file.Revision)
+			h.fileStarted(i)
 			fileReview := report.FileReview{
 				FileName: fmt.Sprintf("%s (r%d)", file.Path, file.Revision),
 				Status:   file.Status,
@@ -601,7 +905,8 @@ func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 
 			// 删除的文件直接跳过
 			if file.Status == "D" {
-				s.sendLog("  ℹ️  删除的文件，跳过审核")
+				h.log("  ℹ️  删除的文件，跳过审核")
+				h.fileSkipped(i)
 				continue
 			}
 
@@ -610,154 +915,161 @@ func (s *Server) handleOnlineReview(w http.ResponseWriter, r *http.Request) {
 
 			// 对于新增文件，获取完整内容（纯文本，不带diff格式）
 			if file.Status == "A" {
-				s.sendLog("  ℹ️  新增文件，获取完整内容")
-				content, err := s.svnClient.GetFileContentAtRevision(file.Revision, file.Path)
+				h.log("  ℹ️  新增文件，获取完整内容")
+				content, enc, err := svnClient.GetFileContentAtRevision(file.Revision, file.Path)
 				if err != nil {
-					s.sendLog("  ⚠️  获取文件内容失败，尝试使用整个版本的diff")
-					// 备选方案：使用整个版本的diff
-					fullDiff, err2 := s.svnClient.GetRevisionDiff(file.Revision, "")
-					if err2 == nil && strings.TrimSpace(fullDiff) != "" {
-						diff = fullDiff
-					} else {
-						s.sendLog("  ❌ 无法获取文件内容")
-						fileReview.Error = err
-						htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
-						continue
-					}
-			} else {
+					h.log("  ❌ 获取文件内容失败: %v", err)
+					fileReview.Error = err
+					htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+					h.fileFinished(i, fileReview)
+					continue
+				}
 				// 直接使用纯文本内容，不添加任何前缀
 				diff = content
-			}
-		} else {
-			// 对于修改的文件，获取diff
-			diff, err = s.svnClient.GetRevisionDiff(file.Revision, file.Path)
-			if err != nil {
-				fileReview.Error = err
-				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
-				continue
-			}
-
-			if strings.TrimSpace(diff) == "" {
-				// 尝试获取整个版本的diff作为备选
-				fullDiff, err2 := s.svnClient.GetRevisionDiff(file.Revision, "")
-				if err2 == nil && strings.TrimSpace(fullDiff) != "" {
-					diff = fullDiff
-				} else {
-					// 如果仍然没有diff，跳过但记录到报告中
-					fileReview.Error = fmt.Errorf("未能提取到文件差异内容")
+				file.Encoding = enc
+			} else {
+				// 对于修改的文件，获取该文件的diff
+				diff, file.Encoding, err = svnClient.GetRevisionDiff(file.Revision, file.Path)
+				if err != nil {
+					fileReview.Error = err
 					htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+					h.fileFinished(i, fileReview)
 					continue
 				}
+
+				if strings.TrimSpace(diff) == "" {
+					// 例如只复制没有修改内容
+					h.log("  ℹ️  该版本中文件内容没有变化，跳过审核")
+					h.fileSkipped(i)
+					continue
+				} else if cfg.Context.Enabled {
+					diff, err = svnClient.WithRevisionContext(file.Revision, file.Path, diff, cfg.Context.ContextOptions())
+					if err != nil {
+						h.log("  ⚠️  获取上下文失败，仅使用差异内容: %v", err)
+					}
+				}
 			}
-		}
 
 			// 保存 diff 内容到报告
 			fileReview.Diff = diff
+			fileReview.Encoding = file.Encoding
 
-			result, err := aiClient.Review(ctx, file.Path, diff, s.cfg.ReviewPrompt)
+			// 外部检查工具需要修改的文件的完整内容时再获取
+			revision, filePath := file.Revision, file.Path
+			fileCtx := ai.WithContentLoader(ctx, func() (string, error) {
+				content, _, err := svnClient.GetFileContentAtRevision(revision, filePath)
+				return content, err
+			})
+			result, err := aiClient.Review(fileCtx, file.Path, diff, cfg.ReviewPrompt)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			if err != nil {
-				s.sendLog("  ❌ 审核失败: %v", err)
+				h.log("  ❌ 审核失败: %v", err)
 				fileReview.Error = err
+				fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+			} else if result.Skipped != "" {
+				h.log("  🚫 未发送给 AI: %s", result.Skipped)
+				fileReview.Result = result
 			} else {
-				s.sendLog("  ✅ 审核完成")
+				h.log("  ✅ 审核完成")
 				fileReview.Result = result
 			}
 
 			htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			h.fileFinished(i, fileReview)
 		}
 
-		// 生成报告
-		s.sendLog("正在生成HTML报告...")
-		reportPath, err := report.GenerateHTML(htmlReport, s.cfg.Report.OutputDir)
-		if err != nil {
-			s.sendLog("❌ 生成报告失败: %v", err)
-			return
+		// 跨文件整体审核（按版本分组）
+		runChangesetReview(ctx, h, cfg, aiClient, htmlReport)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
 		}
 
-		absPath, _ := filepath.Abs(reportPath)
-		s.sendLog("✅ 报告已生成: %s", absPath)
-
-		// 发送报告URL到前端，由前端打开
-		// 将文件路径转换为HTTP URL
-		reportFileName := filepath.Base(reportPath)
-		reportURL := "http://localhost:8080/reports/" + reportFileName
-		s.sendLog("REPORT_URL:" + reportURL)
-
-		s.sendLog("所有文件审核完成！")
-	}()
+		return ws.finishReport(h, htmlReport)
+	}, svnClient.release)
 
 	// 立即返回，审核在后台进行
 	respondJSON(w, map[string]interface{}{
 		"success": true,
 		"message": "审核已开始，请查看日志",
+		"job_id":  job.ID,
 	}, http.StatusOK)
 }
 
 
-// handleLogs 处理SSE日志流
-func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
-	// 设置SSE响应头
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// 创建一个新的日志通道用于这个连接
-	logChan := make(chan string, 10)
-	
-	// 启动一个goroutine来转发日志
-	done := make(chan bool)
-	go func() {
-		for {
-			select {
-			case msg := <-s.logChannel:
-				logChan <- msg
-			case <-done:
-				return
-			case <-r.Context().Done():
-				return
-			}
-		}
-	}()
+// handleLogs 处理SSE日志流，包含当前标签页的所有日志和任务事件
+func (ws *workspace) handleLogs(w http.ResponseWriter, r *http.Request) {
+	serveEvents(w, r, ws.events, false)
+}
 
-	// 发送日志到客户端
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+// runChangesetReview 按配置对已审核的文件进行跨文件整体审核
+func runChangesetReview(ctx context.Context, h *jobHandle, cfg *config.Config, aiClient ai.Client, htmlReport *report.Report) {
+	if !cfg.Changeset.Enabled {
 		return
 	}
 
-	for {
-		select {
-		case msg := <-logChan:
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			flusher.Flush()
-		case <-r.Context().Done():
-			close(done)
-			return
+	for _, group := range report.ChangesetGroups(htmlReport.Reviews) {
+		h.log("正在进行整体变更审核: %s (%d 个文件)", group.Title, len(group.Files))
+		result, err := ai.ReviewChangeset(ctx, aiClient, group.Title, group.Files, &cfg.Changeset)
+		if err != nil {
+			h.log("  ❌ 整体审核失败: %v", err)
+		} else {
+			h.log("  ✅ 整体审核完成")
 		}
+		htmlReport.Changesets = append(htmlReport.Changesets, report.ChangesetReview{
+			Title:  group.Title,
+			Result: result,
+			Error:  err,
+		})
 	}
 }
 
-// sendLog 发送日志消息到SSE通道
-func (s *Server) sendLog(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	select {
-	case s.logChannel <- msg:
-	default:
-		// 通道满了，丢弃消息
+// startJob 提交后台审核任务，任务的日志和进度事件同时发送到当前标签页的日志流
+// release 不为空时在任务结束后调用，见 jobManager.submit
+func (ws *workspace) startJob(mode, title string, files []string, run jobFunc, release func()) *Job {
+	return ws.jobs.submit(mode, title, ws.user, files, ws.events, run, release)
+}
+
+// finishReport 生成 HTML 报告并通知前端，返回报告 URL
+// 报告写入 /reports/ 提供访问的目录，不使用网页上加载的配置文件中的 report.output_dir，否则报告链接无法打开
+func (ws *workspace) finishReport(h *jobHandle, htmlReport *report.Report) (string, error) {
+	h.log("正在生成HTML报告...")
+	// 启用登录时按用户分目录保存，/reports/ 只允许访问自己的目录
+	owner := reportOwnerDir(ws.user)
+	reportPath, err := report.GenerateHTML(htmlReport, filepath.Join(ws.reportsDir, owner))
+	if err != nil {
+		h.log("❌ 生成报告失败: %v", err)
+		return "", fmt.Errorf("生成报告失败: %w", err)
 	}
+
+	absPath, _ := filepath.Abs(reportPath)
+	h.log("✅ 报告已生成: %s", absPath)
+
+	// 发送报告URL到前端，由前端打开
+	reportURL := "/reports/" + filepath.Base(reportPath)
+	if owner != "" {
+		reportURL = "/reports/" + owner + "/" + filepath.Base(reportPath)
+	}
+	h.reportReady(reportURL)
+
+	h.log("所有文件审核完成！")
+	return reportURL, nil
 }
 
+// sendLog 发送日志消息到当前标签页的日志流
+func (ws *workspace) sendLog(format string, args ...interface{}) {
+	ws.events.publish(eventLog, fmt.Sprintf(format, args...))
+}
 
 // handleDiff 处理本地模式的文件变更查看
-func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleDiff(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
@@ -771,20 +1083,27 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Index < 0 || req.Index >= len(s.changes) {
+	if req.Index < 0 || req.Index >= len(ws.changes) {
 		respondJSON(w, map[string]interface{}{"error": "无效的文件索引"}, http.StatusBadRequest)
 		return
 	}
+	if err := ws.access.checkPath(req.WorkDir); err != nil {
+		respondAccessError(w, err)
+		return
+	}
 
-	change := s.changes[req.Index]
-	svnClient := svn.NewClient(s.cfg.SVN.Command, req.WorkDir)
+	change := ws.changes[req.Index]
+	svnClient := svn.NewClient(ws.cfg.SVN.Command, req.WorkDir)
+	svnClient.SetEncoding(ws.cfg.SVN.Encoding)
 
 	var content string
 
-	if change.Status == "D" {
+	if problem := change.Problem(); problem != nil {
+		content = fmt.Sprintf("%s: %v", change.Path, problem)
+	} else if change.Status == "D" {
 		content = fmt.Sprintf("文件已删除: %s", change.Path)
-	} else if change.Status == "A" || change.Status == "?" {
-		fileContent, err := svnClient.GetFileContent(change.Path)
+	} else if change.Status == "A" || change.Status == "R" || change.Status == "?" {
+		fileContent, enc, err := svnClient.GetFileContent(change.Path)
 		if err != nil {
 			respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 			return
@@ -794,83 +1113,99 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 			statusDesc = "未受控文件"
 		}
 		content = fmt.Sprintf("%s，完整内容:\n\n%s", statusDesc, fileContent)
+		change.Encoding = enc
 	} else {
-		diff, err := svnClient.GetFileDiff(change.Path)
+		diff, enc, err := svnClient.GetFileDiff(change.Path)
 		if err != nil {
 			respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 			return
 		}
 		content = diff
+		change.Encoding = enc
 	}
 
 	respondJSON(w, map[string]interface{}{
-		"success": true,
-		"file":    change.Path,
-		"status":  change.Status,
-		"content": content,
+		"success":  true,
+		"file":     change.Path,
+		"status":   change.Status,
+		"content":  content,
+		"encoding": change.Encoding,
 	}, http.StatusOK)
 }
 
 // handleOnlineDiff 处理在线模式的文件变更查看
-func (s *Server) handleOnlineDiff(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleOnlineDiff(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.svnClient == nil {
+	if ws.svnClient == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先连接SVN服务器"}, http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
-		Index int `json:"index"`
+		Index int  `json:"index"`
+		Sides bool `json:"sides"` // 返回修改前后的完整内容，用于并排对比
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
 		return
 	}
 
-	if req.Index < 0 || req.Index >= len(s.changes) {
+	if req.Index < 0 || req.Index >= len(ws.changes) {
 		respondJSON(w, map[string]interface{}{"error": "无效的文件索引"}, http.StatusBadRequest)
 		return
 	}
 
-	file := s.changes[req.Index]
-	var content string
+	file := ws.changes[req.Index]
 
+	// 并排对比：返回修改前后的完整内容
+	if req.Sides {
+		versions, err := ws.svnClient.GetFileVersions(file)
+		if err != nil {
+			respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, map[string]interface{}{
+			"success":      true,
+			"file":         file.Path,
+			"status":       file.Status,
+			"revision":     file.Revision,
+			"old_path":     versions.OldPath,
+			"old_revision": versions.OldRevision,
+			"old_content":  versions.OldContent,
+			"new_path":     versions.NewPath,
+			"new_revision": versions.NewRevision,
+			"new_content":  versions.NewContent,
+			"old_encoding": versions.OldEncoding,
+			"new_encoding": versions.NewEncoding,
+		}, http.StatusOK)
+		return
+	}
+
+	var content string
 	if file.Status == "D" {
 		content = fmt.Sprintf("文件已删除: %s (r%d)", file.Path, file.Revision)
 	} else if file.Status == "A" {
-		fileContent, err := s.svnClient.GetFileContentAtRevision(file.Revision, file.Path)
+		fileContent, enc, err := ws.svnClient.GetFileContentAtRevision(file.Revision, file.Path)
 		if err != nil {
-			// 备选：使用整个版本的diff
-			fullDiff, err2 := s.svnClient.GetRevisionDiff(file.Revision, "")
-			if err2 == nil {
-				content = fullDiff
-			} else {
-				respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
-				return
-			}
-		} else {
-			content = fmt.Sprintf("新增文件，完整内容:\n\n%s", fileContent)
+			respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
+			return
 		}
+		content = fmt.Sprintf("新增文件，完整内容:\n\n%s", fileContent)
+		file.Encoding = enc
 	} else {
-		diff, err := s.svnClient.GetRevisionDiff(file.Revision, file.Path)
+		diff, enc, err := ws.svnClient.GetRevisionDiff(file.Revision, file.Path)
 		if err != nil {
 			respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 			return
 		}
+		content = diff
+		file.Encoding = enc
 		if strings.TrimSpace(diff) == "" {
-			// 尝试整个版本的diff
-			fullDiff, err2 := s.svnClient.GetRevisionDiff(file.Revision, "")
-			if err2 == nil {
-				content = fullDiff
-			} else {
-				content = "无法获取文件差异"
-			}
-		} else {
-			content = diff
+			content = "该版本中文件内容没有变化"
 		}
 	}
 
@@ -880,18 +1215,19 @@ func (s *Server) handleOnlineDiff(w http.ResponseWriter, r *http.Request) {
 		"status":   file.Status,
 		"revision": file.Revision,
 		"content":  content,
+		"encoding": file.Encoding,
 	}, http.StatusOK)
 }
 
 
 // handleSourceScan 处理源代码模式的文件扫描
-func (s *Server) handleSourceScan(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleSourceScan(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
@@ -910,21 +1246,41 @@ func (s *Server) handleSourceScan(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, map[string]interface{}{"error": "请提供目录或文件路径"}, http.StatusBadRequest)
 		return
 	}
+	if err := ws.access.checkPath(req.Path); err != nil {
+		respondAccessError(w, err)
+		return
+	}
 
 	// 设置默认最大文件数
 	if req.MaxFiles <= 0 {
 		req.MaxFiles = 100
 	}
 
+	// 目录按 ignore、.svnreviewignore 和 svn:ignore 等规则过滤，与本地、在线模式相同
+	matcher, err := source.Matcher(ws.cfg.SVN.Command, req.Path, ws.cfg.Ignore, nil)
+	if err != nil {
+		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
 	// 扫描文件
-	files, truncated, err := scanSourceFiles(req.Path, req.Filter, req.MaxFiles)
+	opts := source.Options{MaxFiles: req.MaxFiles, Ignore: matcher}
+	if req.Filter != "" {
+		opts.Filters = []string{req.Filter}
+	}
+	paths, truncated, err := source.Scan(req.Path, opts)
 	if err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	files := make([]SourceFile, len(paths))
+	for i, p := range paths {
+		files[i] = SourceFile{Index: i, Path: p}
+	}
 
-	s.sourceFiles = files
-	s.mode = "source"
+	ws.sourceFiles = files
+	ws.sourceRoot = req.Path
+	ws.mode = "source"
 
 	// 初始化为空数组
 	fileList := make([]map[string]interface{}, 0)
@@ -943,117 +1299,8 @@ func (s *Server) handleSourceScan(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
-// scanSourceFiles 扫描指定路径下的文件
-func scanSourceFiles(path string, filter string, maxFiles int) ([]SourceFile, bool, error) {
-	var files []SourceFile
-	index := 0
-	truncated := false
-
-	// 检查路径是否存在
-	info, err := os.Stat(path)
-	if err != nil {
-		return nil, false, fmt.Errorf("路径不存在: %v", err)
-	}
-
-	// 如果是文件，直接返回
-	if !info.IsDir() {
-		if matchFilter(path, filter) {
-			files = append(files, SourceFile{
-				Index: index,
-				Path:  path,
-			})
-		}
-		return files, false, nil
-	}
-
-	// 如果是目录，递归扫描
-	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// 跳过目录
-		if info.IsDir() {
-			return nil
-		}
-
-		// 检查是否达到最大文件数
-		if index >= maxFiles {
-			truncated = true
-			return filepath.SkipDir // 停止扫描
-		}
-
-		// 应用过滤器
-		if matchFilter(filePath, filter) {
-			files = append(files, SourceFile{
-				Index: index,
-				Path:  filePath,
-			})
-			index++
-		}
-
-		return nil
-	})
-
-	if err != nil && err != filepath.SkipDir {
-		return nil, false, fmt.Errorf("扫描文件失败: %v", err)
-	}
-
-	return files, truncated, nil
-}
-
-// matchFilter 检查文件是否匹配过滤器
-func matchFilter(filePath string, filter string) bool {
-	// 如果没有过滤器，匹配所有文件
-	if filter == "" {
-		return true
-	}
-
-	// 将路径分隔符统一为 /
-	filePath = filepath.ToSlash(filePath)
-	filter = filepath.ToSlash(filter)
-
-	// 简单的通配符匹配
-	matched, err := filepath.Match(filter, filepath.Base(filePath))
-	if err == nil && matched {
-		return true
-	}
-
-	// 尝试匹配完整路径
-	matched, err = filepath.Match(filter, filePath)
-	if err == nil && matched {
-		return true
-	}
-
-	// 支持多级路径匹配，例如 src/*.go
-	if strings.Contains(filter, "/") {
-		parts := strings.Split(filter, "/")
-		pathParts := strings.Split(filePath, "/")
-
-		// 从后往前匹配
-		if len(parts) <= len(pathParts) {
-			match := true
-			for i := 0; i < len(parts); i++ {
-				partIdx := len(parts) - 1 - i
-				pathIdx := len(pathParts) - 1 - i
-
-				matched, err := filepath.Match(parts[partIdx], pathParts[pathIdx])
-				if err != nil || !matched {
-					match = false
-					break
-				}
-			}
-			if match {
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
 // handleSourceContent 处理源代码模式的文件内容查看
-func (s *Server) handleSourceContent(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleSourceContent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -1067,41 +1314,51 @@ func (s *Server) handleSourceContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Index < 0 || req.Index >= len(s.sourceFiles) {
+	if req.Index < 0 || req.Index >= len(ws.sourceFiles) {
 		respondJSON(w, map[string]interface{}{"error": "无效的文件索引"}, http.StatusBadRequest)
 		return
 	}
 
-	file := s.sourceFiles[req.Index]
+	file := ws.sourceFiles[req.Index]
+	// 扫描时只检查了目录，目录中指向外部的符号链接在这里拦截
+	if err := ws.access.checkPath(file.Path); err != nil {
+		respondAccessError(w, err)
+		return
+	}
 
 	// 读取文件内容
-	content, err := os.ReadFile(file.Path)
+	hint := ""
+	if ws.cfg != nil {
+		hint = ws.cfg.SVN.Encoding
+	}
+	text, enc, err := source.ReadFile(file.Path, hint)
 	if err != nil {
 		respondJSON(w, map[string]interface{}{"error": fmt.Sprintf("读取文件失败: %v", err)}, http.StatusInternalServerError)
 		return
 	}
-
 	respondJSON(w, map[string]interface{}{
-		"success": true,
-		"file":    file.Path,
-		"content": string(content),
+		"success":  true,
+		"file":     file.Path,
+		"content":  text,
+		"encoding": enc,
 	}, http.StatusOK)
 }
 
 // handleSourceReview 处理源代码模式的审核
-func (s *Server) handleSourceReview(w http.ResponseWriter, r *http.Request) {
+func (ws *workspace) handleSourceReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if s.cfg == nil {
+	if ws.cfg == nil {
 		respondJSON(w, map[string]interface{}{"error": "请先加载配置文件"}, http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
 		Indices []int `json:"indices"`
+		Audit   bool  `json:"audit"` // 项目审计：按模块分组并在报告中显示模块树
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, map[string]interface{}{"error": err.Error()}, http.StatusBadRequest)
@@ -1111,8 +1368,8 @@ func (s *Server) handleSourceReview(w http.ResponseWriter, r *http.Request) {
 	// 获取选中的文件
 	var filesToReview []SourceFile
 	for _, idx := range req.Indices {
-		if idx >= 0 && idx < len(s.sourceFiles) {
-			filesToReview = append(filesToReview, s.sourceFiles[idx])
+		if idx >= 0 && idx < len(ws.sourceFiles) {
+			filesToReview = append(filesToReview, ws.sourceFiles[idx])
 		}
 	}
 
@@ -1121,84 +1378,118 @@ func (s *Server) handleSourceReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paths := make([]string, len(filesToReview))
+	for i, file := range filesToReview {
+		paths[i] = file.Path
+	}
+
+	// 项目审计按模块排列文件
+	jobTitle, reportTitle := "源代码审核", "源代码审核报告"
+	var modules []report.Module
+	if req.Audit {
+		jobTitle, reportTitle = "项目审计: "+ws.sourceRoot, "项目审计报告"
+		modules = source.Modules(ws.sourceRoot, paths)
+		paths = paths[:0]
+		for _, m := range modules {
+			paths = append(paths, m.Files...)
+		}
+		for i, p := range paths {
+			filesToReview[i] = SourceFile{Index: i, Path: p}
+		}
+	}
+
 	// 在后台执行审核
-	go func() {
-		s.sendLog("开始审核 %d 个文件...", len(filesToReview))
+	// 后台任务使用启动时的配置，不再读取工作区
+	cfg, sourceRoot, access := ws.cfg, ws.sourceRoot, ws.access
+	job := ws.startJob("source", jobTitle, paths, func(ctx context.Context, h *jobHandle) (string, error) {
+		h.log("开始审核 %d 个文件...", len(filesToReview))
 
-		aiClient, err := ai.NewClient(&s.cfg.AI)
+		aiClient, err := ai.NewReviewClient(cfg)
 		if err != nil {
-			s.sendLog("❌ 创建AI客户端失败: %v", err)
-			return
+			h.log("❌ 创建AI客户端失败: %v", err)
+			return "", fmt.Errorf("创建AI客户端失败: %w", err)
 		}
 
-		ctx := context.Background()
 		htmlReport := &report.Report{
-			Title:       "源代码审核报告",
+			Title:       reportTitle,
 			GeneratedAt: time.Now(),
 			WorkDir:     "源代码审核",
 			Reviews:     make([]report.FileReview, 0),
+			Modules:     modules,
+		}
+		if req.Audit {
+			htmlReport.WorkDir = sourceRoot
+			h.log("按模块分组: %d 个模块", len(modules))
 		}
 
 		for i, file := range filesToReview {
-			s.sendLog("[%d/%d] 正在审核: %s", i+1, len(filesToReview), file.Path)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			h.log("[%d/%d] 正在审核: %s", i+1, len(filesToReview), file.Path)
+			h.fileStarted(i)
 			fileReview := report.FileReview{
 				FileName: file.Path,
 				Status:   "源代码",
 			}
 
+			// 扫描时只检查了目录，目录中指向外部的符号链接不能读取和发送
+			if err := access.checkPath(file.Path); err != nil {
+				h.log("  🚫 %v", err)
+				fileReview.Error = err
+				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+				h.fileFinished(i, fileReview)
+				continue
+			}
+
 			// 读取文件内容
-			content, err := os.ReadFile(file.Path)
+			fileContent, enc, err := source.ReadFile(file.Path, cfg.SVN.Encoding)
 			if err != nil {
-				s.sendLog("  ❌ 读取文件失败: %v", err)
+				h.log("  ❌ 读取文件失败: %v", err)
 				fileReview.Error = err
 				htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+				h.fileFinished(i, fileReview)
 				continue
 			}
 
-			fileContent := string(content)
 			if strings.TrimSpace(fileContent) == "" {
-				s.sendLog("  ℹ️  文件为空，跳过审核")
+				h.log("  ℹ️  文件为空，跳过审核")
+				h.fileSkipped(i)
 				continue
 			}
 
 			// 保存文件内容到报告
 			fileReview.Diff = fileContent
+			fileReview.Encoding = enc
 
 			// 调用AI审核
-			result, err := aiClient.Review(ctx, file.Path, fileContent, s.cfg.ReviewPrompt)
+			result, err := aiClient.Review(ai.WithLocalDir(ctx, ""), file.Path, fileContent, cfg.ReviewPrompt)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			if err != nil {
-				s.sendLog("  ❌ 审核失败: %v", err)
+				h.log("  ❌ 审核失败: %v", err)
 				fileReview.Error = err
+				fileReview.Result = result // AI 审核失败时仍带有本地检查发现的问题
+			} else if result.Skipped != "" {
+				h.log("  🚫 未发送给 AI: %s", result.Skipped)
+				fileReview.Result = result
 			} else {
-				s.sendLog("  ✅ 审核完成")
+				h.log("  ✅ 审核完成")
 				fileReview.Result = result
 			}
 
 			htmlReport.Reviews = append(htmlReport.Reviews, fileReview)
+			h.fileFinished(i, fileReview)
 		}
 
-		// 生成报告
-		s.sendLog("正在生成HTML报告...")
-		reportPath, err := report.GenerateHTML(htmlReport, s.cfg.Report.OutputDir)
-		if err != nil {
-			s.sendLog("❌ 生成报告失败: %v", err)
-			return
-		}
-
-		absPath, _ := filepath.Abs(reportPath)
-		s.sendLog("✅ 报告已生成: %s", absPath)
-
-		// 发送报告URL到前端
-		reportFileName := filepath.Base(reportPath)
-		reportURL := "http://localhost:8080/reports/" + reportFileName
-		s.sendLog("REPORT_URL:" + reportURL)
-
-		s.sendLog("所有文件审核完成！")
-	}()
+		return ws.finishReport(h, htmlReport)
+	}, nil)
 
 	// 立即返回，审核在后台进行
 	respondJSON(w, map[string]interface{}{
 		"success": true,
 		"message": "审核已开始，请查看日志",
+		"job_id":  job.ID,
 	}, http.StatusOK)
 }
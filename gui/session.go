@@ -0,0 +1,246 @@
+package gui
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/svn"
+)
+
+// sessionCookie 会话 Cookie 名称
+const sessionCookie = "svn_reviewer_session"
+
+// maxWorkspaces 每个会话最多保留的工作区（标签页）数量，超过时淘汰最久未使用的
+const maxWorkspaces = 20
+
+// maxWorkspaceEvents 每个标签页的日志流保留的事件数量，用于断线重连后补发
+const maxWorkspaceEvents = 500
+
+// workspace 一个浏览器标签页的状态
+// 同一会话（同一浏览器）的多个标签页各自独立，互不覆盖
+// 同一标签页的请求可能并发到达（连续点击、多个请求同时发出），mu 保护 cfg 到 sourceRoot 的状态字段，
+// withWorkspace 在调用处理函数期间持有；后台任务启动时复制需要的字段，不直接读取工作区
+type workspace struct {
+	mu          sync.Mutex
+	user        string // 登录用户，未启用登录时为空
+	cfg         *config.Config
+	changes     []svn.FileChange
+	logEntries  []svn.LogEntry
+	svnClient   *onlineClient
+	mode        string        // "local", "online" or "source"
+	events      *broadcaster  // SSE日志流
+	sourceFiles []SourceFile  // 源代码模式的文件列表
+	sourceRoot  string        // 源代码模式扫描的路径，项目审计时按它识别模块
+	reportsDir  string        // 报告输出目录，即 /reports/ 提供访问的目录
+	access      *accessPolicy // 允许访问的目录和配置文件
+	jobs        *jobManager   // 所有会话共用的任务管理器
+	lastSeen    time.Time
+}
+
+func newWorkspace(user, reportsDir string, access *accessPolicy, jobs *jobManager) *workspace {
+	return &workspace{
+		user:       user,
+		reportsDir: reportsDir,
+		access:     access,
+		jobs:       jobs,
+		events:     newBroadcaster(maxWorkspaceEvents),
+		lastSeen:   time.Now(),
+	}
+}
+
+// onlineClient 在线模式的 SVN 连接，工作区和使用它的在线审核任务各持有一个引用
+// 同一标签页重新连接或工作区被淘汰时只释放工作区的引用，最后一个引用释放时才关闭：
+// 命令行方式关闭时会删除临时认证目录，原生协议会断开连接，正在运行的任务不能因此失败
+type onlineClient struct {
+	*svn.Client
+	mu   sync.Mutex
+	refs int
+}
+
+func newOnlineClient(client *svn.Client) *onlineClient {
+	return &onlineClient{Client: client, refs: 1}
+}
+
+// acquire 增加一个引用，使用完后调用 release
+func (c *onlineClient) acquire() *onlineClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refs++
+	return c
+}
+
+// release 释放一个引用，没有引用时关闭连接
+func (c *onlineClient) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refs--
+	if c.refs == 0 {
+		c.Client.Close()
+	}
+}
+
+// close 释放工作区持有的资源
+// 调用方持有会话存储的锁，在后台等待该标签页正在处理的请求结束后再释放，不阻塞其他会话
+func (ws *workspace) close() {
+	go func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		if ws.svnClient != nil {
+			ws.svnClient.release()
+			ws.svnClient = nil
+		}
+	}()
+}
+
+// session 一个浏览器会话，通过 Cookie 识别
+type session struct {
+	id         string
+	user       string
+	workspaces map[string]*workspace // 标签页 ID -> 工作区
+	lastSeen   time.Time
+}
+
+// sessionStore 会话存储，只保存在内存中，服务重启后需要重新登录
+type sessionStore struct {
+	mu         sync.Mutex
+	sessions   map[string]*session
+	ttl        time.Duration
+	path       string // Cookie 路径，部署在子路径下时只对该路径生效
+	secure     bool   // Cookie 总是设置 Secure
+	reportsDir string // 新建工作区的报告输出目录
+	access     *accessPolicy
+	jobs       *jobManager
+}
+
+func newSessionStore(ttl time.Duration, cookiePath, reportsDir string, access *accessPolicy, jobs *jobManager) *sessionStore {
+	return &sessionStore{
+		sessions:   make(map[string]*session),
+		ttl:        ttl,
+		path:       cookiePath,
+		reportsDir: reportsDir,
+		access:     access,
+		jobs:       jobs,
+	}
+}
+
+// get 返回请求对应的会话，不存在或已过期时返回 nil
+func (st *sessionStore) get(r *http.Request) *session {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.expireLocked()
+
+	sess := st.sessions[c.Value]
+	if sess != nil {
+		sess.lastSeen = time.Now()
+	}
+	return sess
+}
+
+// create 创建新会话并写入 Cookie
+func (st *sessionStore) create(w http.ResponseWriter, r *http.Request, user string) *session {
+	sess := &session{
+		id:         newSessionID(),
+		user:       user,
+		workspaces: make(map[string]*workspace),
+		lastSeen:   time.Now(),
+	}
+
+	st.mu.Lock()
+	st.sessions[sess.id] = sess
+	st.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sess.id,
+		Path:     st.path,
+		HttpOnly: true,
+		Secure:   st.secure || isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return sess
+}
+
+// remove 删除会话（退出登录）
+func (st *sessionStore) remove(w http.ResponseWriter, sess *session) {
+	st.mu.Lock()
+	delete(st.sessions, sess.id)
+	sess.close()
+	st.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     st.path,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// workspace 返回标签页对应的工作区，不存在时创建
+func (st *sessionStore) workspace(sess *session, tabID string) *workspace {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	ws := sess.workspaces[tabID]
+	if ws == nil {
+		if len(sess.workspaces) >= maxWorkspaces {
+			var oldestID string
+			for id, w := range sess.workspaces {
+				if oldestID == "" || w.lastSeen.Before(sess.workspaces[oldestID].lastSeen) {
+					oldestID = id
+				}
+			}
+			sess.workspaces[oldestID].close()
+			delete(sess.workspaces, oldestID)
+		}
+		ws = newWorkspace(sess.user, st.reportsDir, st.access, st.jobs)
+		sess.workspaces[tabID] = ws
+	}
+	ws.lastSeen = time.Now()
+	return ws
+}
+
+// expireLocked 清理空闲超时的会话，调用方需持有锁
+func (st *sessionStore) expireLocked() {
+	now := time.Now()
+	for id, sess := range st.sessions {
+		if now.Sub(sess.lastSeen) > st.ttl {
+			delete(st.sessions, id)
+			sess.close()
+		}
+	}
+}
+
+func (sess *session) close() {
+	for _, ws := range sess.workspaces {
+		ws.close()
+	}
+}
+
+// isHTTPS 请求是否通过 HTTPS 到达：直接的 TLS 连接，或反向代理终止 HTTPS 后转发（X-Forwarded-Proto）
+// 请求头可以伪造，但只会让 Cookie 更严格，不会降低安全性
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("生成会话 ID 失败: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,171 @@
+// 会话与标签页支持：所有页面共用
+// 每个标签页生成独立的 ID，服务端按 ID 隔离各标签页的配置、文件列表和日志
+(function () {
+    // URL 前缀（部署在反向代理的子路径下时），由本脚本的地址推算
+    const basePath = new URL(document.currentScript.src).pathname.replace(/\/static\/session\.js$/, '');
+
+    // 给以 / 开头的站内地址加上 URL 前缀
+    window.withBase = function (url) {
+        return typeof url === 'string' && url.startsWith('/') && !url.startsWith('//') ? basePath + url : url;
+    };
+
+    let tabId = sessionStorage.getItem('tabId');
+    if (!tabId) {
+        tabId = Date.now().toString(36) + Math.random().toString(36).slice(2);
+        sessionStorage.setItem('tabId', tabId);
+    }
+    window.tabId = tabId;
+
+    // 所有请求加上 URL 前缀和标签页 ID，未登录时跳转到登录页
+    const originalFetch = window.fetch;
+    window.fetch = function (url, options) {
+        options = options || {};
+        options.headers = Object.assign({ 'X-Tab-ID': tabId }, options.headers || {});
+        return originalFetch(withBase(url), options).then(function (response) {
+            if (response.status === 401) {
+                location.href = basePath + '/login?next=' + encodeURIComponent(location.pathname);
+            }
+            return response;
+        });
+    };
+
+    // 日志流地址
+    window.logsURL = function () {
+        return basePath + '/api/logs?tab=' + encodeURIComponent(tabId);
+    };
+
+    // 日志流：服务端按事件类型发送 log、progress、file-done、report-ready、job-state
+    // 断线重连时带上最后收到的事件 ID，服务端会补发断开期间的事件
+    let eventSource = null;
+    let lastEventId = '';
+
+    window.connectLogStream = function (onLog) {
+        closeLogStream();
+        let url = logsURL();
+        if (lastEventId) {
+            url += '&last_event_id=' + encodeURIComponent(lastEventId);
+        }
+        eventSource = new EventSource(url);
+
+        function on(type, handler) {
+            eventSource.addEventListener(type, function (event) {
+                if (event.lastEventId) {
+                    lastEventId = event.lastEventId;
+                }
+                handler(event.data);
+            });
+        }
+
+        on('log', onLog);
+        on('progress', function (data) {
+            const e = JSON.parse(data);
+            showReviewProgress(e.job_id, e.completed, e.total);
+        });
+        on('file-done', function (data) {
+            const e = JSON.parse(data);
+            showReviewProgress(e.job_id, e.completed, e.total);
+        });
+        on('report-ready', function (data) {
+            const e = JSON.parse(data);
+            window.open(withBase(e.url), '_blank');
+            onLog('✅ 已在新标签页打开报告');
+        });
+        on('job-state', function (data) {
+            const e = JSON.parse(data);
+            if (e.state === 'done' || e.state === 'failed' || e.state === 'cancelled') {
+                endReviewJob(e.job_id);
+            }
+        });
+
+        eventSource.onerror = function () {
+            // 浏览器会自动重连（并带上 Last-Event-ID），连接被关闭时才需要手动重连
+            if (eventSource.readyState === EventSource.CLOSED) {
+                setTimeout(function () { connectLogStream(onLog); }, 5000);
+            }
+        };
+    };
+
+    window.closeLogStream = function () {
+        if (eventSource) {
+            eventSource.close();
+            eventSource = null;
+        }
+    };
+
+    // 当前标签页正在运行的审核任务，页面上需要有 reviewBtn 和 cancelBtn 两个按钮
+    let currentJobId = null;
+    // 审核接口返回之前就已结束的任务（文件很少时可能先收到结束事件）
+    const finishedJobs = new Set();
+
+    window.startReviewJob = function (jobId) {
+        currentJobId = jobId;
+        const cancelBtn = document.getElementById('cancelBtn');
+        cancelBtn.disabled = false;
+        cancelBtn.style.display = '';
+        if (finishedJobs.has(jobId)) {
+            endReviewJob(jobId);
+        }
+    };
+
+    function showReviewProgress(jobId, completed, total) {
+        if (jobId !== currentJobId) {
+            return;
+        }
+        document.getElementById('reviewBtn').innerHTML =
+            '<span class="loading"></span> 审核中 (' + completed + '/' + total + ')...';
+    }
+
+    // 任务结束（完成、失败或取消）后恢复按钮状态
+    window.endReviewJob = function (jobId) {
+        if (jobId !== currentJobId) {
+            finishedJobs.add(jobId);
+            return;
+        }
+        currentJobId = null;
+        document.getElementById('cancelBtn').style.display = 'none';
+        const reviewBtn = document.getElementById('reviewBtn');
+        reviewBtn.disabled = false;
+        reviewBtn.textContent = '开始审核';
+    };
+
+    window.cancelReview = function () {
+        if (!currentJobId) {
+            return;
+        }
+        const cancelBtn = document.getElementById('cancelBtn');
+        cancelBtn.disabled = true;
+        fetch('/api/jobs/' + encodeURIComponent(currentJobId) + '/cancel', { method: 'POST' })
+            .then(function (r) { return r.json(); })
+            .then(function (data) {
+                if (data.error) {
+                    alert('取消失败: ' + data.error);
+                    cancelBtn.disabled = false;
+                }
+            })
+            .catch(function () { cancelBtn.disabled = false; });
+    };
+
+    // 显示当前用户和退出按钮
+    window.addEventListener('DOMContentLoaded', function () {
+        fetch('/api/me').then(function (r) { return r.json(); }).then(function (data) {
+            if (!data.auth || !data.user) {
+                return;
+            }
+            const bar = document.createElement('div');
+            bar.style.cssText = 'position:fixed;top:10px;right:20px;color:white;font-size:14px;z-index:1000;';
+            bar.textContent = '👤 ' + data.user + ' ';
+            const logout = document.createElement('a');
+            logout.href = '#';
+            logout.textContent = '退出';
+            logout.style.color = 'white';
+            logout.onclick = function (e) {
+                e.preventDefault();
+                fetch('/api/logout', { method: 'POST' }).then(function () {
+                    location.href = basePath + '/login';
+                });
+            };
+            bar.appendChild(logout);
+            document.body.appendChild(bar);
+        }).catch(function () {});
+    });
+})();
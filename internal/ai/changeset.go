@@ -0,0 +1,163 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+// maxChangesetSize 整体审核摘要的总长度上限，需低于 Review 的截断阈值
+const maxChangesetSize = 40000
+
+// DefaultChangesetPrompt 整体变更审核的默认系统提示词
+const DefaultChangesetPrompt = `你是一个专业的代码审核专家。下面是同一次提交（或同一个工作副本）中所有文件的变更摘要，以及逐文件审核已经发现的问题。
+逐文件审核看不到其他文件，请你只关注跨文件的问题，例如：
+1. 接口、函数签名或数据结构已修改，但调用方没有同步修改
+2. 新增字段或表结构变更缺少对应的数据库迁移脚本
+3. 配置项被重命名或删除，但读取配置的代码或配置文件没有更新
+4. 新增功能缺少对应的测试、文档或注册代码
+5. 多个文件之间的修改逻辑不一致
+
+不要重复逐文件审核中已经列出的问题。
+
+请以 JSON 格式输出审核结果，格式如下：
+{
+  "summary": "简要总结整体变更的质量（1-2句话）",
+  "score": 85,
+  "issues": [
+    {
+      "severity": "high|medium|low",
+      "title": "问题标题",
+      "description": "问题详细描述（注明涉及的文件）",
+      "suggestion": "改进建议"
+    }
+  ]
+}
+
+注意：只输出 JSON，不要包含任何其他文字。score 为 0-100 的评分。如果没有跨文件问题，issues 为空数组。`
+
+// ChangesetFile 整体审核中单个文件的输入
+type ChangesetFile struct {
+	Path   string
+	Status string
+	Diff   string
+	Review *ReviewJSON // 逐文件审核结果，可能为空
+}
+
+// ReviewChangeset 将所有文件的变更摘要和逐文件审核结果一起发送给模型，检测跨文件问题
+func ReviewChangeset(ctx context.Context, client Client, title string, files []ChangesetFile, cfg *config.ChangesetConfig) (*ReviewResult, error) {
+	prompt := cfg.Prompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultChangesetPrompt
+	}
+
+	// 按外发策略过滤：never-send 的文件不出现在摘要中，有 local-only 文件时整体只发送给本地模型
+	// 二进制、生成等逐文件审核时跳过的文件也不出现在摘要中
+	var kept []ChangesetFile
+	var excluded, filtered []string
+	strictest, strictestRule := PolicyRemote, ""
+	for _, f := range files {
+		policy, rule := EgressPolicyOf(client, f.Path)
+		if policy == PolicyNeverSend {
+			excluded = append(excluded, f.Path)
+			continue
+		}
+		if FileSkipReason(client, f.Path, f.Diff) != "" {
+			filtered = append(filtered, f.Path)
+			continue
+		}
+		if policy.strictness() > strictest.strictness() {
+			strictest, strictestRule = policy, rule
+		}
+		kept = append(kept, f)
+	}
+	if len(kept) == 0 {
+		if len(filtered) == 0 {
+			return &ReviewResult{FileName: title, Success: true, Skipped: "所有文件均为 never-send，未进行整体审核"}, nil
+		}
+		return &ReviewResult{FileName: title, Success: true, Skipped: "所有文件均为 never-send 或二进制、自动生成等不审核的文件，未进行整体审核"}, nil
+	}
+
+	summary := BuildChangesetSummary(kept, cfg.MaxDiffChars)
+	if len(filtered) > 0 {
+		summary = fmt.Sprintf("另有 %d 个二进制、压缩、自动生成或第三方代码文件未提供内容。\n\n", len(filtered)) + summary
+	}
+	if len(excluded) > 0 {
+		summary = fmt.Sprintf("另有 %d 个文件按数据外发策略未提供内容。\n\n", len(excluded)) + summary
+	}
+	ctx = withChangeset(ctx)
+	if strictest != PolicyRemote {
+		ctx = withPolicy(ctx, strictest, strictestRule)
+	}
+	return client.Review(ctx, title, summary, prompt)
+}
+
+// BuildChangesetSummary 生成整体审核用的精简摘要
+// 每个文件只保留变更行（去掉上下文行），并附上逐文件审核的结论
+func BuildChangesetSummary(files []ChangesetFile, maxDiffChars int) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	// 按文件数平均分配差异长度，保证总长度不超过上限
+	perFile := maxChangesetSize / len(files)
+	if maxDiffChars > 0 && maxDiffChars < perFile {
+		perFile = maxDiffChars
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "本次变更共 %d 个文件:\n", len(files))
+	for _, f := range files {
+		fmt.Fprintf(&sb, "  [%s] %s\n", f.Status, f.Path)
+	}
+
+	for i, f := range files {
+		fmt.Fprintf(&sb, "\n===== 文件 %d/%d: %s [%s] =====\n", i+1, len(files), f.Path, f.Status)
+
+		if f.Review != nil {
+			if f.Review.Summary != "" {
+				fmt.Fprintf(&sb, "逐文件审核结论: %s (评分 %d)\n", f.Review.Summary, f.Review.Score)
+			}
+			for _, issue := range f.Review.Issues {
+				fmt.Fprintf(&sb, "  - [%s] %s\n", issue.Severity, issue.Title)
+			}
+		}
+
+		excerpt := condenseDiff(f.Diff)
+		if len(excerpt) > perFile {
+			excerpt = excerpt[:perFile] + "\n... (已截断)"
+		}
+		if excerpt != "" {
+			sb.WriteString("变更内容:\n")
+			sb.WriteString(excerpt)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// condenseDiff 去掉 unified diff 中的上下文行，只保留文件头、hunk 头和增删行
+// 非 diff 格式的内容（如新增文件的完整内容）原样返回
+func condenseDiff(diff string) string {
+	if !strings.Contains(diff, "\n@@") && !strings.HasPrefix(diff, "@@") {
+		return strings.TrimSpace(diff)
+	}
+
+	var kept []string
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Index: "),
+			strings.HasPrefix(line, "@@"),
+			strings.HasPrefix(line, "+"),
+			strings.HasPrefix(line, "-"):
+			if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+				continue
+			}
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
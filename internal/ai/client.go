@@ -14,6 +14,7 @@ type ReviewResult struct {
 	ReviewData *ReviewJSON // 解析后的 JSON 数据
 	Success    bool
 	Error      error
+	Skipped    string // 非空表示按外发策略或文件类型检测未发送给模型，内容为原因
 }
 
 // Client AI 客户端接口
@@ -21,6 +22,59 @@ type Client interface {
 	Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error)
 }
 
+type changesetKey struct{}
+
+// withChangeset 标记整体变更审核：内容是多个文件的摘要，逐个文件已经检测、检查和报告过，
+// 客户端包装不再检测文件类型、运行静态检查和外部检查工具，敏感信息只脱敏、不写入结果
+func withChangeset(ctx context.Context) context.Context {
+	return context.WithValue(ctx, changesetKey{}, true)
+}
+
+// isChangeset 是否为整体变更审核，见 withChangeset
+func isChangeset(ctx context.Context) bool {
+	return ctx.Value(changesetKey{}) != nil
+}
+
+// mergeIssues 将客户端包装在本地发现的问题（敏感信息、静态检查、外部检查工具）合并到被包装客户端的审核结果中
+//   - AI 审核失败时错误照常返回，调用方显示审核失败；这些问题附在 Success 为 false 的结果中，一并写入报告
+//   - first 为 true 时放在 AI 给出的问题之前
+func mergeIssues(result *ReviewResult, err error, fileName string, issues []Issue, first bool) (*ReviewResult, error) {
+	if len(issues) == 0 {
+		return result, err
+	}
+	if err != nil {
+		// 内层包装已经附加的问题（result 为失败结果时）保留
+		var previous []Issue
+		if result != nil && !result.Success && result.ReviewData != nil {
+			previous = result.ReviewData.Issues
+		}
+		if first {
+			issues = append(issues, previous...)
+		} else {
+			issues = append(previous, issues...)
+		}
+		return &ReviewResult{
+			FileName: fileName,
+			ReviewData: &ReviewJSON{
+				Summary: fmt.Sprintf("AI 审核失败，本地检查发现 %d 处问题", len(issues)),
+				Issues:  issues,
+			},
+			Success: false,
+			Error:   err,
+		}, err
+	}
+
+	if result.ReviewData == nil {
+		result.ReviewData = &ReviewJSON{}
+	}
+	if first {
+		result.ReviewData.Issues = append(issues, result.ReviewData.Issues...)
+	} else {
+		result.ReviewData.Issues = append(result.ReviewData.Issues, issues...)
+	}
+	return result, nil
+}
+
 // NewClient 根据配置创建 AI 客户端
 func NewClient(cfg *config.AIConfig) (Client, error) {
 	switch cfg.Provider {
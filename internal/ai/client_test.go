@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergeIssues(t *testing.T) {
+	aiErr := errors.New("请求超时")
+	local := []Issue{{Title: "local"}}
+
+	tests := []struct {
+		name        string
+		result      *ReviewResult
+		err         error
+		first       bool
+		wantErr     bool
+		wantSuccess bool
+		wantTitles  []string
+	}{
+		{
+			name:        "AI 成功，追加在后",
+			result:      &ReviewResult{Success: true, ReviewData: &ReviewJSON{Issues: []Issue{{Title: "ai"}}}},
+			wantSuccess: true,
+			wantTitles:  []string{"ai", "local"},
+		},
+		{
+			name:        "AI 成功，放在前面",
+			result:      &ReviewResult{Success: true, ReviewData: &ReviewJSON{Issues: []Issue{{Title: "ai"}}}},
+			first:       true,
+			wantSuccess: true,
+			wantTitles:  []string{"local", "ai"},
+		},
+		{
+			name:        "AI 没有返回 JSON",
+			result:      &ReviewResult{Success: true},
+			wantSuccess: true,
+			wantTitles:  []string{"local"},
+		},
+		{
+			name:       "AI 失败时错误照常返回",
+			result:     &ReviewResult{Success: false, Error: aiErr},
+			err:        aiErr,
+			wantErr:    true,
+			wantTitles: []string{"local"},
+		},
+		{
+			name:       "AI 失败时保留内层包装附加的问题",
+			result:     &ReviewResult{Success: false, Error: aiErr, ReviewData: &ReviewJSON{Issues: []Issue{{Title: "inner"}}}},
+			err:        aiErr,
+			wantErr:    true,
+			wantTitles: []string{"inner", "local"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := mergeIssues(tt.result, tt.err, "a.go", append([]Issue(nil), local...), tt.first)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeIssues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if tt.wantErr && result.Error == nil {
+				t.Errorf("result.Error is nil")
+			}
+			var titles []string
+			for _, issue := range result.ReviewData.Issues {
+				titles = append(titles, issue.Title)
+			}
+			if len(titles) != len(tt.wantTitles) {
+				t.Fatalf("issues = %v, want %v", titles, tt.wantTitles)
+			}
+			for i := range titles {
+				if titles[i] != tt.wantTitles[i] {
+					t.Errorf("issues = %v, want %v", titles, tt.wantTitles)
+					break
+				}
+			}
+		})
+	}
+}
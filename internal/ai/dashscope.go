@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"svn-ai-reviewer/internal/audit"
 	"svn-ai-reviewer/internal/config"
 )
 
@@ -17,6 +19,7 @@ type DashScopeClient struct {
 	baseURL    string
 	appID      string
 	httpClient *http.Client
+	audit      *audit.Logger
 }
 
 type dashScopeRequest struct {
@@ -72,6 +75,11 @@ func NewDashScopeClient(cfg *config.AIConfig) *DashScopeClient {
 }
 
 func (c *DashScopeClient) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	// 任务已取消时不再发起请求
+	if err := ctx.Err(); err != nil {
+		return &ReviewResult{FileName: fileName, Success: false, Error: err}, err
+	}
+
 	// 检查文件内容大小，避免请求过大
 	const maxDiffSize = 50000 // 50KB 限制
 	if len(diff) > maxDiffSize {
@@ -79,6 +87,8 @@ func (c *DashScopeClient) Review(ctx context.Context, fileName, diff, systemProm
 		diff = truncated + fmt.Sprintf("\n\n... (内容过长，已截断，原始大小: %d 字节)", len(diff))
 	}
 
+	ctx = withRequestInfo(ctx, fileName, diff, systemPrompt)
+
 	// 构建完整的 prompt，包含系统提示词和用户内容
 	fullPrompt := fmt.Sprintf("%s\n\n文件名: %s\n\n代码变更:\n```\n%s\n```\n\n请审核以上代码变更。",
 		systemPrompt, fileName, diff)
@@ -121,7 +131,7 @@ func (c *DashScopeClient) Review(ctx context.Context, fileName, diff, systemProm
 	parseErr := json.Unmarshal([]byte(cleanContent), &reviewData)
 
 	// 如果 JSON 解析失败，重试一次
-	if parseErr != nil {
+	if parseErr != nil && ctx.Err() == nil {
 		fmt.Printf("  [警告] JSON 解析失败: %v\n", parseErr)
 		fmt.Printf("  [警告] 原始内容: %s\n", cleanContent[:min(200, len(cleanContent))])
 		fmt.Printf("  [信息] 正在重试请求...\n")
@@ -159,8 +169,19 @@ func (c *DashScopeClient) Review(ctx context.Context, fileName, diff, systemProm
 	}, nil
 }
 
-func (c *DashScopeClient) makeRequest(ctx context.Context, jsonData []byte) (string, error) {
+// SetAuditLogger 设置请求审计日志
+func (c *DashScopeClient) SetAuditLogger(logger *audit.Logger) {
+	c.audit = logger
+}
+
+func (c *DashScopeClient) makeRequest(ctx context.Context, jsonData []byte) (content string, err error) {
 	url := fmt.Sprintf("%s/api/v1/apps/%s/completion", c.baseURL, c.appID)
+	record := &requestRecord{provider: "dashscope", model: c.appID, url: url, start: time.Now()}
+	defer func() {
+		record.err = err
+		record.write(ctx, c.audit)
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
@@ -169,16 +190,19 @@ func (c *DashScopeClient) makeRequest(ctx context.Context, jsonData []byte) (str
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
+	recordTransmission(ctx, url, len(jsonData))
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("API 请求失败: %v", err)
 	}
 	defer resp.Body.Close()
+	record.status = resp.StatusCode
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("读取响应失败: %w", err)
 	}
+	record.response = string(body)
 
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("API 返回错误状态码: %d\n响应内容:\n%s", resp.StatusCode, string(body))
@@ -188,6 +212,11 @@ func (c *DashScopeClient) makeRequest(ctx context.Context, jsonData []byte) (str
 	if err := json.Unmarshal(body, &dashResp); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w\n原始响应:\n%s", err, string(body))
 	}
+	for _, m := range dashResp.Usage.Models {
+		record.usage.PromptTokens += m.InputTokens
+		record.usage.CompletionTokens += m.OutputTokens
+	}
+	record.usage.TotalTokens = record.usage.PromptTokens + record.usage.CompletionTokens
 
 	if dashResp.Output.Text == "" {
 		respJSON, _ := json.MarshalIndent(dashResp, "", "  ")
@@ -0,0 +1,293 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/pathmatch"
+)
+
+// Policy 文件内容的外发策略
+type Policy string
+
+const (
+	// PolicyRemote 可以发送给远程模型
+	PolicyRemote Policy = "remote"
+	// PolicyLocalOnly 只能发送给本地模型
+	PolicyLocalOnly Policy = "local-only"
+	// PolicyNeverSend 不发送给任何模型
+	PolicyNeverSend Policy = "never-send"
+)
+
+// strictness 策略的严格程度，用于多个文件合并发送时取最严格的策略
+func (p Policy) strictness() int {
+	switch p {
+	case PolicyLocalOnly:
+		return 1
+	case PolicyNeverSend:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func parsePolicy(value string) (Policy, error) {
+	switch Policy(value) {
+	case "", PolicyRemote:
+		return PolicyRemote, nil
+	case PolicyLocalOnly, PolicyNeverSend:
+		return Policy(value), nil
+	default:
+		return "", fmt.Errorf("未知的外发策略 %q (支持: remote, local-only, never-send)", value)
+	}
+}
+
+// egressRule 编译后的路径规则
+type egressRule struct {
+	path   string
+	policy Policy
+}
+
+// endpoint 模型服务的描述，写入审计日志
+type endpoint struct {
+	provider string
+	model    string
+}
+
+// EgressRouter 按文件路径决定内容发送给哪个模型
+//   - remote 文件发送给远程模型
+//   - local-only 文件发送给本地模型，未配置本地模型时跳过
+//   - never-send 文件不发送，结果中注明跳过原因
+//
+// 每个文件实际发送到哪个地址、发送了多少字节都会追加写入审计日志
+type EgressRouter struct {
+	remote        Client
+	local         Client
+	remoteInfo    endpoint
+	localInfo     endpoint
+	rules         []egressRule
+	defaultPolicy Policy
+	audit         *egressAudit
+}
+
+// NewEgressRouter 根据配置创建路由，local 为 nil 表示没有配置本地模型
+func NewEgressRouter(remote, local Client, cfg *config.Config) (*EgressRouter, error) {
+	defaultPolicy, err := parsePolicy(cfg.Egress.DefaultPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("egress.default_policy: %w", err)
+	}
+
+	router := &EgressRouter{
+		remote:        remote,
+		local:         local,
+		remoteInfo:    endpoint{provider: cfg.AI.Provider, model: cfg.AI.Model},
+		localInfo:     endpoint{provider: cfg.Egress.LocalProvider.Provider, model: cfg.Egress.LocalProvider.Model},
+		defaultPolicy: defaultPolicy,
+	}
+	for i, rc := range cfg.Egress.Rules {
+		policy, err := parsePolicy(rc.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("egress.rules[%d]: %w", i, err)
+		}
+		if rc.Path == "" {
+			return nil, fmt.Errorf("egress.rules[%d]: path 不能为空", i)
+		}
+		router.rules = append(router.rules, egressRule{path: rc.Path, policy: policy})
+	}
+
+	auditPath := cfg.Egress.AuditLog
+	if auditPath == "" {
+		auditPath = filepath.Join(config.DefaultAuditDir, "egress-audit.jsonl")
+	}
+	router.audit = sharedEgressAudit(auditPath)
+	return router, nil
+}
+
+// Policy 返回文件适用的策略和命中的规则（未命中时规则为空）
+// 规则从路径中任意一级目录开始匹配：源代码审核传入的是带扫描根目录的路径或绝对路径，
+// 只从开头匹配时 "secrets/*" 之类的规则不会命中，文件会按默认策略发送出去
+func (r *EgressRouter) Policy(filePath string) (Policy, string) {
+	for _, rule := range r.rules {
+		if pathmatch.GlobSuffix(rule.path, filePath) {
+			return rule.policy, rule.path
+		}
+	}
+	return r.defaultPolicy, ""
+}
+
+type forcedPolicyKey struct{}
+
+// withPolicy 指定本次请求使用的策略（整体审核时内容来自多个文件，按其中最严格的策略处理）
+func withPolicy(ctx context.Context, policy Policy, rule string) context.Context {
+	return context.WithValue(ctx, forcedPolicyKey{}, egressRule{path: rule, policy: policy})
+}
+
+func (r *EgressRouter) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	policy, rule := r.Policy(fileName)
+	if forced, ok := ctx.Value(forcedPolicyKey{}).(egressRule); ok {
+		policy, rule = forced.policy, forced.path
+	}
+
+	entry := egressEntry{File: fileName, Policy: string(policy), Rule: rule}
+
+	client, info := r.remote, r.remoteInfo
+	switch policy {
+	case PolicyNeverSend:
+		return r.skip(entry, fileName, fmt.Sprintf("路径匹配规则 %s（never-send），内容未发送给任何模型", ruleName(rule)))
+	case PolicyLocalOnly:
+		if r.local == nil {
+			return r.skip(entry, fileName, fmt.Sprintf("路径匹配规则 %s（local-only），但未配置本地模型 egress.local_provider", ruleName(rule)))
+		}
+		client, info = r.local, r.localInfo
+		fmt.Printf("  🔒 仅限本地模型（规则 %s），发送给 %s\n", ruleName(rule), info.provider)
+	}
+
+	rec := &transmissions{}
+	result, err := client.Review(context.WithValue(ctx, transmissionsKey{}, rec), fileName, diff, systemPrompt)
+
+	entry.Provider, entry.Model = info.provider, info.model
+	entry.Endpoints, entry.Requests, entry.Bytes = rec.summary()
+	entry.Action = "sent"
+	if err != nil {
+		entry.Action = "failed"
+		entry.Error = err.Error()
+	}
+	r.audit.write(entry)
+	return result, err
+}
+
+func (r *EgressRouter) skip(entry egressEntry, fileName, reason string) (*ReviewResult, error) {
+	fmt.Printf("  🚫 %s\n", reason)
+	entry.Action = "skipped"
+	entry.Reason = reason
+	r.audit.write(entry)
+	return &ReviewResult{FileName: fileName, Success: true, Skipped: reason}, nil
+}
+
+func ruleName(rule string) string {
+	if rule == "" {
+		return "default_policy"
+	}
+	return rule
+}
+
+// EgressPolicyOf 返回客户端对该路径适用的外发策略，客户端没有配置外发控制时返回 remote
+func EgressPolicyOf(client Client, filePath string) (Policy, string) {
+	for client != nil {
+		if router, ok := client.(*EgressRouter); ok {
+			return router.Policy(filePath)
+		}
+		u, ok := client.(interface{ Unwrap() Client })
+		if !ok {
+			break
+		}
+		client = u.Unwrap()
+	}
+	return PolicyRemote, ""
+}
+
+// transmissions 记录一次审核中实际发出的请求
+type transmissions struct {
+	mu       sync.Mutex
+	urls     []string
+	requests int
+	bytes    int
+}
+
+type transmissionsKey struct{}
+
+// recordTransmission 由各模型客户端在发出 HTTP 请求时调用
+func recordTransmission(ctx context.Context, url string, size int) {
+	rec, ok := ctx.Value(transmissionsKey{}).(*transmissions)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests++
+	rec.bytes += size
+	for _, u := range rec.urls {
+		if u == url {
+			return
+		}
+	}
+	rec.urls = append(rec.urls, url)
+}
+
+func (t *transmissions) summary() ([]string, int, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.urls, t.requests, t.bytes
+}
+
+// egressEntry 审计日志中的一条记录
+type egressEntry struct {
+	Time      string   `json:"time"`
+	File      string   `json:"file"`
+	Policy    string   `json:"policy"`
+	Rule      string   `json:"rule,omitempty"`
+	Action    string   `json:"action"` // sent, failed, skipped
+	Provider  string   `json:"provider,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Requests  int      `json:"requests"`
+	Bytes     int      `json:"bytes"` // 请求体的总字节数（含重试）
+	Reason    string   `json:"reason,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// egressAudit 追加写入 JSONL 审计日志
+type egressAudit struct {
+	mu   sync.Mutex
+	path string
+}
+
+var (
+	egressAuditsMu sync.Mutex
+	egressAudits   = map[string]*egressAudit{}
+)
+
+// sharedEgressAudit 同一路径共用一个外发审计日志，同时运行的多个审核任务写入时不会交错
+func sharedEgressAudit(path string) *egressAudit {
+	key := path
+	if abs, err := filepath.Abs(path); err == nil {
+		key = abs
+	}
+	egressAuditsMu.Lock()
+	defer egressAuditsMu.Unlock()
+	a := egressAudits[key]
+	if a == nil {
+		a = &egressAudit{path: path}
+		egressAudits[key] = a
+	}
+	return a
+}
+
+func (a *egressAudit) write(entry egressEntry) {
+	entry.Time = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		fmt.Printf("  ⚠️  写入外发审计日志失败: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("  ⚠️  写入外发审计日志失败: %v\n", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"svn-ai-reviewer/internal/audit"
+	"svn-ai-reviewer/internal/config"
+)
+
+// recordingClient 记录收到的文件，用于判断内容发送给了哪个模型
+type recordingClient struct {
+	files []string
+}
+
+func (c *recordingClient) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	c.files = append(c.files, fileName)
+	return &ReviewResult{FileName: fileName, Success: true}, nil
+}
+
+func newTestRouter(t *testing.T, local Client) (*EgressRouter, *recordingClient, string) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Egress.Rules = []config.EgressRule{
+		{Path: "secrets/*", Policy: "never-send"},
+		{Path: "src/crypto/**", Policy: "local-only"},
+		{Path: "*.pem", Policy: "never-send"},
+	}
+	cfg.Egress.AuditLog = filepath.Join(t.TempDir(), "egress-audit.jsonl")
+	remote := &recordingClient{}
+	router, err := NewEgressRouter(remote, local, cfg)
+	if err != nil {
+		t.Fatalf("NewEgressRouter() error = %v", err)
+	}
+	return router, remote, cfg.Egress.AuditLog
+}
+
+func TestEgressPolicy(t *testing.T) {
+	router, _, _ := newTestRouter(t, nil)
+
+	tests := []struct {
+		name string
+		path string
+		want Policy
+	}{
+		{"本地模式", "secrets/db.yaml", PolicyNeverSend},
+		{"本地模式未命中", "src/main.go", PolicyRemote},
+		{"在线模式", "/trunk/src/crypto/aes.go", PolicyLocalOnly},
+		{"源代码审核：带扫描目录", "project/secrets/db.yaml", PolicyNeverSend},
+		{"源代码审核：绝对路径", "/data/project/secrets/db.yaml", PolicyNeverSend},
+		{"源代码审核：绝对路径下的目录规则", "/data/project/src/crypto/aes.go", PolicyLocalOnly},
+		{"源代码审核：Windows 路径", "D:\\project\\secrets\\db.yaml", PolicyNeverSend},
+		{"文件名规则", "/data/project/certs/server.pem", PolicyNeverSend},
+		{"目录名只匹配完整的一级", "/data/project/mysecrets/db.yaml", PolicyRemote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := router.Policy(tt.path); got != tt.want {
+				t.Errorf("Policy(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEgressRouterReview(t *testing.T) {
+	local := &recordingClient{}
+	router, remote, _ := newTestRouter(t, local)
+
+	files := []string{
+		"/data/project/secrets/db.yaml",
+		"/data/project/src/crypto/aes.go",
+		"/data/project/src/main.go",
+	}
+	for _, f := range files {
+		result, err := router.Review(context.Background(), f, "content", "")
+		if err != nil {
+			t.Fatalf("Review(%q) error = %v", f, err)
+		}
+		if f == files[0] && result.Skipped == "" {
+			t.Errorf("Review(%q) was not skipped", f)
+		}
+	}
+
+	if len(remote.files) != 1 || remote.files[0] != files[2] {
+		t.Errorf("remote received %v, want [%s]", remote.files, files[2])
+	}
+	if len(local.files) != 1 || local.files[0] != files[1] {
+		t.Errorf("local received %v, want [%s]", local.files, files[1])
+	}
+}
+
+func TestEgressRouterLocalOnlyWithoutLocal(t *testing.T) {
+	router, remote, auditPath := newTestRouter(t, nil)
+
+	result, err := router.Review(context.Background(), "/data/project/src/crypto/aes.go", "content", "")
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if result.Skipped == "" {
+		t.Errorf("Review() without local provider was not skipped")
+	}
+	if len(remote.files) != 0 {
+		t.Errorf("remote received %v, want nothing", remote.files)
+	}
+	// 跳过的文件也写入外发审计日志
+	if files := audit.Files(auditPath); len(files) != 1 {
+		t.Errorf("egress audit log files = %v, want 1", files)
+	}
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"svn-ai-reviewer/internal/filecheck"
+)
+
+// FileFilter 在发送给 AI 之前跳过二进制、压缩、自动生成和第三方代码文件的客户端包装
+// 跳过的文件在报告中注明原因，路径匹配 file_filter.review 的文件不做检测
+type FileFilter struct {
+	inner   Client
+	checker *filecheck.Checker
+}
+
+// NewFileFilter 包装客户端
+func NewFileFilter(inner Client, checker *filecheck.Checker) *FileFilter {
+	return &FileFilter{inner: inner, checker: checker}
+}
+
+// Unwrap 返回被包装的客户端
+func (f *FileFilter) Unwrap() Client {
+	return f.inner
+}
+
+func (f *FileFilter) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	if !isChangeset(ctx) {
+		if r, skip := f.checker.Check(fileName, diff); skip {
+			fmt.Printf("  🚫 %s，跳过审核\n", r.Reason)
+			return &ReviewResult{FileName: fileName, Success: true, Skipped: r.Reason}, nil
+		}
+	}
+	return f.inner.Review(ctx, fileName, diff, systemPrompt)
+}
+
+// FileSkipReason 返回客户端链中的文件过滤器跳过该文件的原因，不跳过或未启用过滤时返回空字符串
+func FileSkipReason(client Client, filePath, content string) string {
+	for client != nil {
+		if filter, ok := client.(*FileFilter); ok {
+			if r, skip := filter.checker.Check(filePath, content); skip {
+				return r.Reason
+			}
+			return ""
+		}
+		u, ok := client.(interface{ Unwrap() Client })
+		if !ok {
+			break
+		}
+		client = u.Unwrap()
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"svn-ai-reviewer/internal/lint"
+	"svn-ai-reviewer/internal/precheck"
+)
+
+// Linter 运行外部检查工具的客户端包装
+// 工具发现的问题只保留变更的行（diff 中新增的行，完整文件时为所有行），标记为 IssueSourceLint 合并到审核结果中；
+// 开启 explain 时同时附加到提示词中，请模型挑出需要优先处理的问题并解释
+type Linter struct {
+	inner   Client
+	runner  *lint.Runner
+	explain bool
+}
+
+// NewLinter 包装客户端
+func NewLinter(inner Client, runner *lint.Runner, explain bool) *Linter {
+	return &Linter{inner: inner, runner: runner, explain: explain}
+}
+
+// Unwrap 返回被包装的客户端
+func (l *Linter) Unwrap() Client {
+	return l.inner
+}
+
+type localDirKey struct{}
+
+// WithLocalDir 审核的文件名是 dir 下的本地文件（本地工作副本、源代码模式），外部检查工具直接检查该文件
+// dir 为空表示文件名相对当前目录
+func WithLocalDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, localDirKey{}, dir)
+}
+
+type contentLoaderKey struct{}
+
+// WithContentLoader 设置取得被审核文件新版本完整内容的函数
+// 在线模式中修改的文件只有 diff，需要运行外部检查工具时才会调用
+func WithContentLoader(ctx context.Context, load func() (string, error)) context.Context {
+	return context.WithValue(ctx, contentLoaderKey{}, load)
+}
+
+func (l *Linter) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	if isChangeset(ctx) || !l.runner.Matches(fileName, false) {
+		return l.inner.Review(ctx, fileName, diff, systemPrompt)
+	}
+
+	findings := l.lint(ctx, fileName, diff)
+	if len(findings) > 0 {
+		fmt.Printf("  🧹 外部检查工具发现 %d 处问题\n", len(findings))
+		if l.explain {
+			systemPrompt += lint.Prompt(findings)
+		}
+	}
+
+	result, err := l.inner.Review(ctx, fileName, diff, systemPrompt)
+	return mergeIssues(result, err, fileName, lintIssues(findings), false)
+}
+
+// lint 运行外部检查工具，diff 只保留新增行上的问题
+func (l *Linter) lint(ctx context.Context, fileName, diff string) []lint.Finding {
+	// 不是本地文件时只能写入临时文件检查，依赖项目的工具无法运行，没有 single_file 的工具时也不再获取文件内容
+	_, local := ctx.Value(localDirKey{}).(string)
+	if !local && !l.runner.Matches(fileName, true) {
+		return nil
+	}
+
+	file, dir, cleanup, err := lintFile(ctx, fileName, diff)
+	if err != nil {
+		fmt.Printf("  ⚠️  未运行外部检查工具: %v\n", err)
+		return nil
+	}
+	if file == "" {
+		return nil
+	}
+	defer cleanup()
+
+	findings, errs := l.runner.Run(ctx, fileName, file, dir, !local)
+	for _, err := range errs {
+		fmt.Printf("  ⚠️  %v\n", err)
+	}
+
+	added, isDiff := precheck.AddedLines(diff)
+	if !isDiff {
+		return findings
+	}
+	changed := findings[:0]
+	for _, f := range findings {
+		if added[f.Line] {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// lintFile 返回交给外部检查工具的本地文件和运行工具的目录，无法取得文件时返回空字符串
+//   - 本地文件（WithLocalDir）直接检查，已删除的文件不检查
+//   - 完整内容（新增文件）或 WithContentLoader 取得的内容写入临时文件，文件名与原文件相同；
+//     临时目录中没有项目的其他文件和配置，只运行 single_file 的工具
+func lintFile(ctx context.Context, fileName, diff string) (string, string, func(), error) {
+	if dir, ok := ctx.Value(localDirKey{}).(string); ok {
+		file := fileName
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			return "", "", nil, nil
+		}
+		return file, dir, func() {}, nil
+	}
+
+	content, ok := precheck.FileContent(diff)
+	if !ok {
+		load, _ := ctx.Value(contentLoaderKey{}).(func() (string, error))
+		if load == nil {
+			return "", "", nil, nil
+		}
+		var err error
+		if content, err = load(); err != nil {
+			return "", "", nil, fmt.Errorf("获取文件完整内容失败: %w", err)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "svn-reviewer-lint-")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	file := filepath.Join(tmpDir, path.Base(filepath.ToSlash(fileName)))
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", "", nil, fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	return file, "", func() { os.RemoveAll(tmpDir) }, nil
+}
+
+// lintIssues 将外部检查工具的结果转换为问题
+func lintIssues(findings []lint.Finding) []Issue {
+	issues := make([]Issue, 0, len(findings))
+	for _, f := range findings {
+		title, detail, _ := strings.Cut(strings.TrimSpace(f.Message), "\n")
+
+		where := ""
+		switch {
+		case f.Line > 0 && f.Column > 0:
+			where = fmt.Sprintf("第 %d 行第 %d 列", f.Line, f.Column)
+		case f.Line > 0:
+			where = fmt.Sprintf("第 %d 行", f.Line)
+		}
+		source := f.Tool
+		if f.Rule != "" {
+			source += " 规则 " + f.Rule
+		}
+		desc := fmt.Sprintf("%s（%s）", where, source)
+		if where == "" {
+			desc = fmt.Sprintf("（%s）", source)
+		}
+		if detail = strings.TrimSpace(detail); detail != "" {
+			desc += "\n" + detail
+		}
+
+		suggestion := fmt.Sprintf("按照 %s 的规则说明修改；确认是误报时在代码或工具配置中忽略该规则。", f.Tool)
+		if f.HelpURI != "" {
+			suggestion = "参考规则说明修改: " + f.HelpURI
+		}
+
+		issues = append(issues, Issue{
+			Severity:    f.Severity,
+			Title:       title,
+			Description: desc,
+			Suggestion:  suggestion,
+			Source:      IssueSourceLint,
+			Tool:        f.Tool,
+		})
+	}
+	return issues
+}
@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"svn-ai-reviewer/internal/audit"
 	"svn-ai-reviewer/internal/config"
 )
 
@@ -19,6 +21,7 @@ type OpenAIClient struct {
 	temperature float32
 	maxTokens   int
 	httpClient  *http.Client
+	audit       *audit.Logger
 }
 
 type chatMessage struct {
@@ -64,9 +67,20 @@ func NewOpenAIClient(cfg *config.AIConfig) *OpenAIClient {
 	}
 }
 
+// SetAuditLogger 设置请求审计日志
+func (c *OpenAIClient) SetAuditLogger(logger *audit.Logger) {
+	c.audit = logger
+}
+
 // makeRequest 发起 API 请求的辅助函数
-func (c *OpenAIClient) makeRequest(ctx context.Context, jsonData []byte) (string, error) {
+func (c *OpenAIClient) makeRequest(ctx context.Context, jsonData []byte) (content string, err error) {
 	url := c.baseURL + "/chat/completions"
+	record := &requestRecord{provider: "openai", model: c.model, url: url, start: time.Now()}
+	defer func() {
+		record.err = err
+		record.write(ctx, c.audit)
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
@@ -75,16 +89,19 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, jsonData []byte) (string
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
+	recordTransmission(ctx, url, len(jsonData))
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("API 请求失败: %v", err)
 	}
 	defer resp.Body.Close()
+	record.status = resp.StatusCode
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("读取响应失败: %w", err)
 	}
+	record.response = string(body)
 
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("API 返回错误状态码: %d\n响应内容:\n%s", resp.StatusCode, string(body))
@@ -94,6 +111,11 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, jsonData []byte) (string
 	if err := json.Unmarshal(body, &chatResp); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w\n原始响应:\n%s", err, string(body))
 	}
+	record.usage = audit.Usage{
+		PromptTokens:     chatResp.Usage.PromptTokens,
+		CompletionTokens: chatResp.Usage.CompletionTokens,
+		TotalTokens:      chatResp.Usage.TotalTokens,
+	}
 
 	if len(chatResp.Choices) == 0 {
 		respJSON, _ := json.MarshalIndent(chatResp, "", "  ")
@@ -104,6 +126,11 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, jsonData []byte) (string
 }
 
 func (c *OpenAIClient) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	// 任务已取消时不再发起请求
+	if err := ctx.Err(); err != nil {
+		return &ReviewResult{FileName: fileName, Success: false, Error: err}, err
+	}
+
 	// 检查文件内容大小，避免请求过大
 	const maxDiffSize = 50000 // 50KB 限制
 	if len(diff) > maxDiffSize {
@@ -111,6 +138,7 @@ func (c *OpenAIClient) Review(ctx context.Context, fileName, diff, systemPrompt
 		diff = truncated + fmt.Sprintf("\n\n... (内容过长，已截断，原始大小: %d 字节)", len(diff))
 	}
 	
+	ctx = withRequestInfo(ctx, fileName, diff, systemPrompt)
 	userPrompt := fmt.Sprintf("文件名: %s\n\n代码变更:\n```\n%s\n```\n\n请审核以上代码变更。", fileName, diff)
 
 	reqBody := chatRequest{
@@ -159,7 +187,7 @@ func (c *OpenAIClient) Review(ctx context.Context, fileName, diff, systemPrompt
 	parseErr := json.Unmarshal([]byte(cleanContent), &reviewData)
 	
 	// 如果 JSON 解析失败，重试一次
-	if parseErr != nil {
+	if parseErr != nil && ctx.Err() == nil {
 		fmt.Printf("  [警告] JSON 解析失败: %v\n", parseErr)
 		fmt.Printf("  [警告] 原始内容: %s\n", cleanContent[:min(200, len(cleanContent))])
 		fmt.Printf("  [信息] 正在重试请求...\n")
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"svn-ai-reviewer/internal/precheck"
+)
+
+// Prechecker 在发送给 AI 之前执行静态规则检查的客户端包装
+// 发现的问题标记为 IssueSourceRule 并合并到审核结果中；开启 hints 时同时告诉模型，避免重复报告
+type Prechecker struct {
+	inner   Client
+	checker *precheck.Checker
+	hints   bool
+}
+
+// NewPrechecker 包装客户端
+func NewPrechecker(inner Client, checker *precheck.Checker, hints bool) *Prechecker {
+	return &Prechecker{inner: inner, checker: checker, hints: hints}
+}
+
+// Unwrap 返回被包装的客户端
+func (p *Prechecker) Unwrap() Client {
+	return p.inner
+}
+
+func (p *Prechecker) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	if isChangeset(ctx) {
+		return p.inner.Review(ctx, fileName, diff, systemPrompt)
+	}
+
+	findings := p.checker.Check(fileName, diff)
+	if len(findings) > 0 {
+		fmt.Printf("  🔎 静态检查发现 %d 处问题\n", len(findings))
+		if p.hints {
+			// 提示中只有规则标题和行号，不包含代码，不影响敏感信息脱敏和外发控制
+			systemPrompt += precheck.Hints(findings)
+		}
+	}
+
+	result, err := p.inner.Review(ctx, fileName, diff, systemPrompt)
+	return mergeIssues(result, err, fileName, ruleIssues(findings), false)
+}
+
+// ruleIssues 将静态检查结果转换为问题
+func ruleIssues(findings []precheck.Finding) []Issue {
+	issues := make([]Issue, 0, len(findings))
+	for _, f := range findings {
+		desc := f.Snippet
+		if f.Line > 0 {
+			desc = fmt.Sprintf("第 %d 行: %s", f.Line, f.Snippet)
+		}
+		issues = append(issues, Issue{
+			Severity:    f.Severity,
+			Title:       f.Title,
+			Description: fmt.Sprintf("%s（规则 %s）", desc, f.Rule),
+			Suggestion:  f.Suggestion,
+			Source:      IssueSourceRule,
+		})
+	}
+	return issues
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"svn-ai-reviewer/internal/audit"
+)
+
+// auditable 支持写入请求审计日志的客户端
+type auditable interface {
+	SetAuditLogger(logger *audit.Logger)
+}
+
+// requestInfo 审计日志需要的请求信息，由 Review 放入上下文，makeRequest 读取
+type requestInfo struct {
+	fileName   string
+	diffHash   string
+	promptHash string
+}
+
+type requestInfoKey struct{}
+
+func withRequestInfo(ctx context.Context, fileName, diff, systemPrompt string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, requestInfo{
+		fileName:   fileName,
+		diffHash:   audit.Hash(diff),
+		promptHash: audit.Hash(systemPrompt),
+	})
+}
+
+// requestRecord 一次 makeRequest 调用的结果
+type requestRecord struct {
+	provider string
+	model    string
+	url      string
+	start    time.Time
+	status   int
+	response string
+	usage    audit.Usage
+	err      error
+}
+
+// write 写入审计日志，logger 为空时不记录；写入失败只输出警告，不影响审核
+func (r *requestRecord) write(ctx context.Context, logger *audit.Logger) {
+	if logger == nil {
+		return
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	entry := &audit.Entry{
+		Time:       r.start,
+		User:       audit.UserFrom(ctx),
+		Provider:   r.provider,
+		Model:      r.model,
+		Endpoint:   r.url,
+		File:       info.fileName,
+		DiffHash:   info.diffHash,
+		PromptHash: info.promptHash,
+		Usage:      r.usage,
+		LatencyMS:  time.Since(r.start).Milliseconds(),
+		Status:     r.status,
+		Response:   r.response,
+	}
+	if r.err != nil {
+		entry.Error = r.err.Error()
+	}
+	if err := logger.Write(entry); err != nil {
+		fmt.Printf("  [警告] %v\n", err)
+	}
+}
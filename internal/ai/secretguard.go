@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"svn-ai-reviewer/internal/audit"
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/filecheck"
+	"svn-ai-reviewer/internal/lint"
+	"svn-ai-reviewer/internal/precheck"
+	"svn-ai-reviewer/internal/secret"
+)
+
+// SecretGuard 在发送给 AI 之前扫描敏感信息的客户端包装
+// 发现的密码、令牌、私钥等会从发送内容中脱敏，并作为高危问题直接写入审核结果（不依赖 AI）
+type SecretGuard struct {
+	inner   Client
+	scanner *secret.Scanner
+}
+
+// NewSecretGuard 包装客户端
+func NewSecretGuard(inner Client, scanner *secret.Scanner) *SecretGuard {
+	return &SecretGuard{inner: inner, scanner: scanner}
+}
+
+// Unwrap 返回被包装的客户端
+func (g *SecretGuard) Unwrap() Client {
+	return g.inner
+}
+
+func (g *SecretGuard) Review(ctx context.Context, fileName, diff, systemPrompt string) (*ReviewResult, error) {
+	redacted, findings := g.scanner.Redact(diff)
+	if len(findings) > 0 {
+		fmt.Printf("  ⚠️  检测到 %d 处疑似敏感信息，已脱敏后再发送\n", len(findings))
+	}
+	// 外层的 Linter、Prechecker 会把检查结果附加到提示词中，工具输出可能引用代码原文，提示词同样脱敏；
+	// 提示词中的发现与 diff 重复或行号不对应，不写入结果
+	systemPrompt, _ = g.scanner.Redact(systemPrompt)
+
+	result, err := g.inner.Review(ctx, fileName, redacted, systemPrompt)
+	if isChangeset(ctx) {
+		// 整体审核的摘要中逐文件的问题已经报告过，只脱敏
+		return result, err
+	}
+	// 高危问题放在最前面
+	return mergeIssues(result, err, fileName, findingIssues(findings), true)
+}
+
+// findingIssues 将扫描结果转换为高危问题
+func findingIssues(findings []secret.Finding) []Issue {
+	issues := make([]Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, Issue{
+			Severity:    "high",
+			Title:       "疑似泄露" + f.Description,
+			Description: fmt.Sprintf("第 %d 行发现疑似%s: %s（规则 %s，发送给 AI 前已脱敏）", f.Line, f.Description, f.Preview, f.Rule),
+			Suggestion:  "从代码中移除该凭据，改为从环境变量或密钥管理服务读取；如果已经提交到仓库，请立即作废并更换该凭据。",
+			Source:      IssueSourceSecret,
+		})
+	}
+	return issues
+}
+
+// NewReviewClient 创建审核流程使用的客户端：在 NewClient 的基础上加上请求审计、外发控制、发送前的敏感信息扫描、
+// 静态规则检查、外部检查工具以及二进制、生成文件的过滤
+func NewReviewClient(cfg *config.Config) (Client, error) {
+	client, err := NewClient(&cfg.AI)
+	if err != nil {
+		return nil, err
+	}
+
+	var logger *audit.Logger
+	if cfg.Audit.Enabled {
+		logger = audit.NewLogger(cfg.Audit.Path, cfg.Audit.MaxSizeMB, cfg.Audit.MaxBackups)
+		if a, ok := client.(auditable); ok {
+			a.SetAuditLogger(logger)
+		}
+	}
+
+	if cfg.Egress.Enabled() {
+		var local Client
+		if cfg.Egress.LocalProvider.Provider != "" {
+			if local, err = NewClient(&cfg.Egress.LocalProvider); err != nil {
+				return nil, fmt.Errorf("egress.local_provider: %w", err)
+			}
+			if a, ok := local.(auditable); ok && logger != nil {
+				a.SetAuditLogger(logger)
+			}
+		}
+		if client, err = NewEgressRouter(client, local, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if !cfg.SecretScan.Disabled {
+		scanner, err := secret.NewScanner(&cfg.SecretScan)
+		if err != nil {
+			return nil, err
+		}
+		client = NewSecretGuard(client, scanner)
+	}
+
+	if !cfg.Precheck.Disabled {
+		checker, err := precheck.New(&cfg.Precheck)
+		if err != nil {
+			return nil, err
+		}
+		client = NewPrechecker(client, checker, cfg.Precheck.Hints)
+	}
+
+	if len(cfg.Lint.Tools) > 0 {
+		runner, err := lint.New(&cfg.Lint)
+		if err != nil {
+			return nil, err
+		}
+		client = NewLinter(client, runner, cfg.Lint.Explain)
+	}
+
+	if !cfg.FileFilter.Disabled {
+		client = NewFileFilter(client, filecheck.New(&cfg.FileFilter))
+	}
+	return client, nil
+}
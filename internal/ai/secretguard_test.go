@@ -0,0 +1,21 @@
+package ai
+
+import (
+	"testing"
+
+	"svn-ai-reviewer/internal/secret"
+)
+
+func TestFindingIssues(t *testing.T) {
+	issues := findingIssues([]secret.Finding{{Rule: "aws-access-key", Description: "AWS Access Key ID", Line: 3, Preview: "AKIA****MPLE"}})
+	if len(issues) != 1 {
+		t.Fatalf("findingIssues() = %d issues, want 1", len(issues))
+	}
+	// 报告按来源显示标签，不能算作 AI 发现的问题
+	if issues[0].Source != IssueSourceSecret {
+		t.Errorf("Source = %q, want %q", issues[0].Source, IssueSourceSecret)
+	}
+	if issues[0].Severity != "high" {
+		t.Errorf("Severity = %q, want high", issues[0].Severity)
+	}
+}
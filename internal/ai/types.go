@@ -13,4 +13,13 @@ type Issue struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Suggestion  string `json:"suggestion"`
+	Source      string `json:"source,omitempty"` // 问题来源：空表示模型给出，见 IssueSourceRule、IssueSourceLint、IssueSourceSecret
+	Tool        string `json:"tool,omitempty"`   // 外部检查工具的名称
 }
+
+// 不依赖 AI 的问题来源
+const (
+	IssueSourceRule   = "rule"   // 静态规则检查
+	IssueSourceLint   = "lint"   // 外部检查工具
+	IssueSourceSecret = "secret" // 敏感信息扫描
+)
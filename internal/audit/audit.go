@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry 一次 AI 请求的审计记录
+type Entry struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Endpoint   string    `json:"endpoint"`
+	File       string    `json:"file"`
+	DiffHash   string    `json:"diff_hash"`   // 发送的代码内容的 SHA-256
+	PromptHash string    `json:"prompt_hash"` // 系统提示词的 SHA-256
+	Usage      Usage     `json:"usage"`
+	LatencyMS  int64     `json:"latency_ms"`
+	Status     int       `json:"status"` // HTTP 状态码，请求未发出时为 0
+	Error      string    `json:"error,omitempty"`
+	Response   string    `json:"response"` // 原始响应内容
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Logger 追加写入 JSONL 审计日志，文件超过大小上限时轮转
+// 轮转后的文件为 path.1（最新）… path.N（最旧）
+type Logger struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+}
+
+var (
+	loggersMu sync.Mutex
+	loggers   = map[string]*Logger{}
+)
+
+// NewLogger 返回写入 path 的审计日志，maxSizeMB <= 0 表示不轮转
+// 同一路径共用一个 Logger：网页端同时运行的多个审核任务各自创建客户端，写入和轮转需要同一把锁，
+// 否则记录可能交错，轮转时也可能重命名另一个任务正在写入的文件；轮转参数以最后一次调用为准
+func NewLogger(path string, maxSizeMB, maxBackups int) *Logger {
+	key := path
+	if abs, err := filepath.Abs(path); err == nil {
+		key = abs
+	}
+
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	l := loggers[key]
+	if l == nil {
+		l = &Logger{path: path}
+		loggers[key] = l
+	}
+	l.mu.Lock()
+	l.maxSize = int64(maxSizeMB) * 1024 * 1024
+	l.maxBackups = maxBackups
+	l.mu.Unlock()
+	return l
+}
+
+// Write 追加一条记录
+func (l *Logger) Write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+	if err := l.rotate(int64(len(data))); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// rotate 写入 size 字节后超过上限时轮转
+func (l *Logger) rotate(size int64) error {
+	if l.maxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(l.path)
+	if err != nil || info.Size() == 0 || info.Size()+size <= l.maxSize {
+		return nil
+	}
+
+	if l.maxBackups <= 0 {
+		return os.Remove(l.path)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("轮转审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// Files 返回审计日志及其轮转文件，按时间从旧到新排列
+func Files(path string) []string {
+	var files []string
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(name); err != nil {
+			break
+		}
+		files = append([]string{name}, files...)
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// Read 按时间顺序读取审计日志（含轮转文件）中满足条件的记录
+func Read(path string, match func(*Entry) bool) ([]*Entry, error) {
+	files := Files(path)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("审计日志不存在: %s", path)
+	}
+
+	var entries []*Entry
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("打开审计日志失败: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue // 跳过写入中断等原因造成的损坏行
+			}
+			if match == nil || match(&e) {
+				entries = append(entries, &e)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取审计日志失败: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+// Hash 计算内容的 SHA-256
+func Hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+type userKey struct{}
+
+// WithUser 指定发起请求的用户（如网页端登录的用户）
+func WithUser(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, userKey{}, name)
+}
+
+var (
+	osUserOnce sync.Once
+	osUserName string
+)
+
+// UserFrom 返回上下文中的用户，未指定时使用当前系统用户
+func UserFrom(ctx context.Context) string {
+	if name, ok := ctx.Value(userKey{}).(string); ok && name != "" {
+		return name
+	}
+	osUserOnce.Do(func() {
+		if u, err := user.Current(); err == nil {
+			osUserName = u.Username
+		}
+	})
+	return osUserName
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestLoggerRotate(t *testing.T) {
+	tests := []struct {
+		name       string
+		writes     int
+		maxBackups int
+		wantFiles  int // 包括当前文件
+	}{
+		{"未超过上限", 1, 2, 1},
+		{"轮转一次", 2, 2, 2},
+		{"超过保留数量时删除最旧的", 5, 2, 3},
+		{"不保留轮转文件", 3, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit", "ai-audit.jsonl")
+			// 每条记录都超过一半上限，第二条开始每次写入都会轮转
+			l := &Logger{path: path, maxSize: 200, maxBackups: tt.maxBackups}
+			for i := 0; i < tt.writes; i++ {
+				if err := l.Write(&Entry{File: fmt.Sprintf("file%d.go", i), Response: "0123456789012345678901234567890123456789"}); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+
+			files := Files(path)
+			if len(files) != tt.wantFiles {
+				t.Fatalf("Files() = %v, want %d files", files, tt.wantFiles)
+			}
+			entries, err := Read(path, nil)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			// 保留的记录按时间顺序排列，最后一条是最新写入的
+			if len(entries) != tt.wantFiles {
+				t.Fatalf("Read() = %d entries, want %d", len(entries), tt.wantFiles)
+			}
+			if got, want := entries[len(entries)-1].File, fmt.Sprintf("file%d.go", tt.writes-1); got != want {
+				t.Errorf("last entry File = %q, want %q", got, want)
+			}
+			if got, want := entries[0].File, fmt.Sprintf("file%d.go", tt.writes-tt.wantFiles); got != want {
+				t.Errorf("first entry File = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLoggerFileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "ai-audit.jsonl")
+	if err := NewLogger(path, 0, 0).Write(&Entry{File: "a.go"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("file mode = %o, want 600", mode)
+	}
+}
+
+func TestNewLoggerShared(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ai-audit.jsonl")
+
+	a := NewLogger(path, 1, 2)
+	b := NewLogger(filepath.Join(dir, ".", "ai-audit.jsonl"), 1, 2)
+	if a != b {
+		t.Errorf("NewLogger() with the same path returned different loggers")
+	}
+	if c := NewLogger(filepath.Join(dir, "other.jsonl"), 1, 2); c == a {
+		t.Errorf("NewLogger() with a different path returned the same logger")
+	}
+
+	// 多个任务并发写入同一路径，每一行都应完整
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := NewLogger(path, 1, 2)
+			for j := 0; j < 20; j++ {
+				l.Write(&Entry{File: fmt.Sprintf("job%d-%d.go", i, j)})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := Read(path, nil)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 8*20 {
+		t.Errorf("Read() = %d entries, want %d", len(entries), 8*20)
+	}
+}
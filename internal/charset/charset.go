@@ -0,0 +1,225 @@
+package charset
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 检测结果中的编码名称
+const (
+	UTF8     = "UTF-8"
+	UTF16LE  = "UTF-16LE"
+	UTF16BE  = "UTF-16BE"
+	GB18030  = "GB18030"
+	Big5     = "Big5"
+	ShiftJIS = "Shift_JIS"
+)
+
+const (
+	sniffSize     = 8000 // 与二进制检测相同，开头有 NUL 字节的内容不转换
+	gb2312Percent = 90   // 双字节字符几乎都在 GB2312 常用区时判断为 GBK
+	kanaPercent   = 20   // 按 Shift_JIS 解码后假名占比超过该值时判断为日文
+)
+
+// FromMimeType 从 svn:mime-type（如 "text/plain; charset=GBK"）中取出 charset 参数
+func FromMimeType(mimeType string) string {
+	if mimeType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// Decode 将文件内容转换为 UTF-8，返回转换后的文本和原始编码
+// 按顺序判断：BOM、是否为有效的 UTF-8、hint 指定的编码（svn:mime-type 的 charset 或配置的默认编码）、GBK/Big5/Shift_JIS 启发式检测
+// 看起来是二进制或无法判断编码时原样返回，编码为空
+func Decode(data []byte, hint string) (string, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return string(data[3:]), UTF8
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return decodeWith(data, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), UTF16LE, "")
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return decodeWith(data, unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), UTF16BE, "")
+	}
+	if utf8.Valid(data) {
+		return string(data), UTF8
+	}
+	sample := data
+	if len(sample) > sniffSize {
+		sample = sample[:sniffSize]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return string(data), ""
+	}
+
+	if enc, name := lookup(hint); enc != nil {
+		return decodeWith(data, enc, name, string(data))
+	}
+	enc, name := detect(data)
+	if enc == nil {
+		return string(data), ""
+	}
+	return decodeWith(data, enc, name, string(data))
+}
+
+// DecodeDiff 转换 diff 输出，只转换不是有效 UTF-8 的行
+// svn 输出的 Index、---、+++ 等行中的路径已经是 UTF-8，只有文件内容行保留了原始编码
+func DecodeDiff(diff string, hint string) (string, string) {
+	if utf8.ValidString(diff) {
+		return diff, UTF8
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	var raw bytes.Buffer
+	for _, line := range lines {
+		if !utf8.ValidString(line) {
+			raw.WriteString(line)
+		}
+	}
+	enc, name := lookup(hint)
+	if enc == nil {
+		enc, name = detect(raw.Bytes())
+	}
+	if enc == nil {
+		return diff, ""
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		if utf8.ValidString(line) {
+			sb.WriteString(line)
+			continue
+		}
+		decoded, err := enc.NewDecoder().String(line)
+		if err != nil {
+			decoded = line
+		}
+		sb.WriteString(decoded)
+	}
+	return sb.String(), name
+}
+
+// decodeWith 使用指定编码转换，失败时返回 fallback
+func decodeWith(data []byte, enc encoding.Encoding, name, fallback string) (string, string) {
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return fallback, ""
+	}
+	return string(out), name
+}
+
+// lookup 按名称查找编码，GBK、GB2312 统一使用兼容它们的 GB18030 解码
+func lookup(name string) (encoding.Encoding, string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ""
+	}
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "")) {
+	case "gbk", "gb2312", "gb18030", "cp936", "euccn":
+		return simplifiedchinese.GB18030, GB18030
+	case "utf8":
+		return nil, ""
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, ""
+	}
+	canonical, err := htmlindex.Name(enc)
+	if err != nil {
+		canonical = name
+	}
+	for _, known := range []string{UTF16LE, UTF16BE, Big5, ShiftJIS} {
+		if strings.EqualFold(canonical, known) {
+			canonical = known
+		}
+	}
+	return enc, canonical
+}
+
+// detect 启发式判断 GBK（GB18030）、Big5 或 Shift_JIS
+//   - 双字节字符几乎都落在 GB2312 区（首尾字节都在 0xA1-0xFE）时为简体中文；Big5 约有四成字符的第二字节在 0x40-0x7E
+//   - 按 Shift_JIS 能完整解码且假名较多时为日文
+//   - 否则依次尝试 Big5、GB18030、Shift_JIS，取第一个能完整解码的
+func detect(data []byte) (encoding.Encoding, string) {
+	if len(data) > 64*1024 {
+		data = data[:64*1024]
+	}
+
+	pairs, gb2312 := 0, 0
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b < 0x80 {
+			continue
+		}
+		if i+1 < len(data) {
+			pairs++
+			if b >= 0xa1 && b <= 0xf7 && data[i+1] >= 0xa1 && data[i+1] <= 0xfe {
+				gb2312++
+			}
+			i++
+		}
+	}
+	if pairs == 0 {
+		return nil, ""
+	}
+
+	if gb2312*100 >= pairs*gb2312Percent {
+		if _, ok := tryDecode(simplifiedchinese.GB18030, data); ok {
+			return simplifiedchinese.GB18030, GB18030
+		}
+	}
+
+	// 半角片假名与 GBK 的单字节区间重叠，只统计全角平假名和片假名
+	if text, ok := tryDecode(japanese.ShiftJIS, data); ok {
+		kana, wide := 0, 0
+		for _, r := range text {
+			if r < 0x80 {
+				continue
+			}
+			wide++
+			if r >= 0x3040 && r <= 0x30ff {
+				kana++
+			}
+		}
+		if wide > 0 && kana*100 >= wide*kanaPercent {
+			return japanese.ShiftJIS, ShiftJIS
+		}
+	}
+
+	for _, c := range []struct {
+		enc  encoding.Encoding
+		name string
+	}{
+		{traditionalchinese.Big5, Big5},
+		{simplifiedchinese.GB18030, GB18030},
+		{japanese.ShiftJIS, ShiftJIS},
+	} {
+		if _, ok := tryDecode(c.enc, data); ok {
+			return c.enc, c.name
+		}
+	}
+	return nil, ""
+}
+
+// tryDecode 解码并检查是否出现无法转换的字符，末尾被截断的半个字符忽略
+func tryDecode(enc encoding.Encoding, data []byte) (string, bool) {
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", false
+	}
+	text := strings.TrimSuffix(string(out), "\uFFFD")
+	return text, !strings.ContainsRune(text, utf8.RuneError)
+}
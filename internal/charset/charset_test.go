@@ -0,0 +1,90 @@
+package charset
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, text string) []byte {
+	t.Helper()
+	data, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("encode %q: %v", text, err)
+	}
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	const (
+		zhHans = "// 计算订单的总金额，包括运费和优惠券\nint total = 0;\n"
+		zhHant = "// 計算訂單總額，包括運費與優惠券\nint total = 0;\n"
+		ja     = "// ファイルを読み込んでから、データをチェックします\nint total = 0;\n"
+	)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		hint     string
+		want     string
+		wantName string
+	}{
+		{"UTF-8", []byte(zhHans), "", zhHans, UTF8},
+		{"UTF-8 BOM", append([]byte{0xef, 0xbb, 0xbf}, zhHans...), "", zhHans, UTF8},
+		{"UTF-16LE BOM", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), zhHans), "", zhHans, UTF16LE},
+		{"UTF-16BE BOM", encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), zhHans), "", zhHans, UTF16BE},
+		{"GBK", encode(t, simplifiedchinese.GBK, zhHans), "", zhHans, GB18030},
+		{"Big5", encode(t, traditionalchinese.Big5, zhHant), "", zhHant, Big5},
+		{"Shift_JIS", encode(t, japanese.ShiftJIS, ja), "", ja, ShiftJIS},
+		{"按 hint 解码", encode(t, traditionalchinese.Big5, zhHant), "big5", zhHant, Big5},
+		{"hint 为 GB2312", encode(t, simplifiedchinese.GBK, zhHans), "GB2312", zhHans, GB18030},
+		{"未知 hint 时自动检测", encode(t, simplifiedchinese.GBK, zhHans), "no-such-charset", zhHans, GB18030},
+		{"包含 NUL 字节", []byte("a\x00\xd6\xd0"), "", "a\x00\xd6\xd0", ""},
+		{"无法判断", []byte("\x80"), "", "\x80", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name := Decode(tt.data, tt.hint)
+			if got != tt.want || name != tt.wantName {
+				t.Errorf("Decode() = %q, %q, want %q, %q", got, name, tt.want, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDecodeDiff(t *testing.T) {
+	gbk := encode(t, simplifiedchinese.GBK, "// 修改后的注释\n")
+	diff := "Index: 源码/a.c\n===\n--- 源码/a.c\t(revision 1)\n+++ 源码/a.c\t(working copy)\n@@ -1 +1 @@\n-int a;\n+" + string(gbk)
+	want := "Index: 源码/a.c\n===\n--- 源码/a.c\t(revision 1)\n+++ 源码/a.c\t(working copy)\n@@ -1 +1 @@\n-int a;\n+// 修改后的注释\n"
+
+	got, name := DecodeDiff(diff, "")
+	if got != want || name != GB18030 {
+		t.Errorf("DecodeDiff() = %q, %q, want %q, %q", got, name, want, GB18030)
+	}
+	if got, name := DecodeDiff(want, "gbk"); got != want || name != UTF8 {
+		t.Errorf("DecodeDiff(UTF-8) = %q, %q, want unchanged", got, name)
+	}
+}
+
+func TestFromMimeType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     string
+	}{
+		{"", ""},
+		{"text/plain", ""},
+		{"text/plain; charset=GBK", "GBK"},
+		{"text/x-c; charset=\"big5\"", "big5"},
+		{"not a mime type;;", ""},
+	}
+	for _, tt := range tests {
+		if got := FromMimeType(tt.mimeType); got != tt.want {
+			t.Errorf("FromMimeType(%q) = %q, want %q", tt.mimeType, got, tt.want)
+		}
+	}
+}
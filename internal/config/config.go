@@ -1,20 +1,40 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"svn-ai-reviewer/internal/crypto"
+	"svn-ai-reviewer/internal/svn"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	AI           AIConfig     `yaml:"ai"`
-	ReviewPrompt string       `yaml:"review_prompt"`
-	SVN          SVNConfig    `yaml:"svn"`
-	Ignore       []string     `yaml:"ignore"`
-	Report       ReportConfig `yaml:"report"`
-	Online       OnlineConfig `yaml:"online"`
+	AI           AIConfig         `yaml:"ai"`
+	ReviewPrompt string           `yaml:"review_prompt"`
+	SVN          SVNConfig        `yaml:"svn"`
+	Ignore       []string         `yaml:"ignore"`
+	Report       ReportConfig     `yaml:"report"`
+	Online       OnlineConfig     `yaml:"online"`
+	Changeset    ChangesetConfig  `yaml:"changeset"`
+	Context      ContextConfig    `yaml:"context"`
+	SecretScan   SecretScanConfig `yaml:"secret_scan"`
+	FileFilter   FileFilterConfig `yaml:"file_filter"`
+	Precheck     PrecheckConfig   `yaml:"precheck"`
+	Lint         LintConfig       `yaml:"lint"`
+	Egress       EgressConfig     `yaml:"egress"`
+	Audit        AuditConfig      `yaml:"audit"`
+	Server       ServerConfig     `yaml:"server"`
+
+	// 配置文件中的原始值（密文或 env:/file: 引用），保存时未修改的字段原样写回
+	rawAPIKey   string
+	rawPassword string
+	rawLocalKey string
+	resolved    bool // 是否已调用 ResolveSecrets
 }
 
 type AIConfig struct {
@@ -28,6 +48,28 @@ type AIConfig struct {
 
 type SVNConfig struct {
 	Command string `yaml:"command"`
+	// Backend 在线模式访问仓库的方式: cli（默认，调用 svn 命令）或 native（原生 svn:// 协议）
+	Backend string `yaml:"backend"`
+	// ConfigDir svn 配置目录（--config-dir），留空使用默认的 ~/.subversion
+	ConfigDir string `yaml:"config_dir"`
+	// LogCache 在线模式本地日志缓存
+	LogCache LogCacheConfig `yaml:"log_cache"`
+	// Encoding 文件内容不是 UTF-8、且 svn:mime-type 没有指定 charset 时使用的编码（如 GBK），留空自动检测
+	Encoding string `yaml:"encoding"`
+}
+
+// LogCacheConfig 本地日志缓存配置，按仓库 UUID 保存已获取的提交记录
+type LogCacheConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"` // 缓存目录，默认 ./logcache
+}
+
+// CacheDir 返回启用时的缓存目录，未启用时返回空字符串
+func (c *LogCacheConfig) CacheDir() string {
+	if !c.Enabled {
+		return ""
+	}
+	return c.Dir
 }
 
 type OnlineConfig struct {
@@ -36,11 +78,144 @@ type OnlineConfig struct {
 	Password string `yaml:"password"`
 }
 
+// ChangesetConfig 整体变更审核配置（跨文件第二轮审核）
+type ChangesetConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	Prompt       string `yaml:"prompt"`         // 留空使用内置提示词
+	MaxDiffChars int    `yaml:"max_diff_chars"` // 每个文件摘要中保留的差异字符数
+}
+
+// ContextConfig 差异上下文扩展配置
+type ContextConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Mode     string `yaml:"mode"`      // function: 包含整个函数; lines: 只包含周围若干行
+	Lines    int    `yaml:"lines"`     // 周围行数
+	MaxChars int    `yaml:"max_chars"` // 附加上下文的字符数上限
+}
+
+// SecretScanConfig 发送给 AI 之前的敏感信息扫描配置（默认开启）
+type SecretScanConfig struct {
+	Disabled         bool               `yaml:"disabled"`
+	EntropyThreshold float64            `yaml:"entropy_threshold"` // 高熵字符串的熵阈值，默认 4.0
+	Allowlist        []string           `yaml:"allowlist"`         // 匹配这些正则的内容不视为敏感信息
+	Rules            []SecretRuleConfig `yaml:"rules"`             // 自定义规则
+}
+
+// SecretRuleConfig 自定义敏感信息规则，正则中有子匹配时只脱敏第一个子匹配
+type SecretRuleConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Pattern     string `yaml:"pattern"`
+}
+
+// PrecheckConfig 发送给 AI 之前的静态规则检查配置（默认开启），发现的问题直接写入审核结果
+type PrecheckConfig struct {
+	Disabled     bool                 `yaml:"disabled"`
+	Hints        bool                 `yaml:"hints"`         // 把检查结果作为提示附加到提示词中，让模型不再重复报告
+	DisableRules []string             `yaml:"disable_rules"` // 关闭的内置规则名称
+	Rules        []PrecheckRuleConfig `yaml:"rules"`         // 自定义正则规则
+}
+
+// PrecheckRuleConfig 自定义静态检查规则，按行匹配正则表达式
+type PrecheckRuleConfig struct {
+	Name       string   `yaml:"name"`
+	Pattern    string   `yaml:"pattern"`
+	Files      []string `yaml:"files"`      // 适用的文件（写法同 ignore），为空时适用所有文件
+	Severity   string   `yaml:"severity"`   // high, medium, low，默认 low
+	Title      string   `yaml:"title"`      // 问题标题，默认为规则名称
+	Suggestion string   `yaml:"suggestion"` // 修改建议
+	AddedOnly  bool     `yaml:"added_only"` // 只检查 diff 中新增的行，审核完整文件时不检查
+}
+
+// LintConfig 外部检查工具配置，工具发现的问题只保留变更的行，合并到审核结果中
+type LintConfig struct {
+	Explain bool           `yaml:"explain"` // 把工具发现的问题附加到提示词中，让模型挑出重要的问题并解释
+	Tools   []LinterConfig `yaml:"tools"`
+}
+
+// LinterConfig 一个外部检查工具
+type LinterConfig struct {
+	Name       string   `yaml:"name"`
+	Files      []string `yaml:"files"`       // 适用的文件（写法同 ignore）
+	Command    string   `yaml:"command"`     // 命令模板，{file} 替换为文件的绝对路径，{dir} 替换为文件所在目录
+	Format     string   `yaml:"format"`      // 输出格式: sarif, checkstyle, json
+	Timeout    int      `yaml:"timeout"`     // 超时时间（秒），默认 60
+	Severity   string   `yaml:"severity"`    // 固定的问题级别（high, medium, low），为空时按工具输出的级别换算
+	SingleFile bool     `yaml:"single_file"` // 只需要这一个文件就能检查，在线模式写入临时文件检查时只运行这类工具
+}
+
+// FileFilterConfig 发送给 AI 之前跳过二进制、压缩、生成和第三方代码文件的配置（默认开启）
+type FileFilterConfig struct {
+	Disabled bool     `yaml:"disabled"`
+	Review   []string `yaml:"review"` // 匹配这些路径的文件不做检测，始终发送审核
+	Vendor   []string `yaml:"vendor"` // 第三方代码目录，为空时使用内置列表（vendor/、node_modules/ 等）
+}
+
+// EgressConfig 数据外发控制：哪些文件可以发送给远程模型
+type EgressConfig struct {
+	DefaultPolicy string       `yaml:"default_policy"` // 未匹配任何规则时的策略，默认 remote
+	Rules         []EgressRule `yaml:"rules"`          // 按顺序匹配，第一条命中的规则生效
+	LocalProvider AIConfig     `yaml:"local_provider"` // local-only 文件使用的本地模型
+	AuditLog      string       `yaml:"audit_log"`      // 外发审计日志（JSONL），默认为 ./audit/egress-audit.jsonl
+}
+
+// EgressRule 路径规则
+type EgressRule struct {
+	Path   string `yaml:"path"`   // 通配符，支持 * ? ** 和以 / 结尾的目录
+	Policy string `yaml:"policy"` // remote: 可以发送给远程模型; local-only: 只能发送给本地模型; never-send: 不发送给任何模型
+}
+
+// Enabled 是否配置了外发控制
+func (e *EgressConfig) Enabled() bool {
+	return len(e.Rules) > 0 || (e.DefaultPolicy != "" && e.DefaultPolicy != "remote")
+}
+
+// AuditConfig AI 请求审计日志
+type AuditConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Path       string `yaml:"path"`        // 日志文件（JSONL），默认为 ./audit/ai-audit.jsonl
+	MaxSizeMB  int    `yaml:"max_size_mb"` // 单个文件的大小上限，超过后轮转，默认 20
+	MaxBackups int    `yaml:"max_backups"` // 保留的轮转文件数，默认 5
+}
+
+// ServerConfig 网页端服务配置（svn-reviewer gui 使用）
+type ServerConfig struct {
+	Listen       string     `yaml:"listen"`        // 监听地址，默认 localhost:8080
+	SessionTTL   int        `yaml:"session_ttl"`   // 会话空闲超时（分钟），默认 720
+	MaxJobs      int        `yaml:"max_jobs"`      // 同时运行的审核任务数，默认 2，其余排队
+	JobsDir      string     `yaml:"jobs_dir"`      // 审核任务记录目录，默认 jobs
+	BasePath     string     `yaml:"base_path"`     // URL 前缀，部署在反向代理的子路径下时使用
+	TLSCert      string     `yaml:"tls_cert"`      // HTTPS 证书文件
+	TLSKey       string     `yaml:"tls_key"`       // HTTPS 私钥文件
+	AllowedRoots []string   `yaml:"allowed_roots"` // 网页上可以访问的工作副本和源代码目录，默认只有当前目录
+	ConfigFiles  []string   `yaml:"config_files"`  // 网页上可以加载的配置文件，默认为 config.yaml 和 config 目录下的 yaml 文件
+	SecureCookie bool       `yaml:"secure_cookie"` // 会话 Cookie 总是设置 Secure，反向代理终止 HTTPS 且不转发 X-Forwarded-Proto 时开启
+	Auth         AuthConfig `yaml:"auth"`
+}
+
+// AuthConfig 网页端登录配置
+type AuthConfig struct {
+	Mode      string     `yaml:"mode"`       // none（默认）、local 或 ldap
+	UsersFile string     `yaml:"users_file"` // local 模式的用户文件
+	LDAP      LDAPConfig `yaml:"ldap"`
+}
+
+// LDAPConfig LDAP 简单绑定认证
+type LDAPConfig struct {
+	URL                string `yaml:"url"`                  // ldap://host:389 或 ldaps://host:636
+	BindDN             string `yaml:"bind_dn"`              // 绑定 DN 模板，{username} 替换为登录用户名
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // ldaps 不校验服务器证书（仅用于测试）
+	Timeout            int    `yaml:"timeout"`              // 超时（秒），默认 10
+}
+
 type ReportConfig struct {
-	OutputDir  string `yaml:"output_dir"`
-	AutoOpen   bool   `yaml:"auto_open"`
+	OutputDir string `yaml:"output_dir"`
+	AutoOpen  bool   `yaml:"auto_open"`
 }
 
+// DefaultAuditDir 请求审计日志和外发审计日志的默认目录
+const DefaultAuditDir = "./audit"
+
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -52,20 +227,10 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	// 解密 API Key
-	if cfg.AI.APIKey != "" {
-		decrypted, err := crypto.DecryptAPIKey(cfg.AI.APIKey)
-		if err != nil {
-			// 如果解密失败，可能是明文，尝试直接使用
-			// 这样可以兼容旧的明文配置
-			// 但为了安全，可以选择返回错误强制使用加密
-			// return nil, fmt.Errorf("解密 API Key 失败: %w", err)
-			
-			// 兼容模式：解密失败时使用原值（假设是明文）
-			decrypted = cfg.AI.APIKey
-		}
-		cfg.AI.APIKey = decrypted
-	}
+	// API Key 和密码保留原始值，由需要的命令调用 ResolveSecrets 解析
+	cfg.rawAPIKey = cfg.AI.APIKey
+	cfg.rawPassword = cfg.Online.Password
+	cfg.rawLocalKey = cfg.Egress.LocalProvider.APIKey
 
 	// 设置默认值
 	if cfg.SVN.Command == "" {
@@ -74,14 +239,153 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Report.OutputDir == "" {
 		cfg.Report.OutputDir = "./reports"
 	}
+	if cfg.SVN.LogCache.Dir == "" {
+		cfg.SVN.LogCache.Dir = "./logcache"
+	}
+	if cfg.Context.Mode == "" {
+		cfg.Context.Mode = "function"
+	}
+	if cfg.Context.Lines <= 0 {
+		cfg.Context.Lines = 20
+	}
+	if cfg.Context.MaxChars <= 0 {
+		cfg.Context.MaxChars = 20000
+	}
+	if cfg.Changeset.MaxDiffChars <= 0 {
+		cfg.Changeset.MaxDiffChars = 3000
+	}
+	// 审计日志含有原始响应，不放在报告目录中（网页端通过 /reports/ 提供报告目录的访问）
+	if cfg.Audit.Path == "" {
+		cfg.Audit.Path = filepath.Join(DefaultAuditDir, "ai-audit.jsonl")
+	}
+	if cfg.Egress.AuditLog == "" {
+		cfg.Egress.AuditLog = filepath.Join(DefaultAuditDir, "egress-audit.jsonl")
+	}
+	if cfg.Audit.MaxSizeMB <= 0 {
+		cfg.Audit.MaxSizeMB = 20
+	}
+	if cfg.Audit.MaxBackups <= 0 {
+		cfg.Audit.MaxBackups = 5
+	}
 
 	return &cfg, nil
 }
 
+// ResolveSecrets 解析 API Key 和在线模式密码（支持 enc:v2: 密文、env:/file: 引用、旧版 DES 密文和明文）
+// LoadConfig 不解析，只有需要这些值的命令才调用，未设置的 env: 引用不影响其他命令；重复调用时直接返回
+func (c *Config) ResolveSecrets() error {
+	if c.resolved {
+		return nil
+	}
+	apiKey, err := crypto.ResolveSecret(c.rawAPIKey)
+	if err != nil {
+		return fmt.Errorf("解析 api_key 失败: %w", err)
+	}
+	password, err := crypto.ResolveSecret(c.rawPassword)
+	if err != nil {
+		return fmt.Errorf("解析 online.password 失败: %w", err)
+	}
+	localKey, err := crypto.ResolveSecret(c.rawLocalKey)
+	if err != nil {
+		return fmt.Errorf("解析 egress.local_provider.api_key 失败: %w", err)
+	}
+	c.AI.APIKey = apiKey
+	c.Online.Password = password
+	c.Egress.LocalProvider.APIKey = localKey
+	c.resolved = true
+	return nil
+}
+
+// ContextOptions 转换为 svn 包使用的上下文选项
+func (c *ContextConfig) ContextOptions() svn.ContextOptions {
+	return svn.ContextOptions{
+		Mode:     c.Mode,
+		Lines:    c.Lines,
+		MaxChars: c.MaxChars,
+	}
+}
+
+// SaveConfig 保存配置
+// API Key 和在线模式密码未修改时写回原始值（保留 env:/file: 引用），修改过的用 AES-GCM 加密后写入
 func SaveConfig(path string, cfg *Config) error {
-	data, err := yaml.Marshal(cfg)
+	saved := *cfg
+	var err error
+	if saved.AI.APIKey, err = storedSecret(cfg.AI.APIKey, cfg.rawAPIKey, cfg.resolved); err != nil {
+		return fmt.Errorf("加密 API Key 失败: %w", err)
+	}
+	if saved.Online.Password, err = storedSecret(cfg.Online.Password, cfg.rawPassword, cfg.resolved); err != nil {
+		return fmt.Errorf("加密密码失败: %w", err)
+	}
+	if saved.Egress.LocalProvider.APIKey, err = storedSecret(cfg.Egress.LocalProvider.APIKey, cfg.rawLocalKey, cfg.resolved); err != nil {
+		return fmt.Errorf("加密本地模型 API Key 失败: %w", err)
+	}
+
+	data, err := yaml.Marshal(&saved)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return os.WriteFile(path, data, 0600)
+}
+
+// storedSecret 返回写入配置文件的值
+func storedSecret(value, raw string, resolved bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !resolved && value == raw {
+		// 没有解析过、也没有修改的字段，原样写回
+		return raw, nil
+	}
+	if raw != "" && (crypto.IsEncrypted(raw) || crypto.IsReference(raw)) {
+		if resolved, err := crypto.ResolveSecret(raw); err == nil && resolved == value {
+			return raw, nil
+		}
+	}
+	return crypto.Encrypt(value)
+}
+
+// secretLineRe 匹配配置文件中的 api_key / password 行
+var secretLineRe = regexp.MustCompile(`^(\s*(api_key|password):\s*)(["']?)([^"'#\s]+)(["']?)(.*)$`)
+
+// MigrateLegacySecrets 将配置文件中旧版 DES 加密的 api_key / password 重新用 AES-GCM 加密
+// 按行替换，保留注释和格式，原文件备份为 .bak；返回迁移的字段名
+func MigrateLegacySecrets(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrated []string
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		m := secretLineRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil || !crypto.IsLegacy(m[4]) {
+			continue
+		}
+		plaintext, err := crypto.DecryptAPIKey(m[4])
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行解密失败: %w", i+1, err)
+		}
+		encrypted, err := crypto.Encrypt(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行加密失败: %w", i+1, err)
+		}
+		suffix := ""
+		if strings.HasSuffix(line, "\r") {
+			suffix = "\r"
+		}
+		lines[i] = m[1] + m[3] + encrypted + m[5] + m[6] + suffix
+		migrated = append(migrated, fmt.Sprintf("%s (第 %d 行)", m[2], i+1))
+	}
+
+	if len(migrated) == 0 {
+		return nil, nil
+	}
+	if err := os.WriteFile(path+".bak", data, 0600); err != nil {
+		return nil, fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		return nil, fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return migrated, nil
 }
@@ -4,48 +4,34 @@ import (
 	"crypto/des"
 	"encoding/base64"
 	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-// 写死的 DES 密钥（8字节）
+// 旧版本使用写死的 DES 密钥（8字节）加密，任何拿到程序的人都能解密
+// 现在只用于读取旧配置和 encrypt --migrate 迁移，新的加密统一使用 secret.go 中的 Encrypt
 const desKey = "SVN@2025"
 
-// EncryptAPIKey 加密 API Key
-func EncryptAPIKey(apiKey string) (string, error) {
-	if apiKey == "" {
-		return "", fmt.Errorf("API Key 不能为空")
-	}
-
-	block, err := des.NewCipher([]byte(desKey))
-	if err != nil {
-		return "", fmt.Errorf("创建 DES cipher 失败: %w", err)
-	}
-
-	// 填充数据到 8 的倍数
-	plaintext := []byte(apiKey)
-	plaintext = pkcs5Padding(plaintext, block.BlockSize())
-
-	ciphertext := make([]byte, len(plaintext))
-	
-	// 使用 ECB 模式加密
-	for i := 0; i < len(plaintext); i += block.BlockSize() {
-		block.Encrypt(ciphertext[i:i+block.BlockSize()], plaintext[i:i+block.BlockSize()])
-	}
+// LegacyPrefix 可以手动加在旧版密文前，明确标记为 DES 密文
+const LegacyPrefix = "des:"
 
-	// 返回 Base64 编码的密文
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
-
-// DecryptAPIKey 解密 API Key
+// DecryptAPIKey 解密旧版 DES 密文（可以带 des: 前缀）
+// 填充必须完全正确，且解密结果必须是不含控制字符的 UTF-8 文本，否则视为不是旧版密文
 func DecryptAPIKey(encryptedKey string) (string, error) {
+	encryptedKey = strings.TrimPrefix(encryptedKey, LegacyPrefix)
 	if encryptedKey == "" {
 		return "", fmt.Errorf("加密的 API Key 不能为空")
 	}
 
 	// Base64 解码
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedKey)
+	ciphertext, err := base64.StdEncoding.Strict().DecodeString(encryptedKey)
 	if err != nil {
 		return "", fmt.Errorf("Base64 解码失败: %w", err)
 	}
+	if len(ciphertext) == 0 {
+		return "", fmt.Errorf("密文为空")
+	}
 
 	block, err := des.NewCipher([]byte(desKey))
 	if err != nil {
@@ -68,10 +54,26 @@ func DecryptAPIKey(encryptedKey string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("去除填充失败: %w", err)
 	}
+	if !isPlainText(plaintext) {
+		return "", fmt.Errorf("解密结果不是有效的文本")
+	}
 
 	return string(plaintext), nil
 }
 
+// isPlainText 判断解密结果是否为非空、不含控制字符的 UTF-8 文本
+func isPlainText(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // pkcs5Padding PKCS5 填充
 func pkcs5Padding(data []byte, blockSize int) []byte {
 	padding := blockSize - len(data)%blockSize
@@ -90,9 +92,14 @@ func pkcs5Unpadding(data []byte) ([]byte, error) {
 	}
 	
 	padding := int(data[length-1])
-	if padding > length || padding > des.BlockSize {
+	if padding == 0 || padding > length || padding > des.BlockSize {
 		return nil, fmt.Errorf("无效的填充")
 	}
+	for _, b := range data[length-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("无效的填充")
+		}
+	}
 	
 	return data[:length-padding], nil
 }
@@ -0,0 +1,304 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 密文格式: enc:v2:<base64(模式 | [salt] | nonce | AES-256-GCM 密文)>
+//
+// 密钥来源（每个用户独立，不再写死在程序中）：
+//   - 设置了环境变量 SVN_AI_REVIEWER_PASSPHRASE 时，用 scrypt 从口令派生密钥，salt 随密文保存
+//   - 否则使用密钥文件（默认 ~/.svn-ai-reviewer/key，可用 SVN_AI_REVIEWER_KEY_FILE 指定），
+//     首次加密时自动生成，权限 0600
+//
+// 配置中的敏感字段还支持引用：
+//   - env:VAR     读取环境变量 VAR
+//   - file:/path  读取文件内容（去掉首尾空白）
+
+const (
+	// SecretPrefix 新格式密文的前缀
+	SecretPrefix = "enc:v2:"
+
+	// PassphraseEnv 口令环境变量
+	PassphraseEnv = "SVN_AI_REVIEWER_PASSPHRASE"
+	// KeyFileEnv 密钥文件路径环境变量
+	KeyFileEnv = "SVN_AI_REVIEWER_KEY_FILE"
+
+	modeKeyFile    byte = 1
+	modePassphrase byte = 2
+
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt 参数（约 64MB 内存）
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	keyFileMu  sync.Mutex
+	derivedMu  sync.Mutex
+	derivedKey = map[string][]byte{} // salt -> 口令派生的密钥，避免重复计算 scrypt
+)
+
+// DefaultKeyFile 默认密钥文件路径
+func DefaultKeyFile() (string, error) {
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %w", err)
+	}
+	return filepath.Join(home, ".svn-ai-reviewer", "key"), nil
+}
+
+// IsEncrypted 判断是否为新格式密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, SecretPrefix)
+}
+
+// IsReference 判断是否为 env: 或 file: 引用
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, "env:") || strings.HasPrefix(value, "file:")
+}
+
+// IsLegacy 判断是否为旧版 DES 密文：带 des: 前缀，或者是标准 Base64、长度为整数个 DES 块，
+// 并且能被旧密钥解密出填充正确的 UTF-8 文本
+func IsLegacy(value string) bool {
+	if value == "" || IsEncrypted(value) || IsReference(value) {
+		return false
+	}
+	if !strings.HasPrefix(value, LegacyPrefix) && !looksLegacy(value) {
+		return false
+	}
+	_, err := DecryptAPIKey(value)
+	return err == nil
+}
+
+// looksLegacy 判断没有前缀的值在形式上是否可能是旧版密文（标准 Base64，解码后为整数个 DES 块）
+func looksLegacy(value string) bool {
+	if len(value)%4 != 0 {
+		return false
+	}
+	data, err := base64.StdEncoding.Strict().DecodeString(value)
+	return err == nil && len(data) > 0 && len(data)%8 == 0
+}
+
+// Encrypt 使用 AES-GCM 加密敏感信息
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", fmt.Errorf("待加密的内容不能为空")
+	}
+
+	var header []byte
+	var key []byte
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		salt := make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return "", fmt.Errorf("生成 salt 失败: %w", err)
+		}
+		k, err := passphraseKey(passphrase, salt)
+		if err != nil {
+			return "", err
+		}
+		header = append([]byte{modePassphrase}, salt...)
+		key = k
+	} else {
+		k, err := loadKeyFile(true)
+		if err != nil {
+			return "", err
+		}
+		header = []byte{modeKeyFile}
+		key = k
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("生成 nonce 失败: %w", err)
+	}
+
+	// 模式和 salt 作为附加数据参与认证，防止被篡改
+	payload := append(header, nonce...)
+	payload = gcm.Seal(payload, nonce, []byte(plaintext), header)
+	return SecretPrefix + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+// Decrypt 解密 enc:v2: 格式的密文
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("不是 %s 格式的密文", SecretPrefix)
+	}
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, SecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("Base64 解码失败: %w", err)
+	}
+	if len(payload) < 1 {
+		return "", fmt.Errorf("密文为空")
+	}
+
+	var header []byte
+	var key []byte
+	switch payload[0] {
+	case modeKeyFile:
+		header = payload[:1]
+		k, err := loadKeyFile(false)
+		if err != nil {
+			return "", err
+		}
+		key = k
+	case modePassphrase:
+		if len(payload) < 1+saltSize {
+			return "", fmt.Errorf("密文长度不正确")
+		}
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return "", fmt.Errorf("该密文使用口令加密，请设置环境变量 %s", PassphraseEnv)
+		}
+		header = payload[:1+saltSize]
+		k, err := passphraseKey(passphrase, header[1:])
+		if err != nil {
+			return "", err
+		}
+		key = k
+	default:
+		return "", fmt.Errorf("未知的加密模式: %d", payload[0])
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	rest := payload[len(header):]
+	if len(rest) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文长度不正确")
+	}
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return "", fmt.Errorf("解密失败（密钥或口令不正确）")
+	}
+	return string(plaintext), nil
+}
+
+// ResolveSecret 将配置中的值解析为实际使用的明文
+//   - env:VAR / file:/path 引用
+//   - enc:v2: 新格式密文
+//   - 旧版 DES 密文（des: 前缀，或者满足 IsLegacy 的严格校验）
+//   - 其他情况按明文处理（兼容旧配置）
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case value == "":
+		return "", nil
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		v := os.Getenv(name)
+		if v == "" {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, "file:"):
+		path := strings.TrimPrefix(value, "file:")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case IsEncrypted(value):
+		return Decrypt(value)
+	case strings.HasPrefix(value, LegacyPrefix):
+		decrypted, err := DecryptAPIKey(value)
+		if err != nil {
+			return "", fmt.Errorf("解密旧版密文失败: %w", err)
+		}
+		return decrypted, nil
+	default:
+		if IsLegacy(value) {
+			return DecryptAPIKey(value)
+		}
+		return value, nil
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 AES cipher 失败: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建 GCM 失败: %w", err)
+	}
+	return gcm, nil
+}
+
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	derivedMu.Lock()
+	defer derivedMu.Unlock()
+
+	cacheKey := string(salt)
+	if key, ok := derivedKey[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	derivedKey[cacheKey] = key
+	return key, nil
+}
+
+// loadKeyFile 读取密钥文件，create 为 true 且文件不存在时生成新密钥
+func loadKeyFile(create bool) ([]byte, error) {
+	keyFileMu.Lock()
+	defer keyFileMu.Unlock()
+
+	path, err := DefaultKeyFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("密钥文件格式不正确: %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	if !create {
+		return nil, fmt.Errorf("密钥文件不存在: %s（请在加密时使用的账户下运行，或设置 %s）", path, KeyFileEnv)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "已生成新的密钥文件: %s（请妥善保管，丢失后无法解密）\n", path)
+	return key, nil
+}
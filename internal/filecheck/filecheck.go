@@ -0,0 +1,223 @@
+package filecheck
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/pathmatch"
+)
+
+// 跳过的文件类型
+const (
+	KindBinary    = "binary"
+	KindMinified  = "minified"
+	KindGenerated = "generated"
+	KindVendored  = "vendored"
+)
+
+// Result 一个文件的检测结果
+type Result struct {
+	Kind   string // 上面的文件类型之一
+	Reason string // 写入报告的跳过原因
+}
+
+// DefaultVendorDirs 默认视为第三方代码的目录
+var DefaultVendorDirs = []string{
+	"vendor/",
+	"node_modules/",
+	"bower_components/",
+	"third_party/",
+	"thirdparty/",
+	"3rdparty/",
+}
+
+const (
+	sniffSize         = 8000 // 二进制检测只看开头这么多字节，与 git 相同
+	generatedLines    = 40   // 完整文件只在开头这么多行中查找生成标记
+	minMinifiedSize   = 2000 // 内容太短时不做压缩检测
+	minifiedAvgLine   = 300  // 平均行长超过该值视为压缩文件
+	minifiedLongLine  = 5000 // 单行超过该长度且占全部内容一半以上视为压缩文件
+	maxControlPercent = 10   // 控制字符占比超过该值视为二进制
+)
+
+// binaryNoticeRe svn diff 对二进制文件输出的提示（svn 包获取二进制文件内容时也返回该提示）
+var binaryNoticeRe = regexp.MustCompile(`(?m)^Cannot display: file marked as a binary type\.\r?\n(?:svn:mime-type = (.*))?`)
+
+// generatedRe 注释中的生成标记：// Code generated ... DO NOT EDIT.、@generated、auto-generated 等
+var generatedRe = regexp.MustCompile(`(?i)^\s*(?://+|#+|/\*+|\*+|<!--|--|;+|')\s*(?:.*\bcode generated\b|.*@generated\b|.*\b(?:auto-?generated|automatically generated)\b|.*\bgenerated by\b.*\bdo not (?:edit|modify)\b)`)
+
+// Checker 判断文件是否应该跳过审核
+type Checker struct {
+	review []string
+	vendor []string
+}
+
+// New 根据配置创建检测器
+func New(cfg *config.FileFilterConfig) *Checker {
+	vendor := cfg.Vendor
+	if len(vendor) == 0 {
+		vendor = DefaultVendorDirs
+	}
+	return &Checker{review: cfg.Review, vendor: vendor}
+}
+
+// Check 检测文件是否为二进制、压缩、生成或第三方代码文件，需要跳过时第二个返回值为 true
+// content 可以是完整文件内容，也可以是 unified diff
+func (c *Checker) Check(filePath, content string) (Result, bool) {
+	for _, pattern := range c.review {
+		if pathmatch.Glob(pattern, filePath) {
+			return Result{}, false
+		}
+	}
+
+	for _, pattern := range c.vendor {
+		if pathmatch.Glob(pattern, filePath) {
+			return Result{KindVendored, fmt.Sprintf("第三方代码目录（%s）", pattern)}, true
+		}
+	}
+	if r, ok := detectBinary(content); ok {
+		return r, true
+	}
+	if r, ok := detectMinified(filePath, content); ok {
+		return r, true
+	}
+	if r, ok := detectGenerated(content); ok {
+		return r, true
+	}
+	return Result{}, false
+}
+
+// detectBinary svn:mime-type 为二进制、包含 NUL 字节，或者不是有效的 UTF-8 且控制字符很多
+// 不是有效 UTF-8 但控制字符很少的内容（例如 GBK 编码的源码）仍按文本处理
+func detectBinary(content string) (Result, bool) {
+	if m := binaryNoticeRe.FindStringSubmatch(content); m != nil {
+		mime := strings.TrimSpace(m[1])
+		if mime == "" {
+			return Result{KindBinary, "二进制文件"}, true
+		}
+		return Result{KindBinary, fmt.Sprintf("二进制文件（svn:mime-type = %s）", mime)}, true
+	}
+
+	sample := []byte(content)
+	if len(sample) > sniffSize {
+		sample = sample[:sniffSize]
+	}
+	if len(sample) == 0 || hasUnicodeBOM(sample) {
+		return Result{}, false
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return Result{KindBinary, "二进制文件（包含 NUL 字节）"}, true
+	}
+	if utf8.Valid(trimPartialRune(sample)) {
+		return Result{}, false
+	}
+
+	control := 0
+	for _, b := range sample {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\v' && b != 0x1b) || b == 0x7f {
+			control++
+		}
+	}
+	if control*100 > len(sample)*maxControlPercent {
+		return Result{KindBinary, "二进制文件（不是有效的 UTF-8 文本，且包含大量控制字符）"}, true
+	}
+	return Result{}, false
+}
+
+// hasUnicodeBOM UTF-16/UTF-32 文本中有大量 NUL 字节，带 BOM 时不视为二进制
+func hasUnicodeBOM(b []byte) bool {
+	return bytes.HasPrefix(b, []byte{0xff, 0xfe}) || bytes.HasPrefix(b, []byte{0xfe, 0xff}) ||
+		bytes.HasPrefix(b, []byte{0, 0, 0xfe, 0xff})
+}
+
+// trimPartialRune 去掉截断采样时末尾不完整的 UTF-8 字符
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// detectMinified 按文件名（.min.js、source map）或行长判断压缩、打包后的文件
+func detectMinified(filePath, content string) (Result, bool) {
+	name := strings.ToLower(filePath[strings.LastIndexAny(filePath, "/\\")+1:])
+	for _, suffix := range []string{".min.js", ".min.mjs", ".min.css", "-min.js", ".bundle.js"} {
+		if strings.HasSuffix(name, suffix) {
+			return Result{KindMinified, fmt.Sprintf("压缩或打包后的文件（%s）", suffix)}, true
+		}
+	}
+	if strings.HasSuffix(name, ".js.map") || strings.HasSuffix(name, ".css.map") {
+		return Result{KindMinified, "source map 文件"}, true
+	}
+
+	total, count, longest := 0, 0, 0
+	for _, line := range contentLines(content) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		total += len(line)
+		count++
+		if len(line) > longest {
+			longest = len(line)
+		}
+	}
+	if total < minMinifiedSize || count == 0 {
+		return Result{}, false
+	}
+	if total/count > minifiedAvgLine || (longest > minifiedLongLine && longest*2 > total) {
+		return Result{KindMinified, fmt.Sprintf("疑似压缩后的文件（%d 行，平均每行 %d 个字符，最长一行 %d 个字符）", count, total/count, longest)}, true
+	}
+	return Result{}, false
+}
+
+// detectGenerated 查找注释中的生成标记
+// 完整文件只看开头几行；diff 中看所有新增和上下文行，因为修改位置通常不在文件开头
+func detectGenerated(content string) (Result, bool) {
+	lines := contentLines(content)
+	if !isDiff(content) && len(lines) > generatedLines {
+		lines = lines[:generatedLines]
+	}
+	for _, line := range lines {
+		if generatedRe.MatchString(line) {
+			marker := strings.TrimSpace(line)
+			if len([]rune(marker)) > 80 {
+				marker = string([]rune(marker)[:80]) + "…"
+			}
+			return Result{KindGenerated, fmt.Sprintf("自动生成的文件（%s）", marker)}, true
+		}
+	}
+	return Result{}, false
+}
+
+// isDiff 判断内容是否为 unified diff
+func isDiff(content string) bool {
+	return strings.HasPrefix(content, "Index: ") || strings.HasPrefix(content, "--- ") ||
+		strings.Contains(content, "\n@@ ")
+}
+
+// contentLines 返回文件内容的各行；diff 只保留新增行和上下文行，并去掉行首的标记
+func contentLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if !isDiff(content) {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "+++") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, " ") {
+			result = append(result, line[1:])
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package filecheck
+
+import (
+	"strings"
+	"testing"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+func TestCheck(t *testing.T) {
+	source := "package main\n\nfunc main() {\n\tprintln(\"你好\")\n}\n"
+	longLine := "var a=" + strings.Repeat("b+", 3000) + "c;\n"
+	// GBK 编码的“中文注释”，不是有效的 UTF-8，但没有控制字符
+	gbk := "// \xd6\xd0\xce\xc4\xd7\xa2\xca\xcd\nint main() { return 0; }\n"
+
+	tests := []struct {
+		name     string
+		cfg      config.FileFilterConfig
+		path     string
+		content  string
+		wantKind string // 为空表示不跳过
+	}{
+		{"普通源码", config.FileFilterConfig{}, "src/main.go", source, ""},
+		{"svn 二进制提示", config.FileFilterConfig{}, "img/logo.png", "Cannot display: file marked as a binary type.\nsvn:mime-type = image/png\n", KindBinary},
+		{"包含 NUL 字节", config.FileFilterConfig{}, "lib/a.dat", "abc\x00def", KindBinary},
+		{"大量控制字符", config.FileFilterConfig{}, "lib/a.dat", strings.Repeat("\x01\x02\xff", 100), KindBinary},
+		{"GBK 文本", config.FileFilterConfig{}, "src/main.c", gbk, ""},
+		{"UTF-16 BOM", config.FileFilterConfig{}, "res/a.txt", "\xff\xfea\x00b\x00", ""},
+		{"截断在多字节字符中间", config.FileFilterConfig{}, "src/a.txt", "a" + strings.Repeat("中文注释\n", 1000), ""},
+		{"min.js 文件名", config.FileFilterConfig{}, "static/app.MIN.js", "var a=1;", KindMinified},
+		{"source map", config.FileFilterConfig{}, "static/app.js.map", "{}", KindMinified},
+		{"超长单行", config.FileFilterConfig{}, "static/app.js", longLine, KindMinified},
+		{"Go 生成标记", config.FileFilterConfig{}, "api/api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n", KindGenerated},
+		{"@generated", config.FileFilterConfig{}, "a.js", "/**\n * @generated\n */\n", KindGenerated},
+		{"生成标记不在开头", config.FileFilterConfig{}, "a.go", strings.Repeat("\n", generatedLines) + "// Code generated. DO NOT EDIT.\n", ""},
+		{"diff 中的生成标记", config.FileFilterConfig{}, "a.go", "Index: a.go\n--- a.go\n+++ a.go\n@@ -100,1 +100,2 @@\n // Code generated by tool. DO NOT EDIT.\n+x := 1\n", KindGenerated},
+		{"diff 删除行中的生成标记", config.FileFilterConfig{}, "a.go", "Index: a.go\n--- a.go\n+++ a.go\n@@ -1,2 +1,1 @@\n-// Code generated by tool. DO NOT EDIT.\n package a\n", ""},
+		{"字符串中的 generated", config.FileFilterConfig{}, "a.go", "msg := \"code generated\"\n", ""},
+		{"默认第三方目录", config.FileFilterConfig{}, "web/node_modules/lodash/index.js", source, KindVendored},
+		{"自定义第三方目录", config.FileFilterConfig{Vendor: []string{"libs/"}}, "libs/a.go", source, KindVendored},
+		{"自定义后不再使用默认目录", config.FileFilterConfig{Vendor: []string{"libs/"}}, "vendor/a.go", source, ""},
+		{"始终审核", config.FileFilterConfig{Review: []string{"api/*.pb.go"}}, "api/api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, skip := New(&tt.cfg).Check(tt.path, tt.content)
+			if skip != (tt.wantKind != "") || result.Kind != tt.wantKind {
+				t.Errorf("Check() = %+v, %v, want kind %q", result, skip, tt.wantKind)
+			}
+			if skip && result.Reason == "" {
+				t.Errorf("Check() Reason is empty")
+			}
+		})
+	}
+}
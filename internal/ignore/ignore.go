@@ -0,0 +1,262 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FileName 可选的忽略规则文件，放在工作副本、源代码目录或仓库地址的根目录下，格式与 .gitignore 相同
+const FileName = ".svnreviewignore"
+
+// Matcher 与 .gitignore 语义相同的忽略规则
+//   - 空行和 # 开头的行忽略，\# 和 \! 表示字面量
+//   - ! 开头的规则重新包含之前被忽略的路径，后面的规则优先
+//   - 以 / 结尾的规则只匹配目录（目录被忽略时其中的所有文件也被忽略）
+//   - 开头或中间含有 / 的规则相对规则所在目录匹配，否则匹配任意层级的名称
+//   - * 和 ? 不匹配 /，** 匹配任意层级的目录，[a-z] 匹配字符范围
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	negate      bool
+	dirOnly     bool
+	unversioned bool // 来自 svn:ignore、svn:global-ignores，只作用于未受控的条目
+	re          *regexp.Regexp
+}
+
+// New 使用根目录下的规则创建匹配器（例如配置文件中的 ignore）
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	m.Add("", patterns)
+	return m
+}
+
+// Add 添加 dir 目录下的规则（dir 为相对根目录的路径，空字符串表示根目录）
+func (m *Matcher) Add(dir string, patterns []string) {
+	for _, p := range patterns {
+		if r, ok := compile(dir, p, false); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// AddFile 读取规则文件并添加到 dir 目录下，文件不存在时忽略
+func (m *Matcher) AddFile(dir, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.Add(dir, lines)
+	return nil
+}
+
+// AddSVNIgnore 添加目录的 svn:ignore 属性：每行一个通配符，只匹配该目录下直接包含的文件和目录名
+// 与 svn 相同，只作用于未受控的条目，见 MatchVersioned
+func (m *Matcher) AddSVNIgnore(dir, value string) {
+	for _, p := range svnPatterns(value) {
+		if r, ok := compile(dir, p, true); ok {
+			r.unversioned = true
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// AddGlobalIgnores 添加目录的 svn:global-ignores 属性：以空白分隔的通配符，匹配该目录下任意层级的名称
+// 与 svn:ignore 相同只作用于未受控的条目
+func (m *Matcher) AddGlobalIgnores(dir, value string) {
+	for _, p := range strings.Fields(value) {
+		if r, ok := compile(dir, strings.ReplaceAll(p, "/", ""), false); ok {
+			r.unversioned = true
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Empty 没有任何规则
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match 判断未受控（或不知道是否受控）的路径是否被忽略，path 为相对根目录的路径
+// 与 git 相同，父目录被忽略时其中的文件无法再用 ! 重新包含
+func (m *Matcher) Match(filePath string, isDir bool) bool {
+	return m.matchPath(filePath, isDir, false)
+}
+
+// MatchVersioned 判断已加入版本控制的路径是否被忽略
+// svn:ignore 和 svn:global-ignores 只决定哪些未受控文件不显示、不被 svn add 添加，对已受控的文件不起作用，
+// 因此跳过这些规则，只使用配置文件的 ignore 和 .svnreviewignore
+func (m *Matcher) MatchVersioned(filePath string, isDir bool) bool {
+	return m.matchPath(filePath, isDir, true)
+}
+
+func (m *Matcher) matchPath(filePath string, isDir, versioned bool) bool {
+	if m.Empty() {
+		return false
+	}
+	filePath = strings.Trim(path.Clean("/"+strings.ReplaceAll(filePath, "\\", "/")), "/")
+	if filePath == "" {
+		return false
+	}
+
+	parts := strings.Split(filePath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true, versioned) {
+			return true
+		}
+	}
+	return m.match(filePath, isDir, versioned)
+}
+
+func (m *Matcher) match(filePath string, isDir, versioned bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if (r.dirOnly && !isDir) || (versioned && r.unversioned) {
+			continue
+		}
+		if ignored == r.negate && r.re.MatchString(filePath) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// svnPatterns 拆分 svn:ignore 的值（每行一个模式）
+func svnPatterns(value string) []string {
+	var patterns []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// compile 将一行规则转换为正则表达式，anchored 为 true 时只匹配 dir 下直接包含的名称（svn:ignore）
+func compile(dir, line string, anchored bool) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line[:len(line)-2], " ") + `\ `
+	} else {
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	if strings.Contains(line, "/") {
+		anchored = true
+	}
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if dir = strings.Trim(strings.ReplaceAll(dir, "\\", "/"), "/"); dir != "" && dir != "." {
+		sb.WriteString(regexp.QuoteMeta(dir) + "/")
+	}
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	sb.WriteString(globToRegexp(line))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp 将通配符转换为正则表达式
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				// ** 只有单独作为一级路径时才匹配多级目录
+				atStart := i == 0 || glob[i-1] == '/'
+				i++
+				switch {
+				case atStart && i+1 < len(glob) && glob[i+1] == '/':
+					i++
+					sb.WriteString("(?:.*/)?")
+				case atStart && i+1 == len(glob):
+					sb.WriteString(".*")
+				default:
+					sb.WriteString("[^/]*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if end == 0 {
+				// []...] 中第一个 ] 是字面量
+				if next := strings.IndexByte(glob[i+2:], ']'); next >= 0 {
+					class = glob[i+1 : i+2+next]
+					end = next + 1
+				}
+			}
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				r, size := utf8.DecodeRuneInString(glob[i+1:])
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+				i += size
+			}
+		default:
+			// 按字符转义，避免把中文等多字节字符拆开
+			r, size := utf8.DecodeRuneInString(glob[i:])
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			i += size - 1
+		}
+	}
+	return sb.String()
+}
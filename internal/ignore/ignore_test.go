@@ -0,0 +1,132 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"文件名匹配任意层级", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"不匹配", []string{"*.log"}, "a/b/debug.go", false, false},
+		{"注释和空行", []string{"# *.go", "", "   "}, "main.go", false, false},
+		{"字面量 #", []string{`\#notes`}, "a/#notes", false, true},
+		{"目录规则不匹配文件", []string{"build/"}, "build", false, false},
+		{"目录规则匹配目录", []string{"build/"}, "web/build", true, true},
+		{"目录被忽略时忽略其中的文件", []string{"build/"}, "web/build/app.js", false, true},
+		{"开头的 / 相对根目录", []string{"/build"}, "web/build", true, false},
+		{"中间的 / 相对根目录", []string{"doc/*.md"}, "doc/a.md", false, true},
+		{"* 不匹配 /", []string{"doc/*.md"}, "doc/api/a.md", false, false},
+		{"** 匹配多级目录", []string{"doc/**/*.md"}, "doc/api/v1/a.md", false, true},
+		{"** 匹配零级目录", []string{"**/gen/*.go"}, "gen/a.go", false, true},
+		{"结尾的 **", []string{"tmp/**"}, "tmp/a/b.txt", false, true},
+		{"字符范围", []string{"file[0-9].txt"}, "file7.txt", false, true},
+		{"取反字符范围", []string{"file[!0-9].txt"}, "file7.txt", false, false},
+		{"? 匹配单个字符", []string{"?.txt"}, "a.txt", false, true},
+		{"重新包含", []string{"*.log", "!keep.log"}, "logs/keep.log", false, false},
+		{"后面的规则优先", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"父目录被忽略时不能重新包含", []string{"logs/", "!logs/keep.log"}, "logs/keep.log", false, true},
+		{"字面量 !", []string{`\!important.txt`}, "!important.txt", false, true},
+		{"末尾转义的空格", []string{`a\ `}, "a ", false, true},
+		{"末尾空格被去掉", []string{"a.txt  "}, "a.txt", false, true},
+		{"中文文件名", []string{"临时文件/", "*.备份"}, "文档/临时文件/a.go", false, true},
+		{"中文扩展名", []string{"*.备份"}, "文档/a.备份", false, true},
+		{"Windows 路径", []string{"build/"}, `web\build\app.js`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.patterns).Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSubdirectory(t *testing.T) {
+	m := New(nil)
+	m.Add("web", []string{"/dist", "*.map"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"web/dist", true, true},
+		{"dist", true, false},
+		{"web/src/dist", true, false},
+		{"web/src/app.js.map", false, true},
+		{"app.js.map", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSVNIgnore(t *testing.T) {
+	m := New([]string{"*.tmp"})
+	m.AddSVNIgnore("src", "*.o\r\n\n target \n")
+	m.AddGlobalIgnores("", "*.bak  .idea")
+
+	tests := []struct {
+		name      string
+		path      string
+		isDir     bool
+		want      bool
+		versioned bool // MatchVersioned 的结果
+	}{
+		{"svn:ignore 匹配直接包含的文件", "src/a.o", false, true, false},
+		{"svn:ignore 不匹配子目录", "src/lib/a.o", false, false, false},
+		{"svn:ignore 去掉首尾空白", "src/target", true, true, false},
+		{"svn:global-ignores 匹配任意层级", "src/lib/a.bak", false, true, false},
+		{"svn:global-ignores 目录", "a/.idea/workspace.xml", false, true, false},
+		{"配置文件的规则对受控文件有效", "src/a.tmp", false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+			if got := m.MatchVersioned(tt.path, tt.isDir); got != tt.versioned {
+				t.Errorf("MatchVersioned(%q) = %v, want %v", tt.path, got, tt.versioned)
+			}
+		})
+	}
+}
+
+func TestAddFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, FileName)
+	if err := os.WriteFile(name, []byte("# 生成的文件\r\n*.pb.go\r\n!keep.pb.go\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(nil)
+	if err := m.AddFile("api", name); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := m.AddFile("", filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("AddFile(missing) error = %v, want nil", err)
+	}
+	if !m.Match("api/v1/a.pb.go", false) {
+		t.Error("Match(api/v1/a.pb.go) = false, want true")
+	}
+	if m.Match("api/keep.pb.go", false) {
+		t.Error("Match(api/keep.pb.go) = true, want false")
+	}
+	if m.Match("other/a.pb.go", false) {
+		t.Error("Match(other/a.pb.go) = true, want false")
+	}
+	if New(nil).Empty() != true || m.Empty() {
+		t.Error("Empty() returned wrong result")
+	}
+}
@@ -0,0 +1,307 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// parse 按格式解析工具的输出，没有输出时返回空列表
+func parse(format string, data []byte) ([]Finding, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	switch format {
+	case FormatSARIF:
+		return parseSARIF(data)
+	case FormatCheckstyle:
+		return parseCheckstyle(data)
+	default:
+		return parseJSON(data)
+	}
+}
+
+// severityOf 将工具输出的级别换算为问题级别
+func severityOf(level string) string {
+	switch strings.ToLower(level) {
+	case "error", "fatal", "blocker", "critical", "high":
+		return "high"
+	case "warning", "warn", "major", "medium":
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// SARIF 2.1.0 中用到的部分
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Rules []struct {
+					ID                   string `json:"id"`
+					HelpURI              string `json:"helpUri"`
+					DefaultConfiguration struct {
+						Level string `json:"level"`
+					} `json:"defaultConfiguration"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		OriginalURIBaseIDs map[string]struct {
+			URI string `json:"uri"`
+		} `json:"originalUriBaseIds"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex *int   `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI       string `json:"uri"`
+						URIBaseID string `json:"uriBaseId"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func parseSARIF(data []byte) ([]Finding, error) {
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("SARIF 格式无效: %w", err)
+	}
+
+	var findings []Finding
+	for _, run := range log.Runs {
+		rules := run.Tool.Driver.Rules
+		for _, res := range run.Results {
+			f := Finding{Rule: res.RuleID, Message: res.Message.Text}
+
+			level := res.Level
+			ruleIndex := -1
+			if res.RuleIndex != nil {
+				ruleIndex = *res.RuleIndex
+			} else {
+				for i := range rules {
+					if rules[i].ID == res.RuleID {
+						ruleIndex = i
+						break
+					}
+				}
+			}
+			if ruleIndex >= 0 && ruleIndex < len(rules) {
+				if f.Rule == "" {
+					f.Rule = rules[ruleIndex].ID
+				}
+				f.HelpURI = rules[ruleIndex].HelpURI
+				if level == "" {
+					level = rules[ruleIndex].DefaultConfiguration.Level
+				}
+			}
+			if level == "" {
+				level = "warning" // SARIF 规定的默认级别
+			}
+			f.Severity = severityOf(level)
+
+			if len(res.Locations) > 0 {
+				loc := res.Locations[0].PhysicalLocation
+				base := ""
+				if b, ok := run.OriginalURIBaseIDs[loc.ArtifactLocation.URIBaseID]; ok {
+					base = uriPath(b.URI)
+				}
+				f.File = uriPath(loc.ArtifactLocation.URI)
+				if base != "" && f.File != "" && !filepath.IsAbs(f.File) {
+					f.File = filepath.Join(base, f.File)
+				}
+				f.Line = loc.Region.StartLine
+				f.Column = loc.Region.StartColumn
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// uriPath 将 SARIF 中的 URI（file:///a/b.go 或相对路径）转换为本地路径
+func uriPath(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		if u.Scheme != "file" {
+			return ""
+		}
+		p := u.Path
+		// file:///C:/src/a.go
+		if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+			p = p[1:]
+		}
+		return filepath.FromSlash(p)
+	}
+	if p, err := url.PathUnescape(uri); err == nil {
+		uri = p
+	}
+	return filepath.FromSlash(uri)
+}
+
+// checkstyle XML 格式（Checkstyle、golangci-lint、ESLint 等都支持输出）
+type checkstyleResult struct {
+	Files []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Column   int    `xml:"column,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+func parseCheckstyle(data []byte) ([]Finding, error) {
+	var result checkstyleResult
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("checkstyle 格式无效: %w", err)
+	}
+
+	var findings []Finding
+	for _, file := range result.Files {
+		for _, e := range file.Errors {
+			findings = append(findings, Finding{
+				Rule:     e.Source,
+				Severity: severityOf(e.Severity),
+				File:     file.Name,
+				Line:     e.Line,
+				Column:   e.Column,
+				Message:  e.Message,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// level 工具输出的问题级别，可以是字符串或数字（ESLint: 1 警告，2 错误）
+type level string
+
+func (l *level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = level(s)
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("无法识别的级别 %s", data)
+	}
+	switch n {
+	case 2:
+		*l = "error"
+	case 1:
+		*l = "warning"
+	default:
+		*l = "info"
+	}
+	return nil
+}
+
+// jsonEntry JSON 数组中的一项：ESLint 的文件结果（filePath + messages），或者一个问题
+type jsonEntry struct {
+	FilePath string `json:"filePath"`
+	Messages []struct {
+		RuleID   string `json:"ruleId"`
+		Severity level  `json:"severity"`
+		Message  string `json:"message"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+	} `json:"messages"`
+
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity level  `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// golangci-lint 的 JSON 输出
+type golangciResult struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+// parseJSON 解析 JSON 输出，支持 ESLint（-f json）、golangci-lint（--out-format json）
+// 以及 [{"file", "line", "column", "severity", "rule", "message"}] 形式的通用格式
+func parseJSON(data []byte) ([]Finding, error) {
+	var findings []Finding
+	if data[0] == '{' {
+		var result golangciResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("JSON 格式无效: %w", err)
+		}
+		for _, issue := range result.Issues {
+			severity := issue.Severity
+			if severity == "" {
+				severity = "warning"
+			}
+			findings = append(findings, Finding{
+				Rule:     issue.FromLinter,
+				Severity: severityOf(severity),
+				File:     issue.Pos.Filename,
+				Line:     issue.Pos.Line,
+				Column:   issue.Pos.Column,
+				Message:  issue.Text,
+			})
+		}
+		return findings, nil
+	}
+
+	var entries []jsonEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("JSON 格式无效: %w", err)
+	}
+	for _, e := range entries {
+		if e.FilePath != "" || e.Messages != nil {
+			for _, m := range e.Messages {
+				findings = append(findings, Finding{
+					Rule:     m.RuleID,
+					Severity: severityOf(string(m.Severity)),
+					File:     e.FilePath,
+					Line:     m.Line,
+					Column:   m.Column,
+					Message:  m.Message,
+				})
+			}
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:     e.Rule,
+			Severity: severityOf(string(e.Severity)),
+			File:     e.File,
+			Line:     e.Line,
+			Column:   e.Column,
+			Message:  e.Message,
+		})
+	}
+	return findings, nil
+}
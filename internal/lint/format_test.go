@@ -0,0 +1,129 @@
+package lint
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    []Finding
+		wantErr bool
+	}{
+		{"空输出", FormatSARIF, "  \n", nil, false},
+		{
+			"SARIF",
+			FormatSARIF,
+			`{"runs": [{
+				"tool": {"driver": {"rules": [
+					{"id": "G101", "helpUri": "https://example.com/G101", "defaultConfiguration": {"level": "error"}},
+					{"id": "G104"}
+				]}},
+				"originalUriBaseIds": {"SRCROOT": {"uri": "file:///work/project/"}},
+				"results": [
+					{"ruleId": "G101", "message": {"text": "硬编码的密码"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/a%20b.go", "uriBaseId": "SRCROOT"}, "region": {"startLine": 3, "startColumn": 5}}}]},
+					{"ruleIndex": 1, "level": "note", "message": {"text": "未检查错误"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///work/project/b.go"}}}]},
+					{"ruleId": "X1", "message": {"text": "默认级别"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "https://example.com/c.go"}}}]}
+				]
+			}]}`,
+			[]Finding{
+				{Rule: "G101", Severity: "high", File: filepath.FromSlash("/work/project/src/a b.go"), Line: 3, Column: 5, Message: "硬编码的密码", HelpURI: "https://example.com/G101"},
+				{Rule: "G104", Severity: "low", File: filepath.FromSlash("/work/project/b.go"), Message: "未检查错误"},
+				{Rule: "X1", Severity: "medium", Message: "默认级别"},
+			},
+			false,
+		},
+		{"SARIF 格式无效", FormatSARIF, `{"runs": [`, nil, true},
+		{
+			"checkstyle",
+			FormatCheckstyle,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+  <file name="src/App.java">
+    <error line="10" column="2" severity="warning" message="缺少 Javadoc" source="JavadocMethod"/>
+    <error line="20" severity="error" message="未使用的导入" source="UnusedImports"/>
+  </file>
+  <file name="src/Empty.java"></file>
+</checkstyle>`,
+			[]Finding{
+				{Rule: "JavadocMethod", Severity: "medium", File: "src/App.java", Line: 10, Column: 2, Message: "缺少 Javadoc"},
+				{Rule: "UnusedImports", Severity: "high", File: "src/App.java", Line: 20, Message: "未使用的导入"},
+			},
+			false,
+		},
+		{"checkstyle 格式无效", FormatCheckstyle, `<checkstyle><file`, nil, true},
+		{
+			"ESLint",
+			FormatJSON,
+			`[{"filePath": "/work/web/app.js", "messages": [
+				{"ruleId": "no-unused-vars", "severity": 2, "message": "x 未使用", "line": 1, "column": 7},
+				{"ruleId": "semi", "severity": 1, "message": "缺少分号", "line": 2, "column": 10}
+			]}, {"filePath": "/work/web/ok.js", "messages": []}]`,
+			[]Finding{
+				{Rule: "no-unused-vars", Severity: "high", File: "/work/web/app.js", Line: 1, Column: 7, Message: "x 未使用"},
+				{Rule: "semi", Severity: "medium", File: "/work/web/app.js", Line: 2, Column: 10, Message: "缺少分号"},
+			},
+			false,
+		},
+		{
+			"golangci-lint",
+			FormatJSON,
+			`{"Issues": [
+				{"FromLinter": "errcheck", "Text": "未检查错误", "Pos": {"Filename": "main.go", "Line": 12, "Column": 3}},
+				{"FromLinter": "gosec", "Text": "G101", "Severity": "error", "Pos": {"Filename": "main.go", "Line": 5}}
+			], "Report": {}}`,
+			[]Finding{
+				{Rule: "errcheck", Severity: "medium", File: "main.go", Line: 12, Column: 3, Message: "未检查错误"},
+				{Rule: "gosec", Severity: "high", File: "main.go", Line: 5, Message: "G101"},
+			},
+			false,
+		},
+		{
+			"通用 JSON",
+			FormatJSON,
+			`[{"file": "a.py", "line": 4, "severity": "info", "rule": "E501", "message": "行太长"}, {"message": "整个文件的问题"}]`,
+			[]Finding{
+				{Rule: "E501", Severity: "low", File: "a.py", Line: 4, Message: "行太长"},
+				{Severity: "low", Message: "整个文件的问题"},
+			},
+			false,
+		},
+		{"JSON 级别无效", FormatJSON, `[{"file": "a.py", "severity": true}]`, nil, true},
+		{"JSON 格式无效", FormatJSON, `not json`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.format, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityOf(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{"ERROR", "high"},
+		{"blocker", "high"},
+		{"Warning", "medium"},
+		{"major", "medium"},
+		{"note", "low"},
+		{"", "low"},
+	}
+	for _, tt := range tests {
+		if got := severityOf(tt.level); got != tt.want {
+			t.Errorf("severityOf(%q) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
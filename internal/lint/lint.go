@@ -0,0 +1,287 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/pathmatch"
+)
+
+// 支持的输出格式
+const (
+	FormatSARIF      = "sarif"
+	FormatCheckstyle = "checkstyle"
+	FormatJSON       = "json"
+)
+
+// defaultTimeout 工具的默认超时时间
+const defaultTimeout = 60 * time.Second
+
+// Finding 外部检查工具发现的一个问题
+type Finding struct {
+	Tool     string // 工具名称（配置中的 name）
+	Rule     string // 工具的规则 ID，可能为空
+	Severity string // high, medium, low
+	File     string // 工具输出中的文件路径
+	Line     int    // 行号，整个文件的问题为 0
+	Column   int
+	Message  string
+	HelpURI  string // 规则说明的链接（SARIF）
+}
+
+// tool 一个配置好的外部检查工具
+type tool struct {
+	name     string
+	files    []string
+	args     []string // 命令模板拆分后的参数，运行时替换 {file}、{dir}
+	format   string
+	timeout  time.Duration
+	severity string
+	single   bool // 不依赖项目中的其他文件和配置，复制到临时目录中也能检查
+}
+
+// Runner 按文件类型运行外部检查工具
+type Runner struct {
+	tools []tool
+}
+
+// New 根据配置创建 Runner，配置有误时返回错误
+func New(cfg *config.LintConfig) (*Runner, error) {
+	r := &Runner{}
+	for i, tc := range cfg.Tools {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("lint.tools[%d]", i)
+		}
+		args, err := splitArgs(tc.Command)
+		if err != nil {
+			return nil, fmt.Errorf("检查工具 %s 的命令无效: %w", name, err)
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("检查工具 %s 没有配置命令", name)
+		}
+		if len(tc.Files) == 0 {
+			return nil, fmt.Errorf("检查工具 %s 没有配置适用的文件 (files)", name)
+		}
+
+		t := tool{
+			name:     name,
+			files:    tc.Files,
+			args:     args,
+			format:   strings.ToLower(tc.Format),
+			timeout:  time.Duration(tc.Timeout) * time.Second,
+			severity: strings.ToLower(tc.Severity),
+			single:   tc.SingleFile,
+		}
+		switch t.format {
+		case FormatSARIF, FormatCheckstyle, FormatJSON:
+		default:
+			return nil, fmt.Errorf("检查工具 %s 的输出格式 %q 无效 (支持: sarif, checkstyle, json)", name, tc.Format)
+		}
+		switch t.severity {
+		case "", "high", "medium", "low":
+		default:
+			return nil, fmt.Errorf("检查工具 %s 的问题级别 %q 无效 (支持: high, medium, low)", name, tc.Severity)
+		}
+		if t.timeout <= 0 {
+			t.timeout = defaultTimeout
+		}
+		r.tools = append(r.tools, t)
+	}
+	return r, nil
+}
+
+// Matches 是否有适用于该文件的工具，singleFileOnly 为 true 时只看配置了 single_file 的工具
+func (r *Runner) Matches(fileName string, singleFileOnly bool) bool {
+	for i := range r.tools {
+		if r.tools[i].appliesTo(fileName, singleFileOnly) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 对本地文件 path 运行适用于 fileName 的所有工具，dir 是运行工具的目录（为空时为当前目录）
+// path 是不在项目中的临时文件时 singleFileOnly 为 true，只运行配置了 single_file 的工具
+// 只返回工具输出中属于该文件的问题；某个工具运行失败时记录错误，继续运行其他工具
+func (r *Runner) Run(ctx context.Context, fileName, path, dir string, singleFileOnly bool) ([]Finding, []error) {
+	var findings []Finding
+	var errs []error
+	for i := range r.tools {
+		t := &r.tools[i]
+		if !t.appliesTo(fileName, singleFileOnly) {
+			continue
+		}
+		found, err := t.run(ctx, path, dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	return findings, errs
+}
+
+func (t *tool) appliesTo(fileName string, singleFileOnly bool) bool {
+	if singleFileOnly && !t.single {
+		return false
+	}
+	for _, pattern := range t.files {
+		if pathmatch.Glob(pattern, fileName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tool) run(ctx context.Context, path, dir string) ([]Finding, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("检查工具 %s: %w", t.name, err)
+	}
+	replacer := strings.NewReplacer("{file}", absPath, "{dir}", filepath.Dir(absPath))
+	args := make([]string, len(t.args))
+	for i, arg := range t.args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// 发现问题时大多数工具以非 0 状态退出，能解析输出时不视为失败
+	runErr := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("检查工具 %s 运行超时（%v）", t.name, t.timeout)
+	}
+	if runErr != nil && len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil, fmt.Errorf("运行检查工具 %s 失败: %w%s", t.name, runErr, stderrText(stderr.Bytes()))
+	}
+
+	findings, err := parse(t.format, stdout.Bytes())
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("运行检查工具 %s 失败: %w%s", t.name, runErr, stderrText(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("解析检查工具 %s 的输出失败: %w", t.name, err)
+	}
+
+	result := findings[:0]
+	for _, f := range findings {
+		if !sameFile(f.File, absPath, dir) {
+			continue
+		}
+		f.Tool = t.name
+		if t.severity != "" {
+			f.Severity = t.severity
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// stderrText 错误信息中附带的标准错误输出（只取前几行）
+func stderrText(stderr []byte) string {
+	text := strings.TrimSpace(string(stderr))
+	if text == "" {
+		return ""
+	}
+	if lines := strings.Split(text, "\n"); len(lines) > 5 {
+		text = strings.Join(lines[:5], "\n") + "\n..."
+	}
+	return "\n" + text
+}
+
+// sameFile 判断工具输出的路径是否是被检查的文件，相对路径相对运行工具的目录
+// 工具输出中没有路径时视为被检查的文件
+func sameFile(reported, absPath, dir string) bool {
+	if reported == "" {
+		return true
+	}
+	if !filepath.IsAbs(reported) {
+		reported = filepath.Join(dir, reported)
+	}
+	reported, err := filepath.Abs(reported)
+	if err != nil {
+		return false
+	}
+	if reported == absPath {
+		return true
+	}
+	// 符号链接、大小写不敏感的文件系统等
+	a, errA := os.Stat(reported)
+	b, errB := os.Stat(absPath)
+	return errA == nil && errB == nil && os.SameFile(a, b)
+}
+
+// splitArgs 按空白拆分命令模板，支持单引号和双引号
+func splitArgs(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("引号不匹配: %s", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Prompt 生成附加到提示词中的说明，请模型挑出需要优先处理的问题并解释
+func Prompt(findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n以下问题由外部检查工具发现，会直接写入报告。请结合代码判断其中哪些最需要处理，" +
+		"在审核结果中说明原因和修改方法；误报或无关紧要的问题不需要重复报告：\n")
+	for _, f := range findings {
+		tool := f.Tool
+		if f.Rule != "" {
+			tool += " " + f.Rule
+		}
+		message := strings.SplitN(f.Message, "\n", 2)[0]
+		if f.Line > 0 {
+			fmt.Fprintf(&sb, "- 第 %d 行 [%s] %s\n", f.Line, tool, message)
+		} else {
+			fmt.Fprintf(&sb, "- [%s] %s\n", tool, message)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    config.LinterConfig
+		wantErr string
+	}{
+		{"有效配置", config.LinterConfig{Command: "eslint -f json {file}", Files: []string{"*.js"}, Format: "JSON"}, ""},
+		{"没有命令", config.LinterConfig{Name: "eslint", Files: []string{"*.js"}, Format: "json"}, "没有配置命令"},
+		{"引号不匹配", config.LinterConfig{Command: `eslint "{file}`, Files: []string{"*.js"}, Format: "json"}, "命令无效"},
+		{"没有文件", config.LinterConfig{Command: "eslint", Format: "json"}, "没有配置适用的文件"},
+		{"格式无效", config.LinterConfig{Command: "eslint", Files: []string{"*.js"}, Format: "text"}, "输出格式"},
+		{"级别无效", config.LinterConfig{Command: "eslint", Files: []string{"*.js"}, Format: "json", Severity: "critical"}, "问题级别"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&config.LintConfig{Tools: []config.LinterConfig{tt.tool}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("New() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"eslint -f json {file}", []string{"eslint", "-f", "json", "{file}"}},
+		{"  golangci-lint\trun  ", []string{"golangci-lint", "run"}},
+		{`sh -c 'checker "{file}" --level=2'`, []string{"sh", "-c", `checker "{file}" --level=2`}},
+		{`tool --name="a b" ""`, []string{"tool", "--name=a b", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.command)
+		if err != nil {
+			t.Errorf("splitArgs(%q) error = %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	script := filepath.Join(dir, "lint.sh")
+	// 输出两个问题，其中一个属于其他文件；发现问题时以非 0 状态退出
+	write := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(file, "package main\n")
+	write(script, `printf '[{"file": "%s", "line": 3, "severity": 2, "rule": "R1", "message": "问题"}, {"file": "other.go", "line": 1, "message": "其他文件"}]' "$1"
+exit 1
+`)
+	write(filepath.Join(dir, "fail.sh"), "echo 配置文件不存在 >&2\nexit 2\n")
+
+	r, err := New(&config.LintConfig{Tools: []config.LinterConfig{
+		{Name: "fake", Command: "sh " + script + " {file}", Files: []string{"*.go"}, Format: "json", SingleFile: true},
+		{Name: "fixed", Command: "sh " + script + " {file}", Files: []string{"*.go"}, Format: "json", Severity: "low"},
+		{Name: "broken", Command: "sh fail.sh", Files: []string{"*.go"}, Format: "json"},
+		{Name: "js", Command: "sh " + script + " {file}", Files: []string{"*.js"}, Format: "json"},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if !r.Matches("src/main.go", true) || r.Matches("src/app.ts", false) {
+		t.Error("Matches() returned wrong result")
+	}
+
+	findings, errs := r.Run(context.Background(), "src/main.go", file, dir, false)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken") || !strings.Contains(errs[0].Error(), "配置文件不存在") {
+		t.Errorf("Run() errs = %v, want one error from broken with stderr", errs)
+	}
+	want := []Finding{
+		{Tool: "fake", Rule: "R1", Severity: "high", File: file, Line: 3, Message: "问题"},
+		{Tool: "fixed", Rule: "R1", Severity: "low", File: file, Line: 3, Message: "问题"},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("Run() = %+v, want %+v", findings, want)
+	}
+
+	// 临时文件只运行 single_file 工具
+	findings, errs = r.Run(context.Background(), "src/main.go", file, dir, true)
+	if len(errs) != 0 || len(findings) != 1 || findings[0].Tool != "fake" {
+		t.Errorf("Run(singleFileOnly) = %+v, %v, want only fake", findings, errs)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	if Prompt(nil) != "" {
+		t.Error("Prompt(nil) != \"\"")
+	}
+	got := Prompt([]Finding{
+		{Tool: "gosec", Rule: "G101", Line: 3, Message: "硬编码的密码\n详细说明"},
+		{Tool: "license", Message: "缺少许可证声明"},
+	})
+	for _, want := range []string{"- 第 3 行 [gosec G101] 硬编码的密码\n", "- [license] 缺少许可证声明\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Prompt() = %q, want containing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "详细说明") {
+		t.Errorf("Prompt() = %q, want only the first line of each message", got)
+	}
+}
@@ -0,0 +1,104 @@
+package pathmatch
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Glob 判断路径是否匹配通配符模式，路径分隔符统一为 /
+//   - *   匹配除 / 以外的任意字符
+//   - ?   匹配除 / 以外的单个字符
+//   - **  匹配任意层级的目录
+//   - 以 / 结尾的模式匹配该目录下的所有文件
+//   - 不含 / 的模式匹配任意层级中的文件名或目录名
+func Glob(pattern, filePath string) bool {
+	filePath = strings.TrimPrefix(strings.ReplaceAll(filePath, "\\", "/"), "/")
+	pattern = strings.ReplaceAll(strings.TrimSpace(pattern), "\\", "/")
+	if pattern == "" {
+		return false
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(strings.TrimSuffix(pattern, "/**"), "/") {
+		// 不含目录的模式：匹配任意一级的名称
+		name := strings.TrimSuffix(pattern, "/**")
+		for _, part := range strings.Split(filePath, "/") {
+			if ok, _ := path.Match(name, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return compile(strings.TrimPrefix(pattern, "/")).MatchString(filePath)
+}
+
+// GlobSuffix 与 Glob 相同，但含目录的模式还会从路径中每一级目录开始尝试匹配
+// 用于路径前缀不确定、漏匹配会造成泄露的场景：源代码审核的路径带有扫描根目录或为绝对路径，
+// 在线模式的路径带有 /trunk 等仓库前缀，此时 "secrets/*" 仍能匹配 "/data/project/secrets/key.pem"
+func GlobSuffix(pattern, filePath string) bool {
+	filePath = strings.TrimPrefix(strings.ReplaceAll(filePath, "\\", "/"), "/")
+	for {
+		if Glob(pattern, filePath) {
+			return true
+		}
+		i := strings.Index(filePath, "/")
+		if i < 0 {
+			return false
+		}
+		filePath = filePath[i+1:]
+	}
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*regexp.Regexp{}
+)
+
+// compile 将通配符模式转换为正则表达式
+func compile(pattern string) *regexp.Regexp {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if re, ok := cache[pattern]; ok {
+		return re
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ 匹配零个或多个目录
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			// 按字符转义，避免把中文等多字节字符拆开
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			i += size - 1
+		}
+	}
+	sb.WriteString("$")
+
+	re := regexp.MustCompile(sb.String())
+	cache[pattern] = re
+	return re
+}
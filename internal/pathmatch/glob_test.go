@@ -0,0 +1,63 @@
+package pathmatch
+
+import "testing"
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// 不含目录的模式匹配任意一级名称
+		{"*.pem", "certs/server.pem", true},
+		{"*.pem", "server.pem.bak", false},
+		{"customer-data/", "a/customer-data/list.csv", true},
+		{"customer-data/", "a/customer-data-old/list.csv", false},
+		// 含目录的模式从路径开头匹配
+		{"src/crypto/**", "src/crypto/aes/aes.go", true},
+		{"src/crypto/**", "lib/src/crypto/aes.go", false},
+		{"src/*.go", "src/a.go", true},
+		{"src/*.go", "src/sub/a.go", false},
+		{"客户数据/*.csv", "客户数据/名单.csv", true},
+		{"src/核心/**", "src/核心/加密/aes.go", true},
+		{"src/?.go", "src/a.go", true},
+		{"**/src/crypto/**", "lib/src/crypto/aes.go", true},
+		{"**/test/*.go", "test/a.go", true},
+		// 开头的 / 和 Windows 分隔符
+		{"/trunk/src/*", "/trunk/src/a.go", true},
+		{"src/*", "src\\a.go", true},
+		{"", "a.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := Glob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("Glob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGlobSuffix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"secrets/*", "secrets/key.pem", true},
+		// 源代码审核：带扫描根目录或绝对路径
+		{"secrets/*", "project/secrets/key.pem", true},
+		{"secrets/*", "/data/project/secrets/key.pem", true},
+		{"secrets/*", "C:\\work\\project\\secrets\\key.pem", true},
+		// 在线模式：带仓库前缀
+		{"src/crypto/**", "/trunk/src/crypto/aes.go", true},
+		// 只从完整的目录名开始匹配
+		{"secrets/*", "project/mysecrets/key.pem", false},
+		{"secrets/*", "project/secrets/sub/key.pem", false},
+		{"*.pem", "/data/project/a.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := GlobSuffix(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("GlobSuffix(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package precheck
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Go 语法树检查的规则名称
+const (
+	ruleGoIgnoredError = "go-ignored-error"
+	ruleGoUnusedResult = "go-unused-result"
+)
+
+// errorFuncs 返回错误、结果却常被直接丢弃的函数（导入路径 -> 函数名）
+var errorFuncs = map[string][]string{
+	"os":            {"Remove", "RemoveAll", "Mkdir", "MkdirAll", "WriteFile", "Rename", "Chdir", "Chmod", "Chown", "Setenv", "Unsetenv", "Symlink", "Link", "Truncate"},
+	"io":            {"Copy", "CopyN", "ReadFull", "WriteString"},
+	"encoding/json": {"Unmarshal"},
+	"encoding/xml":  {"Unmarshal"},
+	"strconv":       {"Atoi", "ParseInt", "ParseUint", "ParseFloat", "ParseBool"},
+}
+
+// errorResultFuncs 最后一个返回值为 error 的函数，v, _ := f() 丢弃的是错误（导入路径 -> 函数名）
+// 没有类型信息时无法知道任意函数的最后一个返回值是什么（strings.Cut、map 取值、strconv.Unquote 的结果常被有意忽略），
+// 因此只检查列出的函数
+var errorResultFuncs = map[string][]string{
+	"os":            {"Open", "Create", "OpenFile", "ReadFile", "ReadDir", "Stat", "Lstat", "Getwd", "MkdirTemp", "CreateTemp", "Readlink"},
+	"io":            {"Copy", "CopyN", "ReadAll", "ReadFull", "WriteString"},
+	"io/ioutil":     {"ReadAll", "ReadFile", "ReadDir", "TempDir", "TempFile"},
+	"path/filepath": {"Abs", "Rel", "EvalSymlinks", "Glob"},
+	"encoding/json": {"Marshal", "MarshalIndent"},
+	"encoding/xml":  {"Marshal", "MarshalIndent"},
+	"strconv":       {"Atoi", "ParseInt", "ParseUint", "ParseFloat", "ParseBool"},
+	"time":          {"Parse", "ParseInLocation", "ParseDuration", "LoadLocation"},
+	"net/url":       {"Parse", "ParseRequestURI", "ParseQuery"},
+	"net/http":      {"Get", "Post", "Head", "NewRequest", "NewRequestWithContext"},
+	"regexp":        {"Compile"},
+}
+
+// pureFuncs 没有副作用的函数，不使用返回值的调用没有任何作用，通常是漏写了赋值
+var pureFuncs = map[string][]string{
+	"strings":       {"TrimSpace", "Trim", "TrimLeft", "TrimRight", "TrimPrefix", "TrimSuffix", "ToLower", "ToUpper", "Replace", "ReplaceAll", "Split", "Join", "Repeat", "Title"},
+	"bytes":         {"TrimSpace", "Trim", "TrimPrefix", "TrimSuffix", "ToLower", "ToUpper", "Replace", "ReplaceAll", "Split", "Join", "Repeat"},
+	"fmt":           {"Sprintf", "Sprint", "Sprintln", "Errorf"},
+	"errors":        {"New", "Unwrap"},
+	"path":          {"Join", "Clean", "Base", "Dir", "Ext"},
+	"path/filepath": {"Join", "Clean", "Base", "Dir", "Ext", "ToSlash", "FromSlash"},
+	"time":          {"Since", "Until", "Now"},
+	"strconv":       {"Itoa", "Quote", "FormatInt", "FormatFloat", "FormatBool"},
+}
+
+// checkGo 解析 Go 源文件，检查被 _ 丢弃的错误和未使用的函数返回值
+// 没有类型信息，只检查常见的标准库函数
+func checkGo(lines []line) []Finding {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	src := strings.Join(texts, "\n")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil
+	}
+
+	// 本地包名 -> 导入路径
+	imports := map[string]string{}
+	for _, imp := range file.Imports {
+		importPath, _ := strconv.Unquote(imp.Path.Value)
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	lineOf := func(n ast.Node) (int, string) {
+		i := fset.Position(n.Pos()).Line - 1
+		if i < 0 || i >= len(lines) {
+			return 0, ""
+		}
+		return lines[i].number, strings.TrimSpace(lines[i].text)
+	}
+
+	var findings []Finding
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			// v, _ := f() 丢弃了 f 返回的 error
+			if len(stmt.Lhs) < 2 || len(stmt.Rhs) != 1 {
+				return true
+			}
+			call, ok := stmt.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			pkg, fn := qualifiedName(call, imports)
+			if pkg == "" || !contains(errorResultFuncs[pkg], fn) {
+				return true
+			}
+			if id, ok := stmt.Lhs[len(stmt.Lhs)-1].(*ast.Ident); ok && id.Name == "_" {
+				line, snippet := lineOf(stmt)
+				findings = append(findings, Finding{
+					Rule:       ruleGoIgnoredError,
+					Severity:   "medium",
+					Title:      "忽略了 " + shortPkg(pkg) + "." + fn + " 返回的错误",
+					Line:       line,
+					Snippet:    snippet,
+					Suggestion: "检查并处理返回的 error；确实可以忽略时添加注释说明原因。",
+				})
+			}
+		case *ast.ExprStmt:
+			call, ok := stmt.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			pkg, fn := qualifiedName(call, imports)
+			if pkg == "" {
+				return true
+			}
+			line, snippet := lineOf(stmt)
+			switch {
+			case contains(errorFuncs[pkg], fn):
+				findings = append(findings, Finding{
+					Rule:       ruleGoIgnoredError,
+					Severity:   "medium",
+					Title:      "未检查 " + shortPkg(pkg) + "." + fn + " 返回的错误",
+					Line:       line,
+					Snippet:    snippet,
+					Suggestion: "检查并处理返回的 error，失败时记录日志或向上返回。",
+				})
+			case contains(pureFuncs[pkg], fn):
+				findings = append(findings, Finding{
+					Rule:       ruleGoUnusedResult,
+					Severity:   "medium",
+					Title:      shortPkg(pkg) + "." + fn + " 的返回值未使用",
+					Line:       line,
+					Snippet:    snippet,
+					Suggestion: "该函数没有副作用，不使用返回值的调用没有任何作用，检查是否漏写了赋值。",
+				})
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// qualifiedName 返回 pkg.Func(...) 形式调用的导入路径和函数名，其他调用返回空字符串
+func qualifiedName(call *ast.CallExpr, imports map[string]string) (string, string) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || id.Obj != nil {
+		// id.Obj 不为空表示是局部变量，不是包名
+		return "", ""
+	}
+	return imports[id.Name], sel.Sel.Name
+}
+
+func shortPkg(importPath string) string {
+	return importPath[strings.LastIndex(importPath, "/")+1:]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
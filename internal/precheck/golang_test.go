@@ -0,0 +1,81 @@
+package precheck
+
+import (
+	"strings"
+	"testing"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+func TestCheckGo(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string // 命中的规则，按出现顺序
+	}{
+		{"丢弃 os.ReadFile 的错误", `data, _ := os.ReadFile("a")`, []string{ruleGoIgnoredError}},
+		{"丢弃 strconv.Atoi 的错误", `n, _ := strconv.Atoi(s)`, []string{ruleGoIgnoredError}},
+		{"导入别名", `u, _ := neturl.Parse(s)`, []string{ruleGoIgnoredError}},
+		{"strings.Cut 不返回错误", `proto, _, _ := strings.Cut(s, ",")`, nil},
+		{"strconv.Unquote 的结果常被有意忽略", `v, _ := strconv.Unquote(s)`, nil},
+		{"map 取值", `v, _ := m["k"]`, nil},
+		{"类型断言", `v, _ := x.(string)`, nil},
+		{"未知函数", `v, _ := lookup(s)`, nil},
+		{"检查了错误", "data, err := os.ReadFile(\"a\")\n\tif err != nil {\n\t\treturn\n\t}\n\t_ = data", nil},
+		{"未检查 os.Remove 的错误", `os.Remove("a")`, []string{ruleGoIgnoredError}},
+		{"返回值未使用", `strings.TrimSpace(s)`, []string{ruleGoUnusedResult}},
+		{"局部变量与包同名", "strings := fake{}\n\tstrings.TrimSpace(s)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := `package p
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	neturl "net/url"
+)
+
+func f(s string, m map[string]int, x interface{}) {
+	` + tt.body + `
+}
+`
+			lines, _ := splitLines(src)
+			findings := checkGo(lines)
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("checkGo() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckGoLineNumber(t *testing.T) {
+	c, err := New(&config.PrecheckConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "新增文件，完整内容:\npackage p\n\nimport \"os\"\n\nfunc f() {\n\tos.Remove(\"a\")\n}\n"
+	findings := c.Check("a.go", content)
+	if len(findings) != 1 {
+		t.Fatalf("Check() = %d findings, want 1", len(findings))
+	}
+	if findings[0].Line != 6 || findings[0].Snippet != `os.Remove("a")` {
+		t.Errorf("finding = line %d %q, want line 6 %q", findings[0].Line, findings[0].Snippet, `os.Remove("a")`)
+	}
+
+	// diff 片段不做语法树检查
+	if findings := c.Check("a.go", "@@ -1,1 +1,2 @@\n a\n+\tos.Remove(\"a\")\n"); len(findings) != 0 {
+		t.Errorf("Check() on diff = %v, want none", findings)
+	}
+	// 关闭规则
+	c, _ = New(&config.PrecheckConfig{DisableRules: []string{ruleGoIgnoredError}})
+	if findings := c.Check("a.go", content); len(findings) != 0 {
+		t.Errorf("Check() with rule disabled = %v, want none", findings)
+	}
+}
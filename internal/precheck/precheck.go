@@ -0,0 +1,275 @@
+package precheck
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"svn-ai-reviewer/internal/config"
+	"svn-ai-reviewer/internal/pathmatch"
+)
+
+// Finding 一处静态检查发现的问题
+type Finding struct {
+	Rule       string // 规则名称
+	Severity   string // high, medium, low
+	Title      string
+	Line       int    // 在新版本文件中的行号，无法确定时为 0
+	Snippet    string // 命中的代码行
+	Suggestion string
+}
+
+// rule 一条按行匹配的正则规则
+type rule struct {
+	name       string
+	severity   string
+	title      string
+	suggestion string
+	re         *regexp.Regexp
+	files      []string // 适用的文件，为空表示所有文件
+	addedOnly  bool     // 只检查 diff 中新增的行
+}
+
+var (
+	javaFiles   = []string{"*.java"}
+	scriptFiles = []string{"*.js", "*.jsx", "*.ts", "*.tsx", "*.vue"}
+)
+
+// 内置规则
+var builtinRules = []rule{
+	{
+		name:       "java-system-out",
+		severity:   "low",
+		title:      "使用 System.out/System.err 输出",
+		suggestion: "改用日志框架（如 SLF4J）输出，便于控制级别和输出位置。",
+		re:         regexp.MustCompile(`\bSystem\.(?:out|err)\.print(?:ln|f)?\s*\(`),
+		files:      javaFiles,
+	},
+	{
+		name:       "java-print-stack-trace",
+		severity:   "medium",
+		title:      "使用 printStackTrace 输出异常",
+		suggestion: "使用日志框架记录异常（log.error(\"...\", e)），或向上抛出，不要只打印到标准错误。",
+		re:         regexp.MustCompile(`\.printStackTrace\s*\(\s*\)`),
+		files:      javaFiles,
+	},
+	{
+		name:       "js-console-log",
+		severity:   "low",
+		title:      "遗留的 console.log 调试输出",
+		suggestion: "提交前删除调试输出，或改用统一的日志工具。",
+		re:         regexp.MustCompile(`\bconsole\.(?:log|debug)\s*\(`),
+		files:      scriptFiles,
+	},
+	{
+		name:       "sql-select-star",
+		severity:   "low",
+		title:      "SQL 使用 SELECT *",
+		suggestion: "明确列出需要的字段，避免表结构变化导致的问题和多余的数据传输。",
+		re:         regexp.MustCompile(`(?i)\bselect\s+\*\s+from\b`),
+	},
+	{
+		name:       "todo-added",
+		severity:   "low",
+		title:      "新增了 TODO/FIXME",
+		suggestion: "确认是否需要在本次提交中完成，或记录到问题跟踪系统中。",
+		re:         regexp.MustCompile(`\b(?:TODO|FIXME|XXX)\b`),
+		addedOnly:  true,
+	},
+}
+
+// Checker 静态规则检查器
+type Checker struct {
+	rules  []rule
+	ignore map[string]bool // 关闭的规则（包括 Go 语法树检查的规则）
+}
+
+// New 根据配置创建检查器，配置中的自定义规则追加在内置规则之后
+func New(cfg *config.PrecheckConfig) (*Checker, error) {
+	c := &Checker{ignore: map[string]bool{}}
+	for _, name := range cfg.DisableRules {
+		c.ignore[strings.TrimSpace(name)] = true
+	}
+	for _, r := range builtinRules {
+		if !c.ignore[r.name] {
+			c.rules = append(c.rules, r)
+		}
+	}
+
+	for _, rc := range cfg.Rules {
+		re, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("静态检查规则 %s 的正则表达式无效: %w", rc.Name, err)
+		}
+		r := rule{
+			name:       rc.Name,
+			severity:   strings.ToLower(rc.Severity),
+			title:      rc.Title,
+			suggestion: rc.Suggestion,
+			re:         re,
+			files:      rc.Files,
+			addedOnly:  rc.AddedOnly,
+		}
+		switch r.severity {
+		case "high", "medium", "low":
+		default:
+			r.severity = "low"
+		}
+		if r.title == "" {
+			r.title = rc.Name
+		}
+		c.rules = append(c.rules, r)
+	}
+	return c, nil
+}
+
+// line 待检查的一行
+type line struct {
+	number int // 新版本文件中的行号
+	text   string
+	added  bool // diff 中新增的行
+}
+
+// Check 检查发送审核的内容：diff 只检查新增的行，完整文件检查所有行
+// Go 文件的完整内容还会解析语法树，检查被忽略的错误和未使用的返回值
+func (c *Checker) Check(fileName, content string) []Finding {
+	lines, isDiff := splitLines(content)
+
+	var findings []Finding
+	for i := range c.rules {
+		r := &c.rules[i]
+		if !r.appliesTo(fileName) || (r.addedOnly && !isDiff) {
+			continue
+		}
+		for _, l := range lines {
+			if isDiff && !l.added {
+				continue
+			}
+			if r.re.MatchString(l.text) {
+				findings = append(findings, Finding{
+					Rule:       r.name,
+					Severity:   r.severity,
+					Title:      r.title,
+					Line:       l.number,
+					Snippet:    strings.TrimSpace(l.text),
+					Suggestion: r.suggestion,
+				})
+			}
+		}
+	}
+
+	if !isDiff && strings.HasSuffix(fileName, ".go") {
+		for _, f := range checkGo(lines) {
+			if !c.ignore[f.Rule] {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+func (r *rule) appliesTo(fileName string) bool {
+	if len(r.files) == 0 {
+		return true
+	}
+	for _, pattern := range r.files {
+		if pathmatch.Glob(pattern, fileName) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines 将内容拆分为行并计算新版本文件中的行号
+// 含有 hunk 头（@@ -a,b +c,d @@）的内容按 unified diff 处理；否则为完整文件，
+// 开头的“新增文件，完整内容:”说明行不计入行号
+func splitLines(content string) ([]line, bool) {
+	raw := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	isDiff := false
+	for _, text := range raw {
+		if hunkRe.MatchString(text) {
+			isDiff = true
+			break
+		}
+	}
+
+	var lines []line
+	if !isDiff {
+		if len(raw) > 0 && strings.HasSuffix(strings.TrimSpace(raw[0]), "完整内容:") {
+			raw = raw[1:]
+		}
+		for i, text := range raw {
+			lines = append(lines, line{number: i + 1, text: text})
+		}
+		return lines, false
+	}
+
+	next := 0
+	for _, text := range raw {
+		if m := hunkRe.FindStringSubmatch(text); m != nil {
+			fmt.Sscanf(m[1], "%d", &next)
+			continue
+		}
+		if strings.HasPrefix(text, "Index: ") || strings.HasPrefix(text, "diff ") {
+			next = 0 // 下一个文件
+		}
+		if next == 0 {
+			continue // 第一个 hunk 之前的 Index、---、+++ 等行
+		}
+		switch {
+		case strings.HasPrefix(text, "+"):
+			lines = append(lines, line{number: next, text: text[1:], added: true})
+			next++
+		case strings.HasPrefix(text, "-"), strings.HasPrefix(text, `\`):
+		default:
+			next++
+		}
+	}
+	return lines, true
+}
+
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// AddedLines 返回 diff 中新增的行在新版本文件中的行号，content 不是 diff（完整文件）时返回 false
+func AddedLines(content string) (map[int]bool, bool) {
+	lines, isDiff := splitLines(content)
+	if !isDiff {
+		return nil, false
+	}
+	added := make(map[int]bool, len(lines))
+	for _, l := range lines {
+		added[l.number] = true
+	}
+	return added, true
+}
+
+// FileContent 返回完整文件的内容（去掉开头的“新增文件，完整内容:”说明行），content 是 diff 时返回 false
+func FileContent(content string) (string, bool) {
+	lines, isDiff := splitLines(content)
+	if isDiff {
+		return "", false
+	}
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	return strings.Join(texts, "\n"), true
+}
+
+// Hints 生成附加到提示词中的说明，只包含规则标题和行号，不包含代码内容
+func Hints(findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n以下问题已由静态规则检查发现，会直接写入报告，请不要重复报告，专注于其他问题：\n")
+	for _, f := range findings {
+		if f.Line > 0 {
+			fmt.Fprintf(&sb, "- 第 %d 行：%s\n", f.Line, f.Title)
+		} else {
+			fmt.Fprintf(&sb, "- %s\n", f.Title)
+		}
+	}
+	return sb.String()
+}
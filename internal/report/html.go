@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"svn-ai-reviewer/internal/ai"
+	"svn-ai-reviewer/internal/charset"
 )
 
 type FileReview struct {
@@ -20,6 +21,8 @@ type FileReview struct {
 	Error    error
 	Revision int    // SVN版本号（在线模式）
 	Diff     string // 变更内容
+	Blocking bool   // 是否为阻塞提交的问题（如冲突），会在报告顶部醒目显示
+	Encoding string // 文件的原始编码，不是 UTF-8 时内容已转换为 UTF-8
 }
 
 type Report struct {
@@ -27,6 +30,22 @@ type Report struct {
 	GeneratedAt time.Time
 	WorkDir     string
 	Reviews     []FileReview
+	Changesets  []ChangesetReview // 整体变更审核结果（显示在报告顶部）
+	Modules     []Module          // 项目审计的模块分组，不为空时在报告顶部显示模块树
+}
+
+// ChangesetReview 一个版本（或工作副本）的跨文件整体审核结果
+type ChangesetReview struct {
+	Title  string
+	Result *ai.ReviewResult
+	Error  error
+}
+
+// ChangesetGroup 参与整体审核的一组文件
+type ChangesetGroup struct {
+	Title    string
+	Revision int // 0 表示工作副本
+	Files    []ai.ChangesetFile
 }
 
 type TemplateData struct {
@@ -36,8 +55,23 @@ type TemplateData struct {
 	TotalFiles    int
 	SuccessCount  int
 	ErrorCount    int
+	SkippedCount  int // 按数据外发策略或文件类型检测未发送给 AI 的文件数
 	AvgScore      int
 	Reviews       []FileReviewData
+	Changesets    []ChangesetData
+	Blocking      []string // 阻塞问题列表
+	Modules       *ModuleNode // 项目审计的模块树
+}
+
+type ChangesetData struct {
+	Title      string
+	HasError   bool
+	ErrorMsg   string
+	Summary    string
+	Score      int
+	ScoreClass string
+	Issues     []IssueData
+	Skipped    string
 }
 
 type FileReviewData struct {
@@ -55,6 +89,8 @@ type FileReviewData struct {
 	Issues      []IssueData
 	Revision    int    // SVN版本号
 	Diff        string // 变更内容
+	Skipped     string // 未发送给 AI 的原因
+	Encoding    string // 原始编码，UTF-8 时为空
 }
 
 type IssueData struct {
@@ -64,6 +100,9 @@ type IssueData struct {
 	Title         string
 	Description   string
 	Suggestion    string
+	FromRule      bool   // 静态规则检查发现的问题
+	FromSecret    bool   // 敏感信息扫描发现的问题
+	Tool          string // 外部检查工具发现的问题：工具名称
 }
 
 func GenerateHTML(report *Report, outputDir string) (string, error) {
@@ -375,6 +414,78 @@ func generateHTMLContent(report *Report) string {
             padding: 5px 0;
             color: #495057;
         }
+        .blocking-box {
+            background: #f8d7da;
+            color: #721c24;
+            border: 1px solid #f5c6cb;
+            border-left: 4px solid #dc3545;
+            border-radius: 6px;
+            padding: 15px 20px;
+            margin-bottom: 20px;
+        }
+        .blocking-title {
+            font-weight: 600;
+            margin-bottom: 8px;
+        }
+        .blocking-box ul {
+            margin-left: 20px;
+        }
+        .changeset-item {
+            border: 1px solid #e9ecef;
+            border-left: 4px solid #667eea;
+            border-radius: 6px;
+            padding: 20px;
+            margin-bottom: 20px;
+            background: #fbfbff;
+        }
+        .changeset-title {
+            display: flex;
+            align-items: center;
+            gap: 10px;
+            font-size: 16px;
+            font-weight: 600;
+            color: #2c3e50;
+            margin-bottom: 10px;
+        }
+        .module-tree {
+            border: 1px solid #e9ecef;
+            border-left: 4px solid #28a745;
+            border-radius: 6px;
+            padding: 20px;
+            margin-bottom: 20px;
+        }
+        .module-node {
+            margin-left: 18px;
+            padding: 2px 0;
+        }
+        .module-tree > .module-node {
+            margin-left: 0;
+        }
+        .module-node summary {
+            cursor: pointer;
+            display: flex;
+            flex-wrap: wrap;
+            align-items: center;
+            gap: 8px;
+            padding: 4px 0;
+        }
+        .module-name {
+            font-family: "Consolas", "Monaco", monospace;
+            font-weight: 600;
+            color: #2c3e50;
+        }
+        .module-kind, .module-stat {
+            font-size: 12px;
+            color: #6c757d;
+        }
+        .module-issues, .module-files {
+            margin: 4px 0 8px 36px;
+            font-size: 13px;
+        }
+        .module-files li {
+            display: inline-block;
+            margin-right: 16px;
+        }
         .footer {
             text-align: center;
             padding: 20px;
@@ -460,6 +571,11 @@ func generateHTMLContent(report *Report) string {
                 <span class="summary-item"><strong>审核成功:</strong> ` + fmt.Sprintf("%d", data.SuccessCount) + `</span>
                 <span class="summary-item"><strong>审核失败:</strong> ` + fmt.Sprintf("%d", data.ErrorCount) + `</span>`)
 
+	if data.SkippedCount > 0 {
+		sb.WriteString(`
+                <span class="summary-item"><strong>未发送:</strong> ` + fmt.Sprintf("%d", data.SkippedCount) + `</span>`)
+	}
+
 	if data.AvgScore > 0 {
 		sb.WriteString(`
                 <span class="summary-item"><strong>平均评分:</strong> ` + fmt.Sprintf("%d", data.AvgScore) + `</span>`)
@@ -469,7 +585,30 @@ func generateHTMLContent(report *Report) string {
             </div>
             <button class="toggle-all-btn" onclick="toggleAll()">全部展开</button>
         </div>
-        <div class="content">
+        <div class="content">`)
+
+	// 渲染阻塞问题
+	if len(data.Blocking) > 0 {
+		sb.WriteString(`
+            <div class="blocking-box">
+                <div class="blocking-title">⛔ 存在阻塞提交的问题 (` + fmt.Sprintf("%d", len(data.Blocking)) + `)</div>
+                <ul>`)
+		for _, msg := range data.Blocking {
+			sb.WriteString(`
+                    <li>` + html.EscapeString(msg) + `</li>`)
+		}
+		sb.WriteString(`
+                </ul>
+            </div>`)
+	}
+
+	// 渲染整体变更审核结果
+	writeChangesets(&sb, data.Changesets)
+
+	// 渲染项目审计的模块树
+	writeModuleTree(&sb, data.Modules)
+
+	sb.WriteString(`
             <div class="file-list">
 `)
 
@@ -493,6 +632,16 @@ func generateHTMLContent(report *Report) string {
                                 <span class="risk-badge">⚠️ 高风险</span>`)
 		}
 
+		if fileData.Skipped != "" {
+			sb.WriteString(`
+                                <span class="status-badge status-deleted">🚫 未发送</span>`)
+		}
+
+		if fileData.Encoding != "" {
+			sb.WriteString(`
+                                <span class="status-badge status-untracked" title="原始编码，已转换为 UTF-8 显示">` + html.EscapeString(fileData.Encoding) + `</span>`)
+		}
+
 		if fileData.HasReview && fileData.Score > 0 {
 			sb.WriteString(`
                                 <span class="score-badge score-` + fileData.ScoreClass + `">` + fmt.Sprintf("%d分", fileData.Score) + `</span>`)
@@ -514,7 +663,17 @@ func generateHTMLContent(report *Report) string {
                             <span class="error-icon">❌</span>
                             <strong>审核失败:</strong> ` + html.EscapeString(fileData.ErrorMsg) + `
                         </div>`)
+			if len(fileData.Issues) > 0 {
+				writeIssues(&sb, "⚠️ 本地检查发现的问题", fileData.Issues)
+			}
 		} else if fileData.HasReview {
+			if fileData.Skipped != "" {
+				sb.WriteString(`
+                        <div class="review-content">
+                            <p><strong>🚫 未发送给 AI:</strong> ` + html.EscapeString(fileData.Skipped) + `</p>
+                        </div>`)
+			}
+
 			// 总结
 			if fileData.Summary != "" {
 				sb.WriteString(`
@@ -525,20 +684,8 @@ func generateHTMLContent(report *Report) string {
 
 			// 问题列表
 			if len(fileData.Issues) > 0 {
-				sb.WriteString(`
-                        <div class="section-title">⚠️ 发现的问题 (` + fmt.Sprintf("%d", len(fileData.Issues)) + `)</div>`)
-				for _, issue := range fileData.Issues {
-					sb.WriteString(`
-                        <div class="issue-item severity-` + issue.Severity + `">
-                            <div class="issue-title">
-                                <span class="status-badge status-` + issue.SeverityClass + `">` + issue.SeverityText + `</span>
-                                ` + html.EscapeString(issue.Title) + `
-                            </div>
-                            <div class="issue-desc">` + html.EscapeString(issue.Description) + `</div>
-                            <div class="issue-suggestion">💡 建议: ` + html.EscapeString(issue.Suggestion) + `</div>
-                        </div>`)
-				}
-			} else {
+				writeIssues(&sb, "⚠️ 发现的问题", fileData.Issues)
+			} else if fileData.Skipped == "" {
 				sb.WriteString(`
                         <div class="review-content">
                             <p style="color: #28a745;">✅ 未发现明显问题</p>
@@ -654,13 +801,33 @@ func prepareTemplateData(report *Report) *TemplateData {
 			Revision:    review.Revision,
 			Diff:        review.Diff,
 		}
+		if review.Encoding != "" && review.Encoding != charset.UTF8 {
+			fileData.Encoding = review.Encoding
+		}
 
 		if review.Error != nil {
 			fileData.HasError = true
 			fileData.ErrorMsg = review.Error.Error()
 			data.ErrorCount++
+			if review.Blocking {
+				data.Blocking = append(data.Blocking, review.FileName+": "+review.Error.Error())
+			}
+			// AI 审核失败时本地检查（敏感信息、静态检查等）发现的问题仍然显示
+			if review.Result != nil && review.Result.ReviewData != nil {
+				for _, issue := range review.Result.ReviewData.Issues {
+					if issue.Severity == "high" {
+						fileData.IsHighRisk = true
+					}
+					fileData.Issues = append(fileData.Issues, toIssueData(issue))
+				}
+			}
 		} else if review.Result != nil && review.Result.Success {
-			data.SuccessCount++
+			if review.Result.Skipped != "" {
+				data.SkippedCount++
+				fileData.Skipped = review.Result.Skipped
+			} else {
+				data.SuccessCount++
+			}
 			fileData.HasReview = true
 
 			if review.Result.ReviewData != nil {
@@ -680,18 +847,12 @@ func prepareTemplateData(report *Report) *TemplateData {
 					if issue.Severity == "high" {
 						hasHighSeverity = true
 					}
-					fileData.Issues = append(fileData.Issues, IssueData{
-						Severity:      issue.Severity,
-						SeverityClass: getSeverityClass(issue.Severity),
-						SeverityText:  getSeverityText(issue.Severity),
-						Title:         issue.Title,
-						Description:   issue.Description,
-						Suggestion:    issue.Suggestion,
-					})
+					fileData.Issues = append(fileData.Issues, toIssueData(issue))
 				}
 
 				// 判断是否高风险：分数低于60或有高严重性问题
-				fileData.IsHighRisk = rd.Score < 60 || hasHighSeverity
+				// 未发送给 AI 的文件没有评分，只按问题判断
+				fileData.IsHighRisk = (rd.Score < 60 && fileData.Skipped == "") || hasHighSeverity
 			}
 		}
 
@@ -702,9 +863,187 @@ func prepareTemplateData(report *Report) *TemplateData {
 		data.AvgScore = totalScore / scoreCount
 	}
 
+	if len(report.Modules) > 0 {
+		data.Modules = buildModuleTree(report.Modules, data.Reviews)
+	}
+
+	for _, cs := range report.Changesets {
+		csData := ChangesetData{Title: cs.Title}
+		if cs.Error != nil {
+			csData.HasError = true
+			csData.ErrorMsg = cs.Error.Error()
+		} else if cs.Result != nil && cs.Result.Skipped != "" {
+			csData.Skipped = cs.Result.Skipped
+		} else if cs.Result != nil && cs.Result.ReviewData != nil {
+			rd := cs.Result.ReviewData
+			csData.Summary = rd.Summary
+			csData.Score = rd.Score
+			csData.ScoreClass = getScoreClass(rd.Score)
+			for _, issue := range rd.Issues {
+				csData.Issues = append(csData.Issues, toIssueData(issue))
+			}
+		}
+		data.Changesets = append(data.Changesets, csData)
+	}
+
 	return data
 }
 
+// writeIssues 输出问题列表
+func writeIssues(sb *strings.Builder, title string, issues []IssueData) {
+	sb.WriteString(`
+                        <div class="section-title">` + title + ` (` + fmt.Sprintf("%d", len(issues)) + `)</div>`)
+	for _, issue := range issues {
+		sb.WriteString(`
+                        <div class="issue-item severity-` + issue.Severity + `">
+                            <div class="issue-title">
+                                <span class="status-badge status-` + issue.SeverityClass + `">` + issue.SeverityText + `</span>` + sourceBadge(issue) + `
+                                ` + html.EscapeString(issue.Title) + `
+                            </div>
+                            <div class="issue-desc">` + html.EscapeString(issue.Description) + `</div>
+                            <div class="issue-suggestion">💡 建议: ` + html.EscapeString(issue.Suggestion) + `</div>
+                        </div>`)
+	}
+}
+
+// sourceBadge 不依赖 AI 发现的问题显示来源标签：静态规则检查显示“规则”，敏感信息扫描显示“敏感信息”，外部检查工具显示工具名称
+func sourceBadge(issue IssueData) string {
+	switch {
+	case issue.FromRule:
+		return ` <span class="status-badge status-untracked" title="静态规则检查发现，不依赖 AI">规则</span>`
+	case issue.FromSecret:
+		return ` <span class="status-badge status-untracked" title="敏感信息扫描发现，不依赖 AI">敏感信息</span>`
+	case issue.Tool != "":
+		return ` <span class="status-badge status-untracked" title="外部检查工具发现，不依赖 AI">` + html.EscapeString(issue.Tool) + `</span>`
+	}
+	return ""
+}
+
+// lintTool 外部检查工具发现的问题返回工具名称
+func lintTool(issue ai.Issue) string {
+	if issue.Source != ai.IssueSourceLint {
+		return ""
+	}
+	if issue.Tool == "" {
+		return "lint"
+	}
+	return issue.Tool
+}
+
+func toIssueData(issue ai.Issue) IssueData {
+	return IssueData{
+		Severity:      issue.Severity,
+		SeverityClass: getSeverityClass(issue.Severity),
+		SeverityText:  getSeverityText(issue.Severity),
+		Title:         issue.Title,
+		Description:   issue.Description,
+		Suggestion:    issue.Suggestion,
+		FromRule:      issue.Source == ai.IssueSourceRule,
+		FromSecret:    issue.Source == ai.IssueSourceSecret,
+		Tool:          lintTool(issue),
+	}
+}
+
+// writeChangesets 渲染整体变更审核区块
+func writeChangesets(sb *strings.Builder, changesets []ChangesetData) {
+	for _, cs := range changesets {
+		sb.WriteString(`
+            <div class="changeset-item">
+                <div class="changeset-title">🔗 ` + html.EscapeString(cs.Title))
+		if cs.Score > 0 {
+			sb.WriteString(`
+                    <span class="score-badge score-` + cs.ScoreClass + `">` + fmt.Sprintf("%d分", cs.Score) + `</span>`)
+		}
+		sb.WriteString(`
+                </div>`)
+
+		if cs.HasError {
+			sb.WriteString(`
+                <div class="error-message">
+                    <span class="error-icon">❌</span>
+                    <strong>整体审核失败:</strong> ` + html.EscapeString(cs.ErrorMsg) + `
+                </div>`)
+		} else if cs.Skipped != "" {
+			sb.WriteString(`
+                <div class="review-content">
+                    <p><strong>🚫 未发送给 AI:</strong> ` + html.EscapeString(cs.Skipped) + `</p>
+                </div>`)
+		} else {
+			if cs.Summary != "" {
+				sb.WriteString(`
+                <div class="review-content">
+                    <p><strong>📝 总结:</strong> ` + html.EscapeString(cs.Summary) + `</p>
+                </div>`)
+			}
+			if len(cs.Issues) > 0 {
+				sb.WriteString(`
+                <div class="section-title">⚠️ 跨文件问题 (` + fmt.Sprintf("%d", len(cs.Issues)) + `)</div>`)
+				for _, issue := range cs.Issues {
+					sb.WriteString(`
+                <div class="issue-item severity-` + issue.Severity + `">
+                    <div class="issue-title">
+                        <span class="status-badge status-` + issue.SeverityClass + `">` + issue.SeverityText + `</span>
+                        ` + html.EscapeString(issue.Title) + `
+                    </div>
+                    <div class="issue-desc">` + html.EscapeString(issue.Description) + `</div>
+                    <div class="issue-suggestion">💡 建议: ` + html.EscapeString(issue.Suggestion) + `</div>
+                </div>`)
+				}
+			} else {
+				sb.WriteString(`
+                <div class="review-content">
+                    <p style="color: #28a745;">✅ 未发现跨文件问题</p>
+                </div>`)
+			}
+		}
+
+		sb.WriteString(`
+            </div>`)
+	}
+}
+
+// ChangesetGroups 按版本对已审核的文件分组，用于整体变更审核
+// 工作副本模式下所有文件的 Revision 都为 0，归为一组；只有一个文件的组没有跨文件审核的意义，直接跳过
+func ChangesetGroups(reviews []FileReview) []ChangesetGroup {
+	var groups []ChangesetGroup
+	index := make(map[int]int)
+
+	for _, review := range reviews {
+		if review.Diff == "" {
+			continue
+		}
+
+		i, ok := index[review.Revision]
+		if !ok {
+			title := "工作副本整体变更"
+			if review.Revision > 0 {
+				title = fmt.Sprintf("r%d 整体变更", review.Revision)
+			}
+			groups = append(groups, ChangesetGroup{Title: title, Revision: review.Revision})
+			i = len(groups) - 1
+			index[review.Revision] = i
+		}
+
+		file := ai.ChangesetFile{
+			Path:   review.FileName,
+			Status: review.Status,
+			Diff:   review.Diff,
+		}
+		if review.Result != nil {
+			file.Review = review.Result.ReviewData
+		}
+		groups[i].Files = append(groups[i].Files, file)
+	}
+
+	var result []ChangesetGroup
+	for _, group := range groups {
+		if len(group.Files) >= 2 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
 func getScoreClass(score int) string {
 	if score >= 80 {
 		return "high"
@@ -748,6 +1087,10 @@ func getStatusClass(status string) string {
 		return "modified"
 	case "D":
 		return "deleted"
+	case "R":
+		return "modified"
+	case "C", "!", "~":
+		return "deleted"
 	case "?":
 		return "untracked"
 	default:
@@ -763,6 +1106,14 @@ func getStatusText(status string) string {
 		return "修改"
 	case "D":
 		return "删除"
+	case "R":
+		return "替换"
+	case "C":
+		return "冲突"
+	case "!":
+		return "缺失"
+	case "~":
+		return "阻塞"
 	case "?":
 		return "未受控"
 	default:
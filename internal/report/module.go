@@ -0,0 +1,311 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 模块类型
+const (
+	ModuleMaven  = "maven"
+	ModuleGradle = "gradle"
+	ModuleNPM    = "npm"
+	ModuleGo     = "go"
+	ModuleDir    = "dir"
+)
+
+// maxModuleIssues 每个模块显示的主要问题数
+const maxModuleIssues = 5
+
+// Module 项目审计中的一个模块（Maven 模块、Go 包或普通目录）
+type Module struct {
+	Path  string   // 相对扫描目录的路径（/ 分隔），空字符串表示根目录
+	Name  string   // 显示名称，如 artifactId、Go 包的导入路径
+	Kind  string   // ModuleMaven、ModuleGo 等
+	Files []string // 模块中的文件，对应 FileReview.FileName
+}
+
+// ModuleNode 模块树中的一个目录，汇总其下所有模块的审核结果
+type ModuleNode struct {
+	Name     string // 显示名称（相对上级目录的路径，单个子目录的链已合并）
+	Path     string
+	Module   *Module // 该目录本身是模块时不为空
+	Children []*ModuleNode
+
+	FileCount   int
+	ScoredCount int
+	AvgScore    int
+	MinScore    int
+	ErrorCount  int
+	HighCount   int
+	MediumCount int
+	LowCount    int
+	Files       []ModuleFile  // 仅模块节点：模块中的文件
+	TopIssues   []ModuleIssue // 该目录下（含子目录）按严重程度排列的主要问题
+}
+
+// ModuleFile 模块中的文件及其在报告中的序号
+type ModuleFile struct {
+	Index    int
+	FileName string
+	Score    int
+	HasError bool
+}
+
+// ModuleIssue 模块的主要问题
+type ModuleIssue struct {
+	FileIndex int
+	FileName  string
+	Issue     IssueData
+}
+
+// buildModuleTree 按模块路径建立目录树，并统计每个目录（含子目录）的评分和问题数
+func buildModuleTree(modules []Module, reviews []FileReviewData) *ModuleNode {
+	index := make(map[string]int, len(reviews))
+	for i, r := range reviews {
+		index[r.FileName] = i
+	}
+
+	root := &ModuleNode{Name: "(根目录)"}
+	nodes := map[string]*ModuleNode{"": root}
+	var nodeFor func(p string) *ModuleNode
+	nodeFor = func(p string) *ModuleNode {
+		if n, ok := nodes[p]; ok {
+			return n
+		}
+		parentPath := path.Dir(p)
+		if parentPath == "." {
+			parentPath = ""
+		}
+		parent := nodeFor(parentPath)
+		n := &ModuleNode{Name: path.Base(p), Path: p}
+		parent.Children = append(parent.Children, n)
+		nodes[p] = n
+		return n
+	}
+
+	for i := range modules {
+		m := &modules[i]
+		n := nodeFor(strings.Trim(m.Path, "/"))
+		n.Module = m
+		for _, f := range m.Files {
+			if idx, ok := index[f]; ok {
+				r := reviews[idx]
+				n.Files = append(n.Files, ModuleFile{Index: idx, FileName: f, Score: r.Score, HasError: r.HasError})
+				for _, issue := range r.Issues {
+					n.TopIssues = append(n.TopIssues, ModuleIssue{FileIndex: idx, FileName: f, Issue: issue})
+				}
+			}
+		}
+	}
+
+	summarize(root, reviews)
+	compact(root)
+	return root
+}
+
+// summarize 自下而上统计目录树，返回该目录下所有已评分文件的总分
+func summarize(n *ModuleNode, reviews []FileReviewData) int {
+	total := 0
+	for _, f := range n.Files {
+		r := reviews[f.Index]
+		n.FileCount++
+		if r.HasError {
+			n.ErrorCount++
+		}
+		if r.Score > 0 && r.Skipped == "" {
+			n.ScoredCount++
+			total += r.Score
+			if n.MinScore == 0 || r.Score < n.MinScore {
+				n.MinScore = r.Score
+			}
+		}
+	}
+	for _, issue := range n.TopIssues {
+		countIssue(n, issue.Issue.Severity)
+	}
+
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+	for _, c := range n.Children {
+		total += summarize(c, reviews)
+		n.FileCount += c.FileCount
+		n.ScoredCount += c.ScoredCount
+		n.ErrorCount += c.ErrorCount
+		n.HighCount += c.HighCount
+		n.MediumCount += c.MediumCount
+		n.LowCount += c.LowCount
+		if c.MinScore > 0 && (n.MinScore == 0 || c.MinScore < n.MinScore) {
+			n.MinScore = c.MinScore
+		}
+		n.TopIssues = append(n.TopIssues, c.TopIssues...)
+	}
+	if n.ScoredCount > 0 {
+		n.AvgScore = total / n.ScoredCount
+	}
+
+	// 主要问题：严重程度从高到低，同级别时所在文件评分低的优先
+	sort.SliceStable(n.TopIssues, func(i, j int) bool {
+		a, b := n.TopIssues[i], n.TopIssues[j]
+		if ra, rb := severityRank(a.Issue.Severity), severityRank(b.Issue.Severity); ra != rb {
+			return ra < rb
+		}
+		return reviews[a.FileIndex].Score < reviews[b.FileIndex].Score
+	})
+	if len(n.TopIssues) > maxModuleIssues {
+		n.TopIssues = n.TopIssues[:maxModuleIssues]
+	}
+	return total
+}
+
+func countIssue(n *ModuleNode, severity string) {
+	switch severity {
+	case "high":
+		n.HighCount++
+	case "medium":
+		n.MediumCount++
+	default:
+		n.LowCount++
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "high":
+		return 0
+	case "medium":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// compact 合并只有一个子目录、本身又不是模块的目录（如 src/main/java）
+func compact(n *ModuleNode) {
+	for i, c := range n.Children {
+		for c.Module == nil && len(c.Children) == 1 {
+			child := c.Children[0]
+			child.Name = c.Name + "/" + child.Name
+			c = child
+		}
+		n.Children[i] = c
+		compact(c)
+	}
+}
+
+// moduleKindText 模块类型的显示名称
+func moduleKindText(kind string) string {
+	switch kind {
+	case ModuleMaven:
+		return "Maven"
+	case ModuleGradle:
+		return "Gradle"
+	case ModuleNPM:
+		return "npm"
+	case ModuleGo:
+		return "Go 包"
+	default:
+		return "目录"
+	}
+}
+
+// writeModuleTree 渲染项目审计的模块树，每个目录显示汇总数据，模块下列出主要问题和文件
+func writeModuleTree(sb *strings.Builder, root *ModuleNode) {
+	if root == nil {
+		return
+	}
+	sb.WriteString(`
+            <div class="module-tree">
+                <div class="changeset-title">📦 模块概览</div>`)
+	writeModuleNode(sb, root, true)
+	sb.WriteString(`
+            </div>`)
+}
+
+func writeModuleNode(sb *strings.Builder, n *ModuleNode, open bool) {
+	attr := ""
+	if open {
+		attr = " open"
+	}
+	sb.WriteString(`
+                <details class="module-node"` + attr + `>
+                    <summary>
+                        <span class="module-name">` + html.EscapeString(n.Name) + `</span>`)
+	if n.Module != nil {
+		name := n.Module.Name
+		if name != "" && name != n.Module.Path {
+			sb.WriteString(`
+                        <span class="module-kind">` + moduleKindText(n.Module.Kind) + `: ` + html.EscapeString(name) + `</span>`)
+		} else {
+			sb.WriteString(`
+                        <span class="module-kind">` + moduleKindText(n.Module.Kind) + `</span>`)
+		}
+	}
+	sb.WriteString(`
+                        <span class="module-stat">` + fmt.Sprintf("%d 个文件", n.FileCount) + `</span>`)
+	if n.AvgScore > 0 {
+		sb.WriteString(`
+                        <span class="score-badge score-` + getScoreClass(n.AvgScore) + `" title="` + fmt.Sprintf("已评分 %d 个文件，最低 %d 分", n.ScoredCount, n.MinScore) + `">` + fmt.Sprintf("平均 %d分", n.AvgScore) + `</span>`)
+	}
+	if n.HighCount > 0 {
+		sb.WriteString(`
+                        <span class="status-badge status-deleted">` + fmt.Sprintf("高 %d", n.HighCount) + `</span>`)
+	}
+	if n.MediumCount > 0 {
+		sb.WriteString(`
+                        <span class="status-badge status-modified">` + fmt.Sprintf("中 %d", n.MediumCount) + `</span>`)
+	}
+	if n.LowCount > 0 {
+		sb.WriteString(`
+                        <span class="status-badge status-new">` + fmt.Sprintf("低 %d", n.LowCount) + `</span>`)
+	}
+	if n.ErrorCount > 0 {
+		sb.WriteString(`
+                        <span class="module-stat">` + fmt.Sprintf("❌ 失败 %d", n.ErrorCount) + `</span>`)
+	}
+	sb.WriteString(`
+                    </summary>`)
+
+	if len(n.TopIssues) > 0 {
+		sb.WriteString(`
+                    <ul class="module-issues">`)
+		for _, mi := range n.TopIssues {
+			sb.WriteString(`
+                        <li><span class="status-badge status-` + mi.Issue.SeverityClass + `">` + mi.Issue.SeverityText + `</span>` + sourceBadge(mi.Issue) + ` ` +
+				html.EscapeString(mi.Issue.Title) + ` — ` + fileLink(mi.FileIndex, filepath.Base(mi.FileName)) + `</li>`)
+		}
+		sb.WriteString(`
+                    </ul>`)
+	}
+	if len(n.Files) > 0 {
+		sb.WriteString(`
+                    <ul class="module-files">`)
+		for _, f := range n.Files {
+			sb.WriteString(`
+                        <li>` + fileLink(f.Index, filepath.Base(f.FileName)))
+			if f.HasError {
+				sb.WriteString(` ❌`)
+			} else if f.Score > 0 {
+				sb.WriteString(` <span class="score-` + getScoreClass(f.Score) + `">` + fmt.Sprintf("%d分", f.Score) + `</span>`)
+			}
+			sb.WriteString(`</li>`)
+		}
+		sb.WriteString(`
+                    </ul>`)
+	}
+
+	for _, c := range n.Children {
+		writeModuleNode(sb, c, false)
+	}
+	sb.WriteString(`
+                </details>`)
+}
+
+// fileLink 跳转到报告中的文件并展开
+func fileLink(index int, name string) string {
+	id := fmt.Sprintf("file-%d", index)
+	return `<a href="#` + id + `" onclick="document.getElementById('` + id + `').classList.add('expanded')">` + html.EscapeString(name) + `</a>`
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestBuildModuleTree(t *testing.T) {
+	modules := []Module{
+		{Path: "server/src/main/java/core", Name: "core", Kind: ModuleMaven, Files: []string{"Core.java", "Util.java"}},
+		{Path: "server/src/main/java/web", Name: "web", Kind: ModuleMaven, Files: []string{"Web.java"}},
+		{Path: "tools/gen", Name: "example.com/tools/gen", Kind: ModuleGo, Files: []string{"main.go", "missing.go"}},
+	}
+	reviews := []FileReviewData{
+		{FileName: "Core.java", Score: 60, Issues: []IssueData{{Severity: "low", Title: "core-low"}, {Severity: "high", Title: "core-high"}}},
+		{FileName: "Util.java", Score: 80, Issues: []IssueData{{Severity: "medium", Title: "util-medium"}}},
+		{FileName: "Web.java", HasError: true, Issues: []IssueData{{Severity: "high", Title: "web-high"}}},
+		{FileName: "main.go", Score: 90, Skipped: "自动生成的文件"},
+	}
+
+	root := buildModuleTree(modules, reviews)
+
+	// 只有一个子目录的 server/src/main/java 合并为一个节点
+	if len(root.Children) != 2 || root.Children[0].Name != "server/src/main/java" || root.Children[1].Name != "tools/gen" {
+		var names []string
+		for _, c := range root.Children {
+			names = append(names, c.Name)
+		}
+		t.Fatalf("root children = %q, want [server/src/main/java tools/gen]", names)
+	}
+
+	tests := []struct {
+		name        string
+		node        *ModuleNode
+		files       int
+		scored      int
+		avg         int
+		min         int
+		errors      int
+		high        int
+		medium      int
+		low         int
+		firstIssues []string
+	}{
+		{"根目录", root, 4, 2, 70, 60, 1, 2, 1, 1, []string{"web-high", "core-high", "util-medium", "core-low"}},
+		{"合并的目录", root.Children[0], 3, 2, 70, 60, 1, 2, 1, 1, []string{"web-high", "core-high", "util-medium", "core-low"}},
+		{"模块", root.Children[0].Children[0], 2, 2, 70, 60, 0, 1, 1, 1, []string{"core-high", "util-medium", "core-low"}},
+		{"跳过的文件不计分", root.Children[1], 1, 0, 0, 0, 0, 0, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := tt.node
+			if n.FileCount != tt.files || n.ScoredCount != tt.scored || n.AvgScore != tt.avg || n.MinScore != tt.min || n.ErrorCount != tt.errors {
+				t.Errorf("files, scored, avg, min, errors = %d, %d, %d, %d, %d, want %d, %d, %d, %d, %d",
+					n.FileCount, n.ScoredCount, n.AvgScore, n.MinScore, n.ErrorCount, tt.files, tt.scored, tt.avg, tt.min, tt.errors)
+			}
+			if n.HighCount != tt.high || n.MediumCount != tt.medium || n.LowCount != tt.low {
+				t.Errorf("high, medium, low = %d, %d, %d, want %d, %d, %d", n.HighCount, n.MediumCount, n.LowCount, tt.high, tt.medium, tt.low)
+			}
+			if len(n.TopIssues) != len(tt.firstIssues) {
+				t.Fatalf("TopIssues = %d, want %d", len(n.TopIssues), len(tt.firstIssues))
+			}
+			for i, title := range tt.firstIssues {
+				if got := n.TopIssues[i].Issue.Title; got != title {
+					t.Errorf("TopIssues[%d] = %q, want %q", i, got, title)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildModuleTreeTopIssuesLimit(t *testing.T) {
+	var issues []IssueData
+	for i := 0; i < maxModuleIssues+3; i++ {
+		issues = append(issues, IssueData{Severity: "low"})
+	}
+	root := buildModuleTree(
+		[]Module{{Path: "a", Kind: ModuleDir, Files: []string{"a.go"}}},
+		[]FileReviewData{{FileName: "a.go", Score: 50, Issues: issues}},
+	)
+	if len(root.TopIssues) != maxModuleIssues || root.LowCount != maxModuleIssues+3 {
+		t.Errorf("TopIssues = %d, LowCount = %d, want %d, %d", len(root.TopIssues), root.LowCount, maxModuleIssues, maxModuleIssues+3)
+	}
+}
@@ -0,0 +1,253 @@
+package secret
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"svn-ai-reviewer/internal/config"
+)
+
+// Finding 一处疑似敏感信息
+type Finding struct {
+	Rule        string // 规则名称
+	Description string // 规则说明
+	Line        int    // 在扫描文本中的行号（从 1 开始）
+	Preview     string // 打码后的片段，可以安全地写入报告
+}
+
+// rule 一条检测规则
+type rule struct {
+	name        string
+	description string
+	re          *regexp.Regexp
+	group       int     // 需要脱敏的子匹配，0 表示整个匹配
+	minEntropy  float64 // 大于 0 时要求匹配内容的香农熵不低于该值
+}
+
+// 内置规则
+var builtinRules = []rule{
+	{
+		name:        "private-key",
+		description: "私钥",
+		re:          regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY[A-Z ]*-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY[A-Z ]*-----|\z)`),
+	},
+	{
+		name:        "aws-access-key",
+		description: "AWS Access Key ID",
+		re:          regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:        "aws-secret-key",
+		description: "AWS Secret Access Key",
+		re:          regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private)?.{0,10}?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		group:       1,
+	},
+	{
+		name:        "jwt",
+		description: "JWT 令牌",
+		re:          regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	},
+	{
+		name:        "github-token",
+		description: "GitHub 令牌",
+		re:          regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`),
+	},
+	{
+		name:        "slack-token",
+		description: "Slack 令牌",
+		re:          regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		name:        "google-api-key",
+		description: "Google API Key",
+		re:          regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		name:        "openai-api-key",
+		description: "OpenAI 风格的 API Key",
+		re:          regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}\b`),
+	},
+	{
+		name:        "jdbc-password",
+		description: "JDBC 连接串中的密码",
+		re:          regexp.MustCompile(`(?i)jdbc:[a-z0-9]+:[^\s"'<>]*?[?;&:](?:password|pwd)=([^;&\s"'<>]+)`),
+		group:       1,
+	},
+	{
+		name:        "url-credentials",
+		description: "URL 中的用户名密码",
+		re:          regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@"']+:([^@\s/"']+)@`),
+		group:       1,
+	},
+	{
+		name:        "password-assignment",
+		description: "硬编码的密码或密钥",
+		re:          regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|client[_-]?secret)["']?\s*(?::=|=>|[:=])\s*["']([^"'\s]{6,})["']`),
+		group:       1,
+	},
+	{
+		name:        "high-entropy",
+		description: "高熵字符串（疑似密钥）",
+		re:          regexp.MustCompile(`(?i)(?:key|secret|token|passw(?:or)?d|credential|auth)[\w.-]*["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_-]{20,})`),
+		group:       1,
+		minEntropy:  4.0,
+	},
+}
+
+// 常见的占位符，不视为敏感信息
+var placeholderRe = regexp.MustCompile(`(?i)^(?:\$\{.*\}|\{\{.*\}\}|%\(.*\)s?|<.*>|x{4,}|\*{3,}|your[-_].*|changeme|password|secret|example.*|dummy.*|test.*|null|none|true|false)$`)
+
+// redactedMarker 脱敏后的替换文本
+const redactedMarker = "[已脱敏:%s]"
+
+// Scanner 敏感信息扫描器
+type Scanner struct {
+	rules     []rule
+	allowlist []*regexp.Regexp
+}
+
+// NewScanner 根据配置创建扫描器，配置中的自定义规则追加在内置规则之后
+func NewScanner(cfg *config.SecretScanConfig) (*Scanner, error) {
+	s := &Scanner{}
+	for _, r := range builtinRules {
+		if r.minEntropy > 0 && cfg.EntropyThreshold > 0 {
+			r.minEntropy = cfg.EntropyThreshold
+		}
+		s.rules = append(s.rules, r)
+	}
+
+	for _, rc := range cfg.Rules {
+		re, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("敏感信息规则 %s 的正则表达式无效: %w", rc.Name, err)
+		}
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		desc := rc.Description
+		if desc == "" {
+			desc = rc.Name
+		}
+		s.rules = append(s.rules, rule{name: rc.Name, description: desc, re: re, group: group})
+	}
+
+	for _, pattern := range cfg.Allowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("敏感信息白名单 %q 无效: %w", pattern, err)
+		}
+		s.allowlist = append(s.allowlist, re)
+	}
+	return s, nil
+}
+
+// match 一处需要脱敏的区间
+type match struct {
+	start, end int
+	rule       *rule
+}
+
+// Redact 扫描文本并将敏感内容替换为 [已脱敏:规则名]，返回脱敏后的文本和发现的问题
+func (s *Scanner) Redact(text string) (string, []Finding) {
+	var matches []match
+	for i := range s.rules {
+		r := &s.rules[i]
+		for _, loc := range r.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if r.group > 0 {
+				if len(loc) <= 2*r.group+1 || loc[2*r.group] < 0 {
+					continue
+				}
+				start, end = loc[2*r.group], loc[2*r.group+1]
+			}
+			value := text[start:end]
+			if s.allowed(value) {
+				continue
+			}
+			if r.minEntropy > 0 && shannonEntropy(value) < r.minEntropy {
+				continue
+			}
+			matches = append(matches, match{start: start, end: end, rule: r})
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// 按位置排序，重叠的区间合并为一处替换，问题只记录先出现（规则靠前）的一个
+	// 只保留其中一个区间会把超出部分原样发送出去（例如密码赋值规则和高熵规则匹配到同一个值的不同范围）
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	var sb strings.Builder
+	var findings []Finding
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			if m.end > last {
+				last = m.end
+			}
+			continue
+		}
+		sb.WriteString(text[last:m.start])
+		fmt.Fprintf(&sb, redactedMarker, m.rule.name)
+
+		findings = append(findings, Finding{
+			Rule:        m.rule.name,
+			Description: m.rule.description,
+			Line:        strings.Count(text[:m.start], "\n") + 1,
+			Preview:     mask(text[m.start:m.end]),
+		})
+		last = m.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), findings
+}
+
+func (s *Scanner) allowed(value string) bool {
+	if placeholderRe.MatchString(strings.TrimSpace(value)) {
+		return true
+	}
+	for _, re := range s.allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask 只保留前后各 4 个字符，私钥等多行内容只显示第一行
+func mask(value string) string {
+	if i := strings.IndexByte(value, '\n'); i >= 0 {
+		return strings.TrimSpace(value[:i]) + " ..."
+	}
+	runes := []rune(value)
+	if len(runes) <= 10 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + "****" + string(runes[len(runes)-4:])
+}
+
+// shannonEntropy 计算字符串每个字符的香农熵（比特）
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,180 @@
+package source
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"svn-ai-reviewer/internal/report"
+)
+
+// Modules 按模块对扫描到的文件分组，用于项目审计
+//   - Go 文件按所在目录（Go 包）分组，名称为 go.mod 中的模块路径加上相对目录
+//   - 其他文件归入最近的、含有 pom.xml（Maven）、build.gradle（Gradle）或 package.json（npm）的上级目录
+//   - 都没有时按所在目录分组
+//
+// 只在 root 范围内查找上级目录，返回的模块路径相对 root，按路径排序
+func Modules(root string, files []string) []report.Module {
+	d := &moduleDetector{root: root, markers: map[string]*report.Module{}, goMods: map[string]string{}}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		d.root = filepath.Dir(root)
+	}
+
+	byPath := map[string]*report.Module{}
+	for _, f := range files {
+		m := d.moduleOf(f)
+		if existing, ok := byPath[m.Path]; ok {
+			// 同一目录中既有 Go 包又有其他文件时，不论文件顺序都使用 Go 包或构建文件的模块名称
+			if existing.Kind == report.ModuleDir && m.Kind != report.ModuleDir {
+				existing.Name, existing.Kind = m.Name, m.Kind
+			}
+			existing.Files = append(existing.Files, f)
+			continue
+		}
+		m.Files = []string{f}
+		byPath[m.Path] = &m
+	}
+
+	modules := make([]report.Module, 0, len(byPath))
+	for _, m := range byPath {
+		modules = append(modules, *m)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	return modules
+}
+
+type moduleDetector struct {
+	root    string
+	markers map[string]*report.Module // 目录 -> 该目录的构建文件对应的模块，没有构建文件时为 nil
+	goMods  map[string]string         // 目录 -> go.mod 中的模块路径，没有 go.mod 时为空
+}
+
+// moduleOf 返回文件所属的模块（不含 Files）
+func (d *moduleDetector) moduleOf(file string) report.Module {
+	dir := d.rel(filepath.Dir(file))
+
+	if strings.HasSuffix(file, ".go") {
+		name := dir
+		for p := dir; ; p = parentDir(p) {
+			if modPath := d.goMod(p); modPath != "" {
+				name = path.Join(modPath, strings.TrimPrefix(strings.TrimPrefix(dir, p), "/"))
+				break
+			}
+			if p == "" {
+				break
+			}
+		}
+		return report.Module{Path: dir, Name: name, Kind: report.ModuleGo}
+	}
+
+	for p := dir; ; p = parentDir(p) {
+		if m := d.marker(p); m != nil {
+			return *m
+		}
+		if p == "" {
+			break
+		}
+	}
+	return report.Module{Path: dir, Name: dir, Kind: report.ModuleDir}
+}
+
+// rel 返回相对 root 的路径（/ 分隔），根目录为空字符串
+func (d *moduleDetector) rel(dir string) string {
+	rel, err := filepath.Rel(d.root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func parentDir(p string) string {
+	if p = path.Dir(p); p == "." {
+		return ""
+	}
+	return p
+}
+
+// marker 检查目录中是否有构建文件
+func (d *moduleDetector) marker(dir string) *report.Module {
+	if m, ok := d.markers[dir]; ok {
+		return m
+	}
+
+	var m *report.Module
+	abs := filepath.Join(d.root, filepath.FromSlash(dir))
+	switch {
+	case exists(filepath.Join(abs, "pom.xml")):
+		m = &report.Module{Path: dir, Name: pomArtifactID(filepath.Join(abs, "pom.xml")), Kind: report.ModuleMaven}
+	case exists(filepath.Join(abs, "build.gradle")), exists(filepath.Join(abs, "build.gradle.kts")):
+		m = &report.Module{Path: dir, Name: dir, Kind: report.ModuleGradle}
+	case exists(filepath.Join(abs, "package.json")):
+		m = &report.Module{Path: dir, Name: packageName(filepath.Join(abs, "package.json")), Kind: report.ModuleNPM}
+	}
+	if m != nil && m.Name == "" {
+		m.Name = dir
+	}
+	d.markers[dir] = m
+	return m
+}
+
+// goMod 返回目录中 go.mod 声明的模块路径
+func (d *moduleDetector) goMod(dir string) string {
+	if modPath, ok := d.goMods[dir]; ok {
+		return modPath
+	}
+
+	modPath := ""
+	if f, err := os.Open(filepath.Join(d.root, filepath.FromSlash(dir), "go.mod")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "module ") {
+				modPath = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+				break
+			}
+		}
+		f.Close()
+	}
+	d.goMods[dir] = modPath
+	return modPath
+}
+
+// pomArtifactID 读取 pom.xml 中本模块的 artifactId（不是 parent 中的）
+func pomArtifactID(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	var pom struct {
+		ArtifactID string `xml:"artifactId"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(pom.ArtifactID)
+}
+
+// packageName 读取 package.json 中的 name
+func packageName(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Name
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
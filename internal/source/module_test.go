@@ -0,0 +1,72 @@
+package source
+
+import (
+	"path/filepath"
+	"testing"
+
+	"svn-ai-reviewer/internal/report"
+)
+
+func TestModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                            "// 注释\nmodule \"example.com/app\"\n\ngo 1.21\n",
+		"main.go":                           "",
+		"internal/api/api.go":               "",
+		"internal/api/api_test.go":          "",
+		"tools/go.mod":                      "module example.com/tools\n",
+		"tools/gen/main.go":                 "",
+		"server/pom.xml":                    "<project><parent><artifactId>parent</artifactId></parent><artifactId>server-core</artifactId></project>",
+		"server/src/main/java/App.java":     "",
+		"server/web/pom.xml":                "<project><artifactId>server-web</artifactId></project>",
+		"server/web/src/main/java/Web.java": "",
+		"android/build.gradle.kts":          "",
+		"android/src/Main.kt":               "",
+		"frontend/package.json":             `{"name": "@app/frontend"}`,
+		"frontend/src/index.ts":             "",
+		"broken/package.json":               "{",
+		"broken/index.js":                   "",
+		"scripts/deploy.sh":                 "",
+		"README.md":                         "",
+	})
+
+	var files []string
+	for _, name := range []string{
+		"README.md", "main.go", "internal/api/api.go", "internal/api/api_test.go", "tools/gen/main.go",
+		"server/src/main/java/App.java", "server/web/src/main/java/Web.java", "android/src/Main.kt",
+		"frontend/src/index.ts", "broken/index.js", "scripts/deploy.sh",
+	} {
+		files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+	}
+
+	want := []report.Module{
+		{Path: "", Name: "example.com/app", Kind: report.ModuleGo, Files: []string{"README.md", "main.go"}},
+		{Path: "android", Name: "android", Kind: report.ModuleGradle, Files: []string{"android/src/Main.kt"}},
+		{Path: "broken", Name: "broken", Kind: report.ModuleNPM, Files: []string{"broken/index.js"}},
+		{Path: "frontend", Name: "@app/frontend", Kind: report.ModuleNPM, Files: []string{"frontend/src/index.ts"}},
+		{Path: "internal/api", Name: "example.com/app/internal/api", Kind: report.ModuleGo, Files: []string{"internal/api/api.go", "internal/api/api_test.go"}},
+		{Path: "scripts", Name: "scripts", Kind: report.ModuleDir, Files: []string{"scripts/deploy.sh"}},
+		{Path: "server", Name: "server-core", Kind: report.ModuleMaven, Files: []string{"server/src/main/java/App.java"}},
+		{Path: "server/web", Name: "server-web", Kind: report.ModuleMaven, Files: []string{"server/web/src/main/java/Web.java"}},
+		{Path: "tools/gen", Name: "example.com/tools/gen", Kind: report.ModuleGo, Files: []string{"tools/gen/main.go"}},
+	}
+
+	got := Modules(root, files)
+	if len(got) != len(want) {
+		t.Fatalf("Modules() = %d modules, want %d: %+v", len(got), len(want), got)
+	}
+	for i, m := range got {
+		w := want[i]
+		files := relPaths(t, root, m.Files)
+		if m.Path != w.Path || m.Name != w.Name || m.Kind != w.Kind || len(files) != len(w.Files) {
+			t.Errorf("module %d = {%q %q %q %v}, want {%q %q %q %v}", i, m.Path, m.Name, m.Kind, files, w.Path, w.Name, w.Kind, w.Files)
+			continue
+		}
+		for j := range files {
+			if files[j] != w.Files[j] {
+				t.Errorf("module %q files = %v, want %v", m.Path, files, w.Files)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"svn-ai-reviewer/internal/charset"
+	"svn-ai-reviewer/internal/ignore"
+	"svn-ai-reviewer/internal/svn"
+)
+
+// Options 源代码扫描选项
+type Options struct {
+	Filters  []string        // 只包含匹配任一过滤条件的文件（如 *.go、src/*.java），为空时包含所有文件
+	MaxFiles int             // 最多返回的文件数，<= 0 表示不限制
+	Ignore   *ignore.Matcher // 忽略规则，路径相对扫描的目录，为空时不忽略
+}
+
+// Matcher 创建扫描目录的忽略规则：svn:ignore 等属性（目录是工作副本时）、配置文件的 ignore、.svnreviewignore，
+// 最后追加 exclude 中的规则（优先级最高）
+// 扫描时不区分文件是否受控，svn:ignore 等属性匹配的文件（通常是构建输出）也一起跳过
+func Matcher(svnCommand, root string, patterns, exclude []string) (*ignore.Matcher, error) {
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	m, err := svn.NewClient(svnCommand, root).IgnoreMatcher(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("读取忽略规则失败: %w", err)
+	}
+	m.Add("", exclude)
+	return m, nil
+}
+
+// Scan 扫描指定路径下的文件，返回的路径以 root 开头
+// 扫描目录时跳过 .svn 和被忽略的文件和目录；root 本身是文件时直接返回，不检查忽略规则
+// 文件数达到 MaxFiles 时停止扫描，truncated 为 true
+func Scan(root string, opts Options) (files []string, truncated bool, err error) {
+	// 检查路径是否存在
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, false, fmt.Errorf("路径不存在: %w", err)
+	}
+
+	// 如果是文件，直接返回
+	if !info.IsDir() {
+		if matchAny(root, opts.Filters) {
+			files = append(files, root)
+		}
+		return files, false, nil
+	}
+
+	// 如果是目录，递归扫描
+	err = filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, relErr := filepath.Rel(root, filePath)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+
+		// 跳过目录（被忽略的目录不再进入）
+		if info.IsDir() {
+			if info.Name() == ".svn" || opts.Ignore.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if opts.Ignore.Match(rel, false) || !matchAny(filePath, opts.Filters) {
+			return nil
+		}
+
+		// 检查是否达到最大文件数
+		if opts.MaxFiles > 0 && len(files) >= opts.MaxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		files = append(files, filePath)
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("扫描文件失败: %w", err)
+	}
+
+	return files, truncated, nil
+}
+
+// ReadFile 读取文件并转换为 UTF-8，返回内容和原始编码，hint 为配置的默认编码
+func ReadFile(filePath, hint string) (string, string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	content, enc := charset.Decode(data, hint)
+	return content, enc, nil
+}
+
+func matchAny(filePath string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if MatchFilter(filePath, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchFilter 检查文件是否匹配过滤器
+func MatchFilter(filePath string, filter string) bool {
+	// 如果没有过滤器，匹配所有文件
+	if filter == "" {
+		return true
+	}
+
+	// 将路径分隔符统一为 /
+	filePath = filepath.ToSlash(filePath)
+	filter = filepath.ToSlash(filter)
+
+	// 简单的通配符匹配
+	matched, err := filepath.Match(filter, filepath.Base(filePath))
+	if err == nil && matched {
+		return true
+	}
+
+	// 尝试匹配完整路径
+	matched, err = filepath.Match(filter, filePath)
+	if err == nil && matched {
+		return true
+	}
+
+	// 支持多级路径匹配，例如 src/*.go
+	if strings.Contains(filter, "/") {
+		parts := strings.Split(filter, "/")
+		pathParts := strings.Split(filePath, "/")
+
+		// 从后往前匹配
+		if len(parts) <= len(pathParts) {
+			match := true
+			for i := 0; i < len(parts); i++ {
+				partIdx := len(parts) - 1 - i
+				pathIdx := len(pathParts) - 1 - i
+
+				matched, err := filepath.Match(parts[partIdx], pathParts[pathIdx])
+				if err != nil || !matched {
+					match = false
+					break
+				}
+			}
+			if match {
+				return true
+			}
+		}
+	}
+
+	return false
+}
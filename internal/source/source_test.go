@@ -0,0 +1,171 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"svn-ai-reviewer/internal/charset"
+	"svn-ai-reviewer/internal/ignore"
+)
+
+// writeFiles 在 root 下创建文件，路径以 / 分隔
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// relPaths 把 Scan 返回的路径转换为相对 root 的 / 分隔路径
+func relPaths(t *testing.T, root string, files []string) []string {
+	t.Helper()
+	rels := make([]string, 0, len(files))
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	return rels
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":             "package main",
+		"README.md":           "# readme",
+		".svn/wc.db":          "",
+		"src/a.go":            "package src",
+		"src/a_test.go":       "package src",
+		"src/gen/b.go":        "package gen",
+		"web/app.js":          "",
+		"web/dist/app.min.js": "",
+	})
+
+	tests := []struct {
+		name          string
+		opts          Options
+		want          []string
+		wantTruncated bool
+	}{
+		{"所有文件", Options{}, []string{"README.md", "main.go", "src/a.go", "src/a_test.go", "src/gen/b.go", "web/app.js", "web/dist/app.min.js"}, false},
+		{"过滤文件名", Options{Filters: []string{"*.go"}}, []string{"main.go", "src/a.go", "src/a_test.go", "src/gen/b.go"}, false},
+		{"过滤目录", Options{Filters: []string{"src/*.go", "*.md"}}, []string{"README.md", "src/a.go", "src/a_test.go"}, false},
+		{"忽略规则", Options{Ignore: ignore.New([]string{"dist/", "*_test.go", "gen/"})}, []string{"README.md", "main.go", "src/a.go", "web/app.js"}, false},
+		{"最多文件数", Options{Filters: []string{"*.go"}, MaxFiles: 2}, []string{"main.go", "src/a.go"}, true},
+		{"恰好达到最多文件数", Options{Filters: []string{"*.md"}, MaxFiles: 1}, []string{"README.md"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, truncated, err := Scan(root, tt.opts)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got := relPaths(t, root, files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+
+	// root 是文件时直接返回，不检查忽略规则
+	file := filepath.Join(root, "src", "a_test.go")
+	files, _, err := Scan(file, Options{Ignore: ignore.New([]string{"*_test.go"})})
+	if err != nil || !reflect.DeepEqual(files, []string{file}) {
+		t.Errorf("Scan(file) = %v, %v, want [%s]", files, err, file)
+	}
+	if _, _, err := Scan(filepath.Join(root, "missing"), Options{}); err == nil {
+		t.Error("Scan(missing) error = nil, want error")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		ignore.FileName: "*.log\n!keep.log\n",
+	})
+
+	// 不是工作副本（svn 命令也不存在）时只使用配置、.svnreviewignore 和 exclude 中的规则
+	m, err := Matcher("svn-not-installed", root, []string{"tmp/"}, []string{"debug.log"})
+	if err != nil {
+		t.Fatalf("Matcher() error = %v", err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"tmp/a.go", true},
+		{"logs/error.log", true},
+		{"logs/keep.log", false},
+		{"logs/debug.log", true},
+		{"src/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path, false); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	tests := []struct {
+		path   string
+		filter string
+		want   bool
+	}{
+		{"/data/project/src/a.go", "", true},
+		{"/data/project/src/a.go", "*.go", true},
+		{"/data/project/src/a.go", "*.java", false},
+		{"/data/project/src/a.go", "src/*.go", true},
+		{"/data/project/src/sub/a.go", "src/*.go", false},
+		{"/data/project/src/sub/a.go", "src/*/*.go", true},
+		{"a.go", "project/src/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := MatchFilter(tt.path, tt.filter); got != tt.want {
+			t.Errorf("MatchFilter(%q, %q) = %v, want %v", tt.path, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	root := t.TempDir()
+	// GBK 编码的“// 中文注释”
+	writeFiles(t, root, map[string]string{
+		"gbk.c":  "// \xd6\xd0\xce\xc4\xd7\xa2\xca\xcd\n",
+		"utf8.c": "// 中文注释\n",
+	})
+
+	tests := []struct {
+		name    string
+		want    string
+		wantEnc string
+	}{
+		{"gbk.c", "// 中文注释\n", charset.GB18030},
+		{"utf8.c", "// 中文注释\n", charset.UTF8},
+	}
+	for _, tt := range tests {
+		content, enc, err := ReadFile(filepath.Join(root, tt.name), "")
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", tt.name, err)
+		}
+		if content != tt.want || enc != tt.wantEnc {
+			t.Errorf("ReadFile(%s) = %q, %q, want %q, %q", tt.name, content, enc, tt.want, tt.wantEnc)
+		}
+	}
+	if _, _, err := ReadFile(filepath.Join(root, "missing.c"), ""); err == nil {
+		t.Error("ReadFile(missing) error = nil, want error")
+	}
+}
@@ -0,0 +1,223 @@
+package svn
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 本文件负责在调用 svn 命令时安全地传递密码，避免密码出现在进程参数中（ps 可见）
+//
+// 传递方式按优先级：
+//  1. svn 1.10 及以上：--password-from-stdin
+//  2. 更早的版本：在私有的临时配置目录（0700）中写入认证缓存文件，通过 --config-dir 使用
+//  3. 以上都失败时返回错误，不会通过 --password 传递密码
+
+// cliAuth 同一个客户端的所有 svn 命令共享的认证参数
+type cliAuth struct {
+	command   string
+	url       string
+	username  string
+	password  string
+	configDir string // 用户指定的配置目录
+
+	once    sync.Once
+	args    []string // 追加到每条命令的认证参数
+	stdin   string   // 通过标准输入传递的密码
+	tempDir string   // 自动创建的临时配置目录，Close 时删除
+	err     error    // 无法安全传递密码时的错误
+}
+
+// prepare 确定密码的传递方式，只执行一次；无法安全传递密码时返回错误
+func (a *cliAuth) prepare() error {
+	a.once.Do(func() {
+		configDir := a.configDir
+		defer func() {
+			if configDir != "" {
+				a.args = append(a.args, "--config-dir", configDir)
+			}
+		}()
+
+		if a.username == "" {
+			return
+		}
+		a.args = append(a.args, "--username", a.username, "--non-interactive")
+		if a.password == "" {
+			return
+		}
+
+		if supportsPasswordFromStdin(a.command) {
+			a.args = append(a.args, "--password-from-stdin", "--no-auth-cache")
+			a.stdin = a.password + "\n"
+			return
+		}
+
+		dir, err := a.writeAuthCache()
+		if err != nil {
+			a.err = fmt.Errorf("当前 svn 版本不支持 --password-from-stdin，且无法写入临时认证文件（%v）；"+
+				"为避免密码出现在进程参数中，请升级到 svn 1.10 及以上，或使用 svn 的认证缓存（不在配置中填写密码）", err)
+			return
+		}
+		a.tempDir = dir
+		configDir = dir
+	})
+	return a.err
+}
+
+// close 删除自动创建的临时配置目录（其中包含认证文件）
+func (a *cliAuth) close() error {
+	if a.tempDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(a.tempDir)
+	a.tempDir = ""
+	return err
+}
+
+// writeAuthCache 创建私有的临时配置目录，并写入 svn.simple 认证缓存
+// 用户指定了配置目录时复制其中的 config 和 servers，保留代理等设置
+func (a *cliAuth) writeAuthCache() (string, error) {
+	realm, err := authRealm(a.url)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "svn-ai-reviewer-")
+	if err != nil {
+		return "", fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	if a.configDir != "" {
+		for _, name := range []string{"config", "servers"} {
+			if data, err := os.ReadFile(filepath.Join(a.configDir, name)); err == nil {
+				os.WriteFile(filepath.Join(dir, name), data, 0600)
+			}
+		}
+	}
+
+	authDir := filepath.Join(dir, "auth", "svn.simple")
+	if err := os.MkdirAll(authDir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("创建认证目录失败: %w", err)
+	}
+
+	sum := md5.Sum([]byte(realm))
+	file := filepath.Join(authDir, hex.EncodeToString(sum[:]))
+	if err := os.WriteFile(file, []byte(authCacheEntry(realm, a.username, a.password)), 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("写入认证文件失败: %w", err)
+	}
+	return dir, nil
+}
+
+// authCacheEntry 生成 svn 认证缓存文件内容（svn hash dump 格式，键按字母顺序）
+func authCacheEntry(realm, username, password string) string {
+	var sb strings.Builder
+	for _, kv := range [][2]string{
+		{"passtype", "simple"},
+		{"password", password},
+		{"svn:realmstring", realm},
+		{"username", username},
+	} {
+		fmt.Fprintf(&sb, "K %d\n%s\nV %d\n%s\n", len(kv[0]), kv[0], len(kv[1]), kv[1])
+	}
+	sb.WriteString("END\n")
+	return sb.String()
+}
+
+var basicRealmRe = regexp.MustCompile(`(?i)realm="([^"]*)"`)
+
+// authRealm 获取服务器的认证域，格式与 svn 一致，如 "<https://host:443> Subversion"
+func authRealm(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("解析地址失败: %w", err)
+	}
+
+	port := u.Port()
+	switch u.Scheme {
+	case "http", "https":
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		client := &http.Client{Timeout: raDialTimeout}
+		req, err := http.NewRequest(http.MethodOptions, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("获取认证域失败: %w", err)
+		}
+		resp.Body.Close()
+		m := basicRealmRe.FindStringSubmatch(resp.Header.Get("WWW-Authenticate"))
+		if m == nil {
+			return "", fmt.Errorf("服务器没有返回认证域")
+		}
+		return fmt.Sprintf("<%s://%s> %s", u.Scheme, net.JoinHostPort(u.Hostname(), port), m[1]), nil
+
+	case "svn":
+		if port == "" {
+			port = "3690"
+		}
+		realm, err := raRealm(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("获取认证域失败: %w", err)
+		}
+		return fmt.Sprintf("<svn://%s> %s", net.JoinHostPort(u.Hostname(), port), realm), nil
+
+	default:
+		return "", fmt.Errorf("不支持为 %s 协议生成认证文件", u.Scheme)
+	}
+}
+
+var (
+	stdinSupportMu sync.Mutex
+	stdinSupport   = map[string]bool{}
+)
+
+var svnVersionRe = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// supportsPasswordFromStdin 检测 svn 是否支持 --password-from-stdin（1.10 新增）
+func supportsPasswordFromStdin(command string) bool {
+	stdinSupportMu.Lock()
+	defer stdinSupportMu.Unlock()
+
+	if ok, cached := stdinSupport[command]; cached {
+		return ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, command, "--version", "--quiet").Output()
+
+	ok := false
+	if err == nil {
+		if m := svnVersionRe.FindStringSubmatch(strings.TrimSpace(string(out))); m != nil {
+			major, _ := strconv.Atoi(m[1])
+			minor, _ := strconv.Atoi(m[2])
+			ok = major > 1 || (major == 1 && minor >= 10)
+		}
+	}
+	stdinSupport[command] = ok
+	return ok
+}
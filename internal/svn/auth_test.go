@@ -0,0 +1,38 @@
+package svn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCliAuthNoPasswordInArgs(t *testing.T) {
+	// 不存在的 svn 命令不支持 --password-from-stdin，svn+ssh 无法生成认证文件
+	a := &cliAuth{command: "svn-ai-reviewer-missing-svn", url: "svn+ssh://svn.example.com/repo", username: "reviewer", password: "s3cret"}
+	b := &cliBackend{command: a.command, url: a.url, auth: a}
+
+	_, err := b.run("info", "--xml", a.url)
+	if err == nil || !strings.Contains(err.Error(), "--password-from-stdin") {
+		t.Fatalf("err = %v, want password transport error", err)
+	}
+	for _, arg := range a.args {
+		if arg == "--password" || arg == "s3cret" {
+			t.Fatalf("password passed in args: %v", a.args)
+		}
+	}
+	// 后续命令返回同样的错误
+	if err := a.prepare(); err == nil {
+		t.Fatal("second prepare should fail too")
+	}
+}
+
+func TestAuthCacheEntry(t *testing.T) {
+	got := authCacheEntry("<https://svn.example.com:443> Subversion", "reviewer", "s3cret")
+	want := "K 8\npasstype\nV 6\nsimple\n" +
+		"K 8\npassword\nV 6\ns3cret\n" +
+		"K 15\nsvn:realmstring\nV 40\n<https://svn.example.com:443> Subversion\n" +
+		"K 8\nusername\nV 8\nreviewer\n" +
+		"END\n"
+	if got != want {
+		t.Errorf("authCacheEntry =\n%s\nwant\n%s", got, want)
+	}
+}
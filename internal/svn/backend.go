@@ -0,0 +1,119 @@
+package svn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Backend 在线模式访问 SVN 仓库的方式
+// 默认通过 svn 命令行（cli），也可以使用不依赖 svn 程序的原生协议实现（native）
+type Backend interface {
+	// Name 后端名称，用于日志输出
+	Name() string
+	// Info 获取服务器地址对应的仓库信息（仓库根地址、UUID、最新版本号）
+	Info() (*Info, error)
+	// Log 获取日志（包含变更路径），按版本从新到旧排列
+	Log(req LogRequest) ([]LogEntry, error)
+	// DatedRevision 返回指定时间点仓库的最新版本号（即该时间之前的最后一次提交）
+	DatedRevision(t time.Time) (int, error)
+	// Cat 获取文件在指定版本的内容，path 为相对仓库根的路径
+	Cat(path string, revision int) (string, error)
+	// MimeType 获取文件在指定版本的 svn:mime-type 属性，未设置时返回空字符串
+	MimeType(path string, revision int) (string, error)
+	// Diff 获取指定版本的 unified diff
+	// path 为相对仓库根的文件路径，为空时返回服务器地址范围内整个版本的差异
+	Diff(revision int, path string) (string, error)
+}
+
+// LogRequest 日志查询参数
+type LogRequest struct {
+	Path     string // 相对服务器地址的路径，为空表示服务器地址本身
+	StartRev int    // 起始版本（较新的一端），0 表示 HEAD
+	EndRev   int    // 结束版本（较旧的一端），0 表示第一个版本
+	Limit    int    // 最多返回的条数，0 表示不限制
+
+	// Search 在服务器端过滤日志（svn log --search），条目需要匹配全部条件
+	// 匹配作者、日期、提交信息和变更路径，支持 * ? [] 通配符，不区分大小写
+	// native 后端不支持，由调用方在本地过滤
+	Search     []string
+	StopOnCopy bool // 遇到复制（创建分支、标签）时停止
+}
+
+// 支持的后端名称
+const (
+	BackendCLI    = "cli"
+	BackendNative = "native"
+)
+
+// SetBackend 切换在线模式使用的后端
+// native 后端目前只支持 svn:// 协议（svnserve），其他协议请使用 cli
+func (c *Client) SetBackend(name string) error {
+	switch name {
+	case "", BackendCLI:
+		c.backend = c.cli()
+		return nil
+	case BackendNative:
+		if !strings.HasPrefix(c.url, "svn://") {
+			return fmt.Errorf("原生后端目前只支持 svn:// 协议，当前地址: %s", c.url)
+		}
+		c.backend = newRaSvnBackend(c.url, c.username, c.password)
+		return nil
+	default:
+		return fmt.Errorf("不支持的 SVN 后端: %s (支持: %s, %s)", name, BackendCLI, BackendNative)
+	}
+}
+
+// BackendName 返回当前使用的后端名称
+func (c *Client) BackendName() string {
+	return c.online().Name()
+}
+
+// online 返回在线模式使用的后端，未设置时使用命令行
+func (c *Client) online() Backend {
+	if c.backend == nil {
+		c.backend = c.cli()
+	}
+	return c.backend
+}
+
+// cli 返回基于 svn 命令行的后端，所有命令共享同一份认证参数
+func (c *Client) cli() *cliBackend {
+	if c.auth == nil {
+		c.auth = &cliAuth{
+			command:   c.command,
+			url:       c.url,
+			username:  c.username,
+			password:  c.password,
+			configDir: c.configDir,
+		}
+	}
+	return &cliBackend{
+		command: c.command,
+		dir:     c.workDir,
+		url:     c.url,
+		auth:    c.auth,
+	}
+}
+
+// SetConfigDir 指定 svn 命令使用的配置目录（--config-dir），需要在执行任何命令之前调用
+// 为空时使用 svn 的默认配置目录
+func (c *Client) SetConfigDir(dir string) {
+	c.configDir = dir
+	c.auth = nil
+	if cli, ok := c.backend.(*cliBackend); ok {
+		cli.auth = c.cli().auth
+	}
+}
+
+// Close 关闭在线连接并删除临时认证文件
+func (c *Client) Close() error {
+	if closer, ok := c.backend.(io.Closer); ok {
+		closer.Close()
+	}
+	if c.auth != nil {
+		return c.auth.close()
+	}
+	return nil
+}
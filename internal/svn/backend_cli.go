@@ -0,0 +1,200 @@
+package svn
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// cliBackend 通过执行 svn 命令行访问仓库
+type cliBackend struct {
+	command string
+	dir     string
+	url     string
+	auth    *cliAuth
+	root    string // 仓库根地址（按需获取后缓存）
+}
+
+func (b *cliBackend) Name() string {
+	return BackendCLI
+}
+
+// run 执行 svn 命令并返回标准输出
+// 认证参数由 cliAuth 统一添加，密码不会出现在命令行参数中
+func (b *cliBackend) run(args ...string) ([]byte, error) {
+	if err := b.auth.prepare(); err != nil {
+		return nil, err
+	}
+	args = append(args, b.auth.args...)
+
+	cmd := exec.Command(b.command, args...)
+	cmd.Dir = b.dir
+	if b.auth.stdin != "" {
+		cmd.Stdin = strings.NewReader(b.auth.stdin)
+	}
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+
+	if err := cmd.Run(); err != nil {
+		return out.Bytes(), fmt.Errorf("%w, 错误信息: %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+func (b *cliBackend) Info() (*Info, error) {
+	out, err := b.run("info", "--xml", b.url)
+	if err != nil {
+		return nil, fmt.Errorf("执行 svn info 失败: %w", err)
+	}
+
+	infos, err := parseInfo(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("svn info 没有返回任何条目: %s", b.url)
+	}
+	return &infos[0], nil
+}
+
+func (b *cliBackend) Log(req LogRequest) ([]LogEntry, error) {
+	target := b.url
+	if req.Path != "" && req.Path != "/" {
+		target = b.url + "/" + strings.TrimPrefix(req.Path, "/")
+	}
+
+	args := []string{"log", target, "--verbose", "--xml"}
+	if req.StartRev > 0 || req.EndRev > 0 {
+		start := "HEAD"
+		if req.StartRev > 0 {
+			start = fmt.Sprintf("%d", req.StartRev)
+		}
+		args = append(args, "-r", fmt.Sprintf("%s:%d", start, req.EndRev))
+	}
+	if req.Limit > 0 {
+		args = append(args, "--limit", fmt.Sprintf("%d", req.Limit))
+	}
+	for i, pattern := range req.Search {
+		if i == 0 {
+			args = append(args, "--search", pattern)
+		} else {
+			args = append(args, "--search-and", pattern)
+		}
+	}
+	if req.StopOnCopy {
+		args = append(args, "--stop-on-copy")
+	}
+
+	out, err := b.run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("获取日志失败: %w", err)
+	}
+
+	entries, err := parseLog(out)
+	if err != nil {
+		return nil, fmt.Errorf("解析日志失败: %w", err)
+	}
+	return entries, nil
+}
+
+func (b *cliBackend) DatedRevision(t time.Time) (int, error) {
+	info, err := b.Info()
+	if err != nil {
+		return 0, err
+	}
+
+	// 在仓库根上查询，子目录在该时间点可能还不存在
+	out, err := b.run("info", "--xml", "-r", "{"+t.UTC().Format(time.RFC3339)+"}", info.RootURL)
+	if err != nil {
+		return 0, fmt.Errorf("按日期查询版本失败: %w", err)
+	}
+	infos, err := parseInfo(out)
+	if err != nil {
+		return 0, err
+	}
+	if len(infos) == 0 {
+		return 0, fmt.Errorf("svn info 没有返回任何条目: %s", info.RootURL)
+	}
+	return infos[0].Revision, nil
+}
+
+func (b *cliBackend) Cat(path string, revision int) (string, error) {
+	target, err := b.fileURL(path, revision)
+	if err != nil {
+		return "", err
+	}
+	out, err := b.run("cat", "-r", fmt.Sprintf("%d", revision), target)
+	if err != nil {
+		return "", fmt.Errorf("获取文件内容失败: %w", err)
+	}
+	return string(out), nil
+}
+
+func (b *cliBackend) MimeType(path string, revision int) (string, error) {
+	props, err := b.properties(path, revision)
+	if err != nil {
+		return "", fmt.Errorf("获取文件属性失败: %w", err)
+	}
+	return props["svn:mime-type"], nil
+}
+
+// properties 获取文件或目录在指定版本的所有属性
+// propget 在属性不存在时返回错误，proplist 则返回空列表
+func (b *cliBackend) properties(path string, revision int) (map[string]string, error) {
+	target, err := b.fileURL(path, revision)
+	if err != nil {
+		return nil, err
+	}
+	out, err := b.run("proplist", "--xml", "-v", "-r", fmt.Sprintf("%d", revision), target)
+	if err != nil {
+		return nil, err
+	}
+	return parseProplist(out)
+}
+
+// Diff 指定 path 时对该文件执行 svn diff -c N ROOT/path@N，按路径精确定位，不再从整个版本的差异中截取
+func (b *cliBackend) Diff(revision int, path string) (string, error) {
+	if path == "" {
+		out, err := b.run("diff", "-c", fmt.Sprintf("%d", revision), b.url)
+		// diff命令在有差异时可能返回非零，这是正常的
+		if err != nil && len(out) == 0 {
+			return "", fmt.Errorf("获取版本差异失败: %w", err)
+		}
+		return string(out), nil
+	}
+
+	target, err := b.fileURL(path, revision)
+	if err != nil {
+		return "", err
+	}
+	out, err := b.run("diff", "-c", fmt.Sprintf("%d", revision), target)
+	if err != nil && len(out) == 0 {
+		// 该版本中删除的文件在 N 中已不存在，改用上一个版本定位
+		prev, prevErr := b.fileURL(path, revision-1)
+		if prevErr == nil {
+			out, err = b.run("diff", "-c", fmt.Sprintf("%d", revision), prev)
+		}
+		if err != nil && len(out) == 0 {
+			return "", fmt.Errorf("获取文件差异失败: %w", err)
+		}
+	}
+	return string(out), nil
+}
+
+// fileURL 返回仓库中文件在指定版本的 URL（ROOT/path@N），路径中的特殊字符会被转义
+func (b *cliBackend) fileURL(path string, revision int) (string, error) {
+	if b.root == "" {
+		info, err := b.Info()
+		if err != nil {
+			return "", err
+		}
+		b.root = strings.TrimSuffix(info.RootURL, "/")
+	}
+	escaped := (&url.URL{Path: "/" + strings.TrimPrefix(path, "/")}).EscapedPath()
+	return fmt.Sprintf("%s%s@%d", b.root, escaped, revision), nil
+}
@@ -0,0 +1,301 @@
+package svn
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// raSvnBackend 通过 svn:// 协议直接访问 svnserve，不依赖本机安装的 svn 程序
+// 所有请求复用同一个连接，出错后在下一次请求时重新连接
+type raSvnBackend struct {
+	url      string
+	username string
+	password string
+
+	mu      sync.Mutex
+	session *raSession
+}
+
+func newRaSvnBackend(url, username, password string) *raSvnBackend {
+	return &raSvnBackend{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+	}
+}
+
+func (b *raSvnBackend) Name() string {
+	return BackendNative
+}
+
+// do 在已连接的会话上执行操作，失败时关闭连接（协议状态可能已经错乱）
+func (b *raSvnBackend) do(fn func(s *raSession) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session == nil {
+		s, err := dialRaSvn(b.url, b.username, b.password)
+		if err != nil {
+			return err
+		}
+		b.session = s
+	}
+
+	if err := fn(b.session); err != nil {
+		b.session.Close()
+		b.session = nil
+		return err
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (b *raSvnBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session == nil {
+		return nil
+	}
+	err := b.session.Close()
+	b.session = nil
+	return err
+}
+
+func (b *raSvnBackend) Info() (*Info, error) {
+	var info *Info
+	err := b.do(func(s *raSession) error {
+		rev, err := s.latestRev()
+		if err != nil {
+			return err
+		}
+		kind, err := s.checkPath(s.prefix, rev)
+		if err != nil {
+			return err
+		}
+		if kind == "none" {
+			return fmt.Errorf("路径不存在: %s", b.url)
+		}
+		info = &Info{
+			Path:        path.Base("/" + s.prefix),
+			Kind:        kind,
+			Revision:    rev,
+			URL:         b.url,
+			RelativeURL: "^/" + s.prefix,
+			RootURL:     s.root,
+			UUID:        s.uuid,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库信息失败: %w", err)
+	}
+	return info, nil
+}
+
+func (b *raSvnBackend) Log(req LogRequest) ([]LogEntry, error) {
+	var entries []LogEntry
+	err := b.do(func(s *raSession) error {
+		start := req.StartRev
+		if start <= 0 {
+			rev, err := s.latestRev()
+			if err != nil {
+				return err
+			}
+			start = rev
+		}
+
+		target := joinRepoPath(s.prefix, req.Path)
+		var err error
+		entries, err = s.log([]string{target}, start, req.EndRev, req.Limit, req.StopOnCopy)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取日志失败: %w", err)
+	}
+	return entries, nil
+}
+
+func (b *raSvnBackend) DatedRevision(t time.Time) (int, error) {
+	var rev int
+	err := b.do(func(s *raSession) error {
+		var err error
+		rev, err = s.datedRev(t)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("按日期查询版本失败: %w", err)
+	}
+	return rev, nil
+}
+
+func (b *raSvnBackend) Cat(filePath string, revision int) (string, error) {
+	var content string
+	err := b.do(func(s *raSession) error {
+		var err error
+		content, _, err = s.getFile(filePath, revision)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取文件内容失败: %w", err)
+	}
+	return content, nil
+}
+
+func (b *raSvnBackend) MimeType(filePath string, revision int) (string, error) {
+	var props map[string]string
+	err := b.do(func(s *raSession) error {
+		var err error
+		props, err = s.fileProps(filePath, revision)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取文件属性失败: %w", err)
+	}
+	return props["svn:mime-type"], nil
+}
+
+// Diff 根据日志中的变更路径，获取每个文件修改前后的内容并在本地生成 diff
+// 输出格式与 svn diff -c N URL 相同：Index 行中的路径相对服务器地址
+func (b *raSvnBackend) Diff(revision int, filePath string) (string, error) {
+	var sb strings.Builder
+	err := b.do(func(s *raSession) error {
+		entries, err := s.log([]string{""}, revision, revision, 1, false)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("未找到版本 %d", revision)
+		}
+
+		base := "/" + s.prefix
+		for _, p := range entries[0].Paths {
+			if s.prefix != "" && p.Path != base && !strings.HasPrefix(p.Path, base+"/") {
+				continue
+			}
+			if filePath != "" && strings.TrimPrefix(p.Path, "/") != strings.TrimPrefix(filePath, "/") {
+				continue
+			}
+			if err := writeNativeFileDiff(&sb, s, p, revision); err != nil {
+				return fmt.Errorf("%s: %w", p.Path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取版本差异失败: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// writeNativeFileDiff 生成单个变更路径的 diff，目录和内容未变化的文件不输出
+func writeNativeFileDiff(sb *strings.Builder, s *raSession, p LogPath, revision int) error {
+	kind := p.Kind
+	if kind == "" {
+		// 旧版本服务器不返回节点类型，删除的路径需要在上一个版本中查询
+		rev := revision
+		if p.Action == "D" {
+			rev = revision - 1
+		}
+		var err error
+		if kind, err = s.checkPath(p.Path, rev); err != nil {
+			return err
+		}
+	}
+	if kind != "file" {
+		return nil
+	}
+
+	// 确定修改前的内容来源
+	oldPath, oldRev := p.Path, revision-1
+	switch {
+	case (p.Action == "A" || p.Action == "R") && p.CopyFromPath != "":
+		oldPath, oldRev = p.CopyFromPath, p.CopyFromRev
+	case p.Action == "A":
+		oldPath = ""
+	case p.Action == "R":
+		// 没有复制来源的替换：修改前的内容是同一路径的上一个版本，原来是目录时没有修改前的内容
+		oldKind, err := s.checkPath(p.Path, oldRev)
+		if err != nil {
+			return err
+		}
+		if oldKind != "file" {
+			oldPath = ""
+		}
+	}
+
+	var oldContent, newContent string
+	var props map[string]string
+	if oldPath != "" {
+		content, oldProps, err := s.getFile(oldPath, oldRev)
+		if err != nil {
+			return err
+		}
+		oldContent, props = content, oldProps
+	}
+	if p.Action != "D" {
+		content, newProps, err := s.getFile(p.Path, revision)
+		if err != nil {
+			return err
+		}
+		newContent, props = content, newProps
+	}
+
+	display := strings.TrimPrefix(strings.TrimPrefix(p.Path, "/"+s.prefix), "/")
+	if s.prefix == "" {
+		display = strings.TrimPrefix(p.Path, "/")
+	}
+
+	if mime := props["svn:mime-type"]; isBinaryMimeType(mime) {
+		fmt.Fprintf(sb, "Index: %s\n", display)
+		sb.WriteString("===================================================================\n")
+		sb.WriteString(binaryNotice(mime))
+		return nil
+	}
+
+	hunks := unifiedDiff(oldContent, newContent)
+	if hunks == "" {
+		return nil
+	}
+
+	oldLabel := fmt.Sprintf("(revision %d)", revision-1)
+	newLabel := fmt.Sprintf("(revision %d)", revision)
+	if oldPath == "" {
+		oldLabel = "(nonexistent)"
+	}
+	if p.Action == "D" {
+		newLabel = "(nonexistent)"
+	}
+
+	fmt.Fprintf(sb, "Index: %s\n", display)
+	sb.WriteString("===================================================================\n")
+	fmt.Fprintf(sb, "--- %s\t%s\n", display, oldLabel)
+	fmt.Fprintf(sb, "+++ %s\t%s\n", display, newLabel)
+	sb.WriteString(hunks)
+	return nil
+}
+
+// binaryNotice 与 svn diff 对二进制文件的提示相同，用来代替二进制文件的内容
+func binaryNotice(mime string) string {
+	return fmt.Sprintf("Cannot display: file marked as a binary type.\nsvn:mime-type = %s\n", mime)
+}
+
+// isBinaryMimeType 与 svn 的判断规则一致：设置了非 text/ 开头的 mime-type 即视为二进制
+func isBinaryMimeType(mime string) bool {
+	return mime != "" && !strings.HasPrefix(mime, "text/")
+}
+
+// joinRepoPath 拼接相对仓库根的路径
+func joinRepoPath(prefix, rel string) string {
+	rel = strings.Trim(rel, "/")
+	if prefix == "" {
+		return rel
+	}
+	if rel == "" {
+		return prefix
+	}
+	return prefix + "/" + rel
+}
@@ -0,0 +1,125 @@
+package svn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sendFile 回复一条 get-file 命令
+func (f *fakeSvnserve) sendFile(path string, rev int, content string) error {
+	params, err := f.expectCommand("get-file")
+	if err != nil {
+		return err
+	}
+	if len(params) < 2 || params[0].str != path || params[1].list[0].num != rev {
+		return fmt.Errorf("get-file %+v, want %s@%d", params, path, rev)
+	}
+	if err := f.noAuth(); err != nil {
+		return err
+	}
+	if err := f.send("( success ( ( ) " + fmt.Sprint(rev) + " ( ) ) )"); err != nil {
+		return err
+	}
+	if err := f.send(raStr(content) + " " + raStr("")); err != nil {
+		return err
+	}
+	return f.send("( success ( ) )")
+}
+
+// sendKind 回复一条 check-path 命令
+func (f *fakeSvnserve) sendKind(path string, rev int, kind string) error {
+	params, err := f.expectCommand("check-path")
+	if err != nil {
+		return err
+	}
+	if len(params) < 2 || params[0].str != path || params[1].list[0].num != rev {
+		return fmt.Errorf("check-path %+v, want %s@%d", params, path, rev)
+	}
+	if err := f.noAuth(); err != nil {
+		return err
+	}
+	return f.send("( success ( " + kind + " ) )")
+}
+
+func TestWriteNativeFileDiffReplaced(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    LogPath
+		script  func(f *fakeSvnserve) error
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "没有复制来源的替换与上一个版本比较",
+			path: LogPath{Action: "R", Path: "/trunk/a.go", Kind: "file"},
+			script: func(f *fakeSvnserve) error {
+				if err := f.sendKind("trunk/a.go", 1041, "file"); err != nil {
+					return err
+				}
+				if err := f.sendFile("trunk/a.go", 1041, "old\n"); err != nil {
+					return err
+				}
+				return f.sendFile("trunk/a.go", 1042, "new\n")
+			},
+			want: []string{"--- a.go\t(revision 1041)", "-old", "+new"},
+		},
+		{
+			name: "替换前是目录",
+			path: LogPath{Action: "R", Path: "/trunk/a.go", Kind: "file"},
+			script: func(f *fakeSvnserve) error {
+				if err := f.sendKind("trunk/a.go", 1041, "dir"); err != nil {
+					return err
+				}
+				return f.sendFile("trunk/a.go", 1042, "new\n")
+			},
+			want:    []string{"--- a.go\t(nonexistent)", "+new"},
+			notWant: []string{"-old"},
+		},
+		{
+			name: "带复制来源的替换与来源比较",
+			path: LogPath{Action: "R", Path: "/trunk/a.go", Kind: "file", CopyFromPath: "/branches/x/a.go", CopyFromRev: 1030},
+			script: func(f *fakeSvnserve) error {
+				if err := f.sendFile("branches/x/a.go", 1030, "branch\n"); err != nil {
+					return err
+				}
+				return f.sendFile("trunk/a.go", 1042, "new\n")
+			},
+			want: []string{"-branch", "+new"},
+		},
+		{
+			name: "新增文件",
+			path: LogPath{Action: "A", Path: "/trunk/a.go", Kind: "file"},
+			script: func(f *fakeSvnserve) error {
+				return f.sendFile("trunk/a.go", 1042, "new\n")
+			},
+			want: []string{"--- a.go\t(nonexistent)", "+new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newFakeSvnserve(t)
+			client.prefix = "trunk"
+			done := server.serve(func() error { return tt.script(server) })
+
+			var sb strings.Builder
+			if err := writeNativeFileDiff(&sb, client, tt.path, 1042); err != nil {
+				t.Fatalf("writeNativeFileDiff() error = %v", err)
+			}
+			wait(t, done)
+
+			diff := sb.String()
+			for _, s := range tt.want {
+				if !strings.Contains(diff, s) {
+					t.Errorf("diff does not contain %q:\n%s", s, diff)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(diff, s) {
+					t.Errorf("diff contains %q:\n%s", s, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package svn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"svn-ai-reviewer/internal/charset"
+)
+
+// ContextOptions 差异上下文扩展选项
+type ContextOptions struct {
+	Mode     string // "function": 尽量包含整个函数；"lines": 只包含上下 Lines 行
+	Lines    int    // 函数识别失败或函数过长时使用的上下文行数
+	MaxChars int    // 附加上下文的总字符数上限，0 表示不限制
+}
+
+// Hunk unified diff 中的一个变更块
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+}
+
+// maxFunctionLines 超过该行数的函数不再整体展示，改为只展示 hunk 周围的若干行
+const maxFunctionLines = 300
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseHunks 解析 unified diff 中的所有 hunk 头
+func ParseHunks(diff string) []Hunk {
+	var hunks []Hunk
+	for _, line := range strings.Split(diff, "\n") {
+		m := hunkHeaderRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		hunks = append(hunks, Hunk{
+			OldStart: atoiDefault(m[1], 0),
+			OldLines: atoiDefault(m[2], 1),
+			NewStart: atoiDefault(m[3], 0),
+			NewLines: atoiDefault(m[4], 1),
+		})
+	}
+	return hunks
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// WithWorkingCopyContext 读取工作副本中的新版本文件，为 diff 附加每个 hunk 的上下文
+func (c *Client) WithWorkingCopyContext(filePath, diff string, opts ContextOptions) (string, error) {
+	content, err := os.ReadFile(filepath.Join(c.workDir, filePath))
+	if err != nil {
+		return diff, fmt.Errorf("读取文件失败: %w", err)
+	}
+	text, _ := charset.Decode(content, c.encoding)
+	return AddHunkContext(diff, text, filePath, opts), nil
+}
+
+// WithRevisionContext 通过 svn cat 获取指定版本的文件，为 diff 附加每个 hunk 的上下文
+func (c *Client) WithRevisionContext(revision int, path, diff string, opts ContextOptions) (string, error) {
+	content, _, err := c.GetFileContentAtRevision(revision, path)
+	if err != nil {
+		return diff, err
+	}
+	return AddHunkContext(diff, content, path, opts), nil
+}
+
+// AddHunkContext 在 diff 后附加新版本文件中每个 hunk 所在函数（或周围若干行）的完整代码
+func AddHunkContext(diff, newContent, path string, opts ContextOptions) string {
+	hunks := ParseHunks(diff)
+	if len(hunks) == 0 || newContent == "" {
+		return diff
+	}
+	if opts.Lines <= 0 {
+		opts.Lines = 20
+	}
+
+	lines := strings.Split(strings.ReplaceAll(newContent, "\r\n", "\n"), "\n")
+	lang := languageOf(path)
+
+	// 计算每个 hunk 需要展示的行范围（0 起始，闭区间）
+	type span struct {
+		start, end int
+		label      string
+	}
+	var spans []span
+	for _, h := range hunks {
+		start := h.NewStart - 1
+		end := start + h.NewLines - 1
+		if h.NewLines == 0 {
+			end = start
+		}
+		start, end = clampRange(start, end, len(lines))
+
+		s := span{start: start - opts.Lines, end: end + opts.Lines}
+		if opts.Mode != "lines" {
+			if fs, fe, name, ok := enclosingFunction(lines, start, end, lang); ok && fe-fs+1 <= maxFunctionLines {
+				s = span{start: fs, end: fe, label: name}
+			}
+		}
+		s.start, s.end = clampRange(s.start, s.end, len(lines))
+		spans = append(spans, s)
+	}
+
+	// 合并重叠的范围
+	var merged []span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end+1 {
+			if s.end > merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			if merged[n-1].label == "" {
+				merged[n-1].label = s.label
+			} else if s.label != "" && s.label != merged[n-1].label {
+				merged[n-1].label += ", " + s.label
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(diff)
+	if !strings.HasSuffix(diff, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n===== 变更上下文（新版本文件，格式: 行号 | 内容） =====\n")
+
+	// remaining 为剩余的字符数，用尽后（等于 0）仍然要限制，不能当作不限制
+	limited, remaining := opts.MaxChars > 0, opts.MaxChars
+	for _, s := range merged {
+		var section strings.Builder
+		if s.label != "" {
+			fmt.Fprintf(&section, "--- 第 %d-%d 行（%s） ---\n", s.start+1, s.end+1, s.label)
+		} else {
+			fmt.Fprintf(&section, "--- 第 %d-%d 行 ---\n", s.start+1, s.end+1)
+		}
+		for i := s.start; i <= s.end; i++ {
+			fmt.Fprintf(&section, "%5d | %s\n", i+1, lines[i])
+		}
+
+		if limited && section.Len() > remaining {
+			sb.WriteString("... (上下文已达到长度上限，其余部分省略)\n")
+			break
+		}
+		remaining -= section.Len()
+		sb.WriteString(section.String())
+	}
+
+	return sb.String()
+}
+
+func clampRange(start, end, n int) (int, int) {
+	if start < 0 {
+		start = 0
+	}
+	if end >= n {
+		end = n - 1
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// languageOf 根据扩展名判断函数边界识别方式
+func languageOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".java", ".kt", ".scala", ".cs", ".groovy":
+		return "java"
+	case ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh":
+		return "c"
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".vue":
+		return "js"
+	case ".php":
+		return "php"
+	case ".rs":
+		return "rust"
+	case ".py":
+		return "python"
+	default:
+		return ""
+	}
+}
+
+// 各语言函数定义行的识别规则
+var functionPatterns = map[string]*regexp.Regexp{
+	"go":   regexp.MustCompile(`^func\b`),
+	"java": regexp.MustCompile(`^\s*(?:(?:public|protected|private|static|final|abstract|synchronized|native|override|internal|open|suspend|async|virtual)\s+)*(?:fun\s+|def\s+)?[\w<>\[\],.?\s]*?\b(\w+)\s*\([^;]*$`),
+	"c":    regexp.MustCompile(`^(?:[\w:*&<>,~]+\s+)*[*&]?([\w:~]+)\s*\([^;]*$`),
+	"js":   regexp.MustCompile(`(?:\bfunction\b\s*\*?\s*(\w*)\s*\(|^\s*(?:async\s+)?(\w+)\s*\([^)]*\)\s*\{|\b(\w+)\s*[:=]\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>))`),
+	"php":  regexp.MustCompile(`\bfunction\s+(\w+)\s*\(`),
+	"rust": regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+(\w+)`),
+}
+
+// 看起来像函数调用但实际是控制语句的关键字
+var controlKeywordRe = regexp.MustCompile(`^\s*(?:if|else|for|foreach|while|switch|catch|return|new|throw|do|try|synchronized|using|lock)\b`)
+
+var goFuncNameRe = regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`)
+
+// enclosingFunction 查找包含 [start, end] 行范围的函数，返回函数的起止行和名称
+func enclosingFunction(lines []string, start, end int, lang string) (int, int, string, bool) {
+	if lang == "python" {
+		return enclosingPythonFunction(lines, start, end)
+	}
+	re, ok := functionPatterns[lang]
+	if !ok {
+		return 0, 0, "", false
+	}
+
+	// 最多向上查找 500 行
+	for i := start; i >= 0 && start-i <= 500; i-- {
+		line := lines[i]
+		if controlKeywordRe.MatchString(line) || !re.MatchString(line) {
+			continue
+		}
+		blockEnd, ok := matchBrace(lines, i)
+		if !ok || blockEnd < start {
+			continue
+		}
+		if blockEnd < end {
+			// hunk 跨越了多个函数，直接扩展到 hunk 结尾
+			blockEnd = end
+		}
+		return i, blockEnd, functionName(line, lang, re), true
+	}
+	return 0, 0, "", false
+}
+
+func functionName(line, lang string, re *regexp.Regexp) string {
+	if lang == "go" {
+		if m := goFuncNameRe.FindStringSubmatch(line); m != nil {
+			return "函数 " + m[1]
+		}
+		return ""
+	}
+	for _, name := range re.FindStringSubmatch(line)[1:] {
+		if name != "" {
+			return "函数 " + name
+		}
+	}
+	return ""
+}
+
+// matchBrace 从函数定义行开始计数大括号，返回函数体结束的行号
+// 会跳过字符串、字符字面量和注释中的括号
+func matchBrace(lines []string, from int) (int, bool) {
+	depth := 0
+	opened := false
+	inBlockComment := false
+
+	for i := from; i < len(lines) && i-from <= 2000; i++ {
+		line := lines[i]
+		var quote byte
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			if inBlockComment {
+				if ch == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inBlockComment = false
+					j++
+				}
+				continue
+			}
+			if quote != 0 {
+				if ch == '\\' {
+					j++
+				} else if ch == quote {
+					quote = 0
+				}
+				continue
+			}
+			switch ch {
+			case '"', '\'', '`':
+				quote = ch
+			case '/':
+				if j+1 < len(line) && line[j+1] == '/' {
+					j = len(line)
+				} else if j+1 < len(line) && line[j+1] == '*' {
+					inBlockComment = true
+					j++
+				}
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+				if opened && depth == 0 {
+					return i, true
+				}
+			}
+		}
+		// 函数签名之后很快就应该出现左括号，否则不是函数定义
+		if !opened && i-from > 5 {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+var pythonDefRe = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+(\w+)`)
+
+// enclosingPythonFunction 根据缩进查找包含指定行的 def/class
+func enclosingPythonFunction(lines []string, start, end int) (int, int, string, bool) {
+	indentOf := func(s string) int {
+		return len(s) - len(strings.TrimLeft(s, " \t"))
+	}
+
+	hunkIndent := indentOf(lines[start])
+	for i := start; i >= 0 && start-i <= 500; i-- {
+		m := pythonDefRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		defIndent := len(m[1])
+		if i != start && defIndent >= hunkIndent && strings.TrimSpace(lines[start]) != "" {
+			continue
+		}
+
+		blockEnd := i
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == "" {
+				continue
+			}
+			if indentOf(lines[j]) <= defIndent {
+				break
+			}
+			blockEnd = j
+		}
+		if blockEnd < start {
+			continue
+		}
+		if blockEnd < end {
+			blockEnd = end
+		}
+		kind := "函数"
+		if m[2] == "class" {
+			kind = "类"
+		}
+		return i, blockEnd, kind + " " + m[3], true
+	}
+	return 0, 0, "", false
+}
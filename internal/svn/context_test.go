@@ -0,0 +1,50 @@
+package svn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAddHunkContextBudget(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	content := strings.Join(lines, "\n")
+	diff := "@@ -10,1 +10,1 @@\n-old\n+line 10\n@@ -50,1 +50,1 @@\n-old\n+line 50\n@@ -90,1 +90,1 @@\n-old\n+line 90\n"
+
+	// 每个 hunk 上下各 1 行，第一段的长度
+	first := "--- 第 9-11 行 ---\n" + "    9 | line 9\n" + "   10 | line 10\n" + "   11 | line 11\n"
+
+	tests := []struct {
+		name     string
+		maxChars int
+		want     []int // 应当出现的段（hunk 所在行号）
+		omitted  bool
+	}{
+		{"不限制", 0, []int{10, 50, 90}, false},
+		{"预算正好用完后不再附加", len(first), []int{10}, true},
+		{"预算不足第一段", len(first) - 1, nil, true},
+		{"足够大", 10000, []int{10, 50, 90}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddHunkContext(diff, content, "a.txt", ContextOptions{Mode: "lines", Lines: 1, MaxChars: tt.maxChars})
+			for _, n := range []int{10, 50, 90} {
+				header := fmt.Sprintf("--- 第 %d-%d 行 ---", n-1, n+1)
+				wantSection := false
+				for _, w := range tt.want {
+					wantSection = wantSection || w == n
+				}
+				if strings.Contains(got, header) != wantSection {
+					t.Errorf("section %q present = %v, want %v", header, !wantSection, wantSection)
+				}
+			}
+			if strings.Contains(got, "上下文已达到长度上限") != tt.omitted {
+				t.Errorf("omitted marker present = %v, want %v", !tt.omitted, tt.omitted)
+			}
+		})
+	}
+}
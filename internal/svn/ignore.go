@@ -0,0 +1,126 @@
+package svn
+
+import (
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"svn-ai-reviewer/internal/ignore"
+)
+
+// IgnoreMatcher 创建工作副本（或普通目录）的忽略规则，按优先级从低到高依次为：
+//   - svn:global-ignores（包括从上级目录继承的）和 svn:ignore 属性，只作用于未受控的条目（Match），
+//     已受控的条目使用 MatchVersioned
+//   - 配置文件中的 ignore
+//   - 根目录下的 .svnreviewignore
+//
+// 不是工作副本或没有 svn 命令时只使用后两者
+func (c *Client) IgnoreMatcher(patterns []string) (*ignore.Matcher, error) {
+	m := &ignore.Matcher{}
+
+	if values, err := c.localPropget("svn:global-ignores", true); err == nil {
+		for target, value := range values {
+			m.AddGlobalIgnores(c.localRuleDir(target), value)
+		}
+	}
+	if values, err := c.localPropget("svn:ignore", false); err == nil {
+		for target, value := range values {
+			m.AddSVNIgnore(c.localRuleDir(target), value)
+		}
+	}
+
+	m.Add("", patterns)
+	if err := m.AddFile("", filepath.Join(c.workDir, ignore.FileName)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// localPropget 递归获取工作副本中所有设置了该属性的目录，返回目标路径到属性值的映射
+func (c *Client) localPropget(name string, inherited bool) (map[string]string, error) {
+	args := []string{"propget", name, "-R", "--xml"}
+	if inherited {
+		args = append(args, "--show-inherited-props")
+	}
+	args = append(args, ".")
+
+	cmd := exec.Command(c.command, args...)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePropTargets(out, name)
+}
+
+// localRuleDir 将 propget 输出的目标路径转换为相对工作副本根的目录
+// 继承自工作副本以外上级目录的属性（目标为 URL）作用于整个工作副本
+func (c *Client) localRuleDir(target string) string {
+	if strings.Contains(target, "://") {
+		return ""
+	}
+	if filepath.IsAbs(target) {
+		if rel, err := filepath.Rel(c.workDir, target); err == nil {
+			target = rel
+		}
+	}
+	target = filepath.ToSlash(filepath.Clean(target))
+	if target == "." || strings.HasPrefix(target, "../") {
+		return ""
+	}
+	return target
+}
+
+// FilterIgnored 过滤在线模式的变更文件：配置文件中的 ignore 和服务器地址根目录下的 .svnreviewignore，路径相对服务器地址
+// 提交中的文件都是受控文件，svn:ignore 和 svn:global-ignores 对它们不起作用，不读取这些属性
+//
+// 读取规则文件失败时忽略该来源，不影响审核
+func (c *Client) FilterIgnored(changes []FileChange, patterns []string) []FileChange {
+	if len(changes) == 0 {
+		return changes
+	}
+
+	// 规则文件取服务器地址当前的最新版本
+	base := ""
+	var fileRules []string
+	if info, err := c.Info(""); err == nil {
+		base = strings.Trim(repositoryPath(info), "/")
+		if content, err := c.online().Cat(path.Join("/", base, ignore.FileName), info.Revision); err == nil {
+			fileRules = strings.Split(content, "\n")
+		}
+	}
+
+	// 服务器地址以外的路径（同一版本中修改的其他分支）按仓库根匹配
+	inBase, atRoot := &ignore.Matcher{}, &ignore.Matcher{}
+	inBase.Add(base, patterns)
+	inBase.Add(base, fileRules)
+	atRoot.Add("", patterns)
+	atRoot.Add("", fileRules)
+
+	var kept []FileChange
+	for _, change := range changes {
+		filePath := strings.Trim(change.Path, "/")
+		m := inBase
+		if base != "" && !strings.HasPrefix(filePath, base+"/") {
+			m = atRoot
+		}
+		if !m.MatchVersioned(filePath, false) {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+// repositoryPath 返回服务器地址相对仓库根的路径（如 /trunk），服务器地址就是仓库根时为 /
+func repositoryPath(info *Info) string {
+	repoPath := strings.TrimPrefix(info.RelativeURL, "^")
+	if unescaped, err := url.PathUnescape(repoPath); err == nil {
+		repoPath = unescaped
+	}
+	if repoPath == "" {
+		repoPath = "/" + strings.Trim(strings.TrimPrefix(info.URL, info.RootURL), "/")
+	}
+	return repoPath
+}
@@ -0,0 +1,347 @@
+package svn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// LogQuery 日志搜索条件，各条件之间为“且”的关系
+type LogQuery struct {
+	Path       string         // 相对服务器地址的路径，为空表示服务器地址本身
+	Keyword    string         // 关键词，匹配作者、日期、提交信息和变更路径，支持 * ? [] 通配符，不区分大小写
+	Authors    []string       // 作者（不区分大小写，满足其一即可）
+	Message    *regexp.Regexp // 提交信息需要匹配的正则表达式
+	Since      time.Time      // 只返回此时间之后（含）的提交
+	Until      time.Time      // 只返回此时间之前（含）的提交
+	StartRev   int            // 版本范围较新的一端，0 表示 HEAD
+	EndRev     int            // 版本范围较旧的一端，0 表示第一个版本
+	StopOnCopy bool           // 遇到复制（创建分支、标签）时停止
+	Before     int            // 翻页游标：只返回小于该版本的提交，0 表示从头开始
+	Limit      int            // 每页条数，默认 100
+}
+
+// LogPage 一页搜索结果
+type LogPage struct {
+	Entries []LogEntry
+	HasMore bool // 是否还有更多记录
+	Next    int  // 下一页的游标，作为 LogQuery.Before 传入
+	Offline bool // 无法连接服务器，结果来自本地日志缓存
+}
+
+const (
+	// logBatchSize 不能在服务器端过滤时每次获取的条数
+	logBatchSize = 200
+	// logSearchWindow 服务器端过滤时每次搜索的版本跨度，按固定边界划分以便缓存复用
+	logSearchWindow = 1000
+	// maxLogRequests 每次搜索最多向服务器发出的日志请求数，超过后返回已找到的结果，剩余部分由下一页继续
+	maxLogRequests = 20
+	// maxLogCachePages 内存中最多缓存的日志页数
+	maxLogCachePages = 256
+)
+
+// logCache 缓存已获取的日志页
+// 只缓存版本范围固定的请求，已提交的版本不会变化，再次翻页或重复搜索时不需要访问服务器
+type logCache struct {
+	mu    sync.Mutex
+	pages map[string][]LogEntry
+}
+
+// SearchLog 搜索日志
+// 启用本地日志缓存（SetLogCache）时，第一页先把缓存同步到最新版本，再直接在缓存中搜索；
+// 缓存更新失败或要搜索的范围尚未缓存时在线搜索
+func (c *Client) SearchLog(q LogQuery) (*LogPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+
+	if c.store != nil {
+		offline, err := false, error(nil)
+		if q.Before == 0 {
+			offline, err = c.store.sync(c.online(), c.url)
+		}
+		if err == nil {
+			if page, ok := c.store.search(q); ok {
+				page.Offline = offline
+				return page, nil
+			}
+		}
+	}
+	return c.searchOnline(q)
+}
+
+// searchOnline 在线搜索日志
+// 版本范围、日期（换算为版本号）、--stop-on-copy 以及关键词和作者（svn log --search）尽量交给服务器过滤，
+// 其余条件在本地过滤；翻页时从上一页的游标继续向旧版本搜索，不会重复下载已经看过的记录
+func (c *Client) searchOnline(q LogQuery) (*LogPage, error) {
+	upper, lower, head, err := c.logRange(q)
+	if err != nil {
+		return nil, fmt.Errorf("搜索日志失败: %w", err)
+	}
+
+	search := q.searchPatterns()
+	// native 后端不支持 --search；--stop-on-copy 时按版本窗口搜索无法判断是否已经遇到复制，
+	// 这两种情况按条数分批获取后在本地过滤
+	_, isCLI := c.online().(*cliBackend)
+	windowed := isCLI && len(search) > 0 && !q.StopOnCopy
+
+	keyword := globRegexp(q.Keyword)
+	page := &LogPage{}
+	batch := logBatchSize
+	if q.Limit+1 > batch {
+		batch = q.Limit + 1
+	}
+
+	cursor := upper
+	for requests := 0; cursor >= lower && len(page.Entries) <= q.Limit; requests++ {
+		if requests == maxLogRequests {
+			page.HasMore = true
+			page.Next = cursor + 1
+			return page, nil
+		}
+
+		req := LogRequest{Path: q.Path, StartRev: cursor, EndRev: lower, StopOnCopy: q.StopOnCopy}
+		if windowed {
+			req.EndRev = (cursor-1)/logSearchWindow*logSearchWindow + 1
+			if top := req.EndRev + logSearchWindow - 1; top <= head {
+				req.StartRev = top
+			} else {
+				req.StartRev = head
+			}
+			if req.EndRev < lower {
+				req.EndRev = lower
+			}
+			req.Search = search
+		} else {
+			req.Limit = batch
+		}
+
+		entries, err := c.cachedLog(req)
+		if err != nil {
+			return nil, fmt.Errorf("搜索日志失败: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Revision <= cursor && entry.Revision >= lower && q.matches(entry, keyword) {
+				page.Entries = append(page.Entries, entry)
+			}
+		}
+
+		switch {
+		case windowed:
+			cursor = req.EndRev - 1
+		case len(entries) < batch:
+			// 已到达范围的起点（或遇到复制）
+			cursor = lower - 1
+		default:
+			cursor = entries[len(entries)-1].Revision - 1
+		}
+	}
+
+	if len(page.Entries) > q.Limit {
+		page.Entries = page.Entries[:q.Limit]
+		page.HasMore = true
+		page.Next = page.Entries[q.Limit-1].Revision
+	}
+	return page, nil
+}
+
+// logRange 把版本范围、日期和翻页游标换算为要搜索的版本区间 [lower, upper]，同时返回最新版本号
+func (c *Client) logRange(q LogQuery) (upper, lower, head int, err error) {
+	backend := c.online()
+	info, err := backend.Info()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	head = info.Revision
+
+	upper, lower = q.StartRev, q.EndRev
+	if upper > 0 && lower > upper {
+		upper, lower = lower, upper
+	}
+	if upper <= 0 || upper > head {
+		upper = head
+	}
+	if lower < 1 {
+		lower = 1
+	}
+	if q.Before > 0 && q.Before-1 < upper {
+		upper = q.Before - 1
+	}
+
+	if !q.Until.IsZero() {
+		rev, err := backend.DatedRevision(q.Until)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if rev < upper {
+			upper = rev
+		}
+	}
+	if !q.Since.IsZero() {
+		// 返回的是该时间之前的最后一个版本，可能早于 Since，由本地按日期精确过滤
+		rev, err := backend.DatedRevision(q.Since)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if rev > lower {
+			lower = rev
+		}
+	}
+	return upper, lower, head, nil
+}
+
+// searchPatterns 可以交给 svn log --search 的条件
+// --search 同时匹配作者、日期、提交信息和路径，只能缩小范围，精确的作者过滤仍在本地进行
+func (q LogQuery) searchPatterns() []string {
+	var patterns []string
+	if q.Keyword != "" {
+		patterns = append(patterns, q.Keyword)
+	}
+	if len(q.Authors) == 1 {
+		patterns = append(patterns, q.Authors[0])
+	}
+	return patterns
+}
+
+// matches 在本地检查日志条目是否满足全部条件
+func (q LogQuery) matches(entry LogEntry, keyword *regexp.Regexp) bool {
+	if keyword != nil && !matchesKeyword(entry, keyword) {
+		return false
+	}
+	if len(q.Authors) > 0 {
+		found := false
+		for _, author := range q.Authors {
+			if strings.EqualFold(author, entry.Author) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Message != nil && !q.Message.MatchString(entry.Message) {
+		return false
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		date, err := time.Parse(time.RFC3339Nano, entry.Date)
+		if err == nil {
+			if !q.Since.IsZero() && date.Before(q.Since) {
+				return false
+			}
+			if !q.Until.IsZero() && date.After(q.Until) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesKeyword 与 svn log --search 相同，匹配作者、日期、提交信息或任意一个变更路径
+func matchesKeyword(entry LogEntry, keyword *regexp.Regexp) bool {
+	if keyword.MatchString(entry.Author) || keyword.MatchString(entry.Date) || keyword.MatchString(entry.Message) {
+		return true
+	}
+	for _, p := range entry.Paths {
+		if keyword.MatchString(p.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp 把 svn --search 的通配符模式转换为正则表达式（子串匹配，不区分大小写）
+func globRegexp(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("(?is)")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			// 按字符转义，避免把中文等多字节字符拆开
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			i += size - 1
+		}
+	}
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return regexp.MustCompile("(?is)" + regexp.QuoteMeta(pattern))
+	}
+	return re
+}
+
+// cachedLog 获取日志，版本范围固定的请求优先使用缓存
+func (c *Client) cachedLog(req LogRequest) ([]LogEntry, error) {
+	if req.StartRev <= 0 {
+		return c.online().Log(req)
+	}
+
+	key := fmt.Sprintf("%s|%s|%d:%d|%d|%t|%q", c.url, req.Path, req.StartRev, req.EndRev, req.Limit, req.StopOnCopy, req.Search)
+	c.logs.mu.Lock()
+	entries, ok := c.logs.pages[key]
+	c.logs.mu.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	entries, err := c.online().Log(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logs.mu.Lock()
+	if c.logs.pages == nil || len(c.logs.pages) >= maxLogCachePages {
+		c.logs.pages = make(map[string][]LogEntry)
+	}
+	c.logs.pages[key] = entries
+	c.logs.mu.Unlock()
+	return entries, nil
+}
+
+// ParseLogDate 解析命令行或网页端输入的日期，支持 2006-01-02、2006-01-02 15:04[:05] 和 RFC 3339 格式
+// 没有时区时按本地时间处理；只有日期时 endOfDay 为 true 返回当天最后一刻，用于“截止日期”
+func ParseLogDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期: %s（示例: 2024-01-31 或 2024-01-31 18:00）", value)
+}
@@ -0,0 +1,205 @@
+package svn
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// fakeBackend 内存中的仓库，只实现日志搜索需要的方法
+type fakeBackend struct {
+	info     Info
+	entries  []LogEntry // 按版本从新到旧
+	offline  bool       // Info 返回错误，模拟无法连接服务器
+	requests []LogRequest
+}
+
+// newFakeBackend 生成 r1 ~ rN 的提交：奇数版本由 zhang.san 提交并修改 /trunk/src，偶数版本由 li.si 提交并修改 /trunk/doc
+// 第 i 个版本的提交时间为 2024-01-01 加 i 天
+func newFakeBackend(n int) *fakeBackend {
+	b := &fakeBackend{info: Info{
+		Revision:    n,
+		URL:         "svn://svn.example.com/repo/trunk",
+		RootURL:     "svn://svn.example.com/repo",
+		RelativeURL: "^/trunk",
+		UUID:        "0a1b2c3d-uuid",
+	}}
+	for rev := n; rev >= 1; rev-- {
+		entry := LogEntry{
+			Revision: rev,
+			Author:   "li.si",
+			Date:     time.Date(2024, 1, 1+rev, 8, 0, 0, 0, time.UTC).Format(time.RFC3339Nano),
+			Message:  fmt.Sprintf("更新文档 %d", rev),
+			Paths:    []LogPath{{Action: "M", Path: fmt.Sprintf("/trunk/doc/%d.md", rev), Kind: "file"}},
+		}
+		if rev%2 == 1 {
+			entry.Author = "zhang.san"
+			entry.Message = fmt.Sprintf("修复 BUG-%d", rev)
+			entry.Paths = []LogPath{{Action: "M", Path: "/trunk/src/a.go", Kind: "file"}}
+		}
+		b.entries = append(b.entries, entry)
+	}
+	return b
+}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) Info() (*Info, error) {
+	if b.offline {
+		return nil, errors.New("无法连接服务器")
+	}
+	info := b.info
+	return &info, nil
+}
+
+func (b *fakeBackend) Log(req LogRequest) ([]LogEntry, error) {
+	if b.offline {
+		return nil, errors.New("无法连接服务器")
+	}
+	b.requests = append(b.requests, req)
+	start, end := req.StartRev, req.EndRev
+	if start <= 0 {
+		start = b.info.Revision
+	}
+	if end <= 0 {
+		end = 1
+	}
+	var entries []LogEntry
+	for _, e := range b.entries {
+		if e.Revision > start || e.Revision < end {
+			continue
+		}
+		entries = append(entries, e)
+		if req.Limit > 0 && len(entries) == req.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (b *fakeBackend) DatedRevision(t time.Time) (int, error) {
+	for _, e := range b.entries {
+		if date, _ := time.Parse(time.RFC3339Nano, e.Date); !date.After(t) {
+			return e.Revision, nil
+		}
+	}
+	return 0, nil
+}
+
+func (b *fakeBackend) Cat(path string, revision int) (string, error)      { return "", nil }
+func (b *fakeBackend) MimeType(path string, revision int) (string, error) { return "", nil }
+func (b *fakeBackend) Diff(revision int, path string) (string, error)     { return "", nil }
+
+func revisions(entries []LogEntry) []int {
+	revs := make([]int, len(entries))
+	for i, e := range entries {
+		revs[i] = e.Revision
+	}
+	return revs
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchLogOnline(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    LogQuery
+		want     []int
+		wantMore bool
+		wantNext int
+	}{
+		{"分页", LogQuery{Limit: 3}, []int{10, 9, 8}, true, 8},
+		{"翻页游标", LogQuery{Limit: 3, Before: 8}, []int{7, 6, 5}, true, 5},
+		{"作者不区分大小写", LogQuery{Authors: []string{"Zhang.San"}}, []int{9, 7, 5, 3, 1}, false, 0},
+		{"多个作者", LogQuery{Authors: []string{"zhang.san", "li.si"}, Limit: 2}, []int{10, 9}, true, 9},
+		{"关键词通配符", LogQuery{Keyword: "bug-?"}, []int{9, 7, 5, 3, 1}, false, 0},
+		{"关键词匹配路径", LogQuery{Keyword: "doc/1*.md"}, []int{10}, false, 0},
+		{"提交信息正则", LogQuery{Message: regexp.MustCompile(`BUG-[35]$`)}, []int{5, 3}, false, 0},
+		{"版本范围", LogQuery{StartRev: 4, EndRev: 2}, []int{4, 3, 2}, false, 0},
+		{"日期范围", LogQuery{Since: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC)}, []int{6, 5, 4}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewOnlineClient("svn", "svn://svn.example.com/repo/trunk", "", "")
+			c.backend = newFakeBackend(10)
+			page, err := c.SearchLog(tt.query)
+			if err != nil {
+				t.Fatalf("SearchLog() error = %v", err)
+			}
+			if got := revisions(page.Entries); !equalInts(got, tt.want) {
+				t.Errorf("revisions = %v, want %v", got, tt.want)
+			}
+			if page.HasMore != tt.wantMore || page.Next != tt.wantNext {
+				t.Errorf("HasMore, Next = %v, %d, want %v, %d", page.HasMore, page.Next, tt.wantMore, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"bug", "修复 BUG-1", true},
+		{"bug-?", "BUG-12", true},
+		{"a*c", "xxabbbcxx", true},
+		{"[abc]x", "bx", true},
+		{"[!abc]x", "bx", false},
+		{"[!abc]x", "dx", true},
+		{"a.b", "axb", false},
+		{"[unclosed", "[unclosed", true},
+		{"修复*bug", "修复 BUG-1", true},
+		{"文档 1?", "更新文档 10", true},
+		{"[文档]", "更新文档", true},
+	}
+	for _, tt := range tests {
+		if got := globRegexp(tt.pattern).MatchString(tt.text); got != tt.want {
+			t.Errorf("globRegexp(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+	if globRegexp("") != nil {
+		t.Error("globRegexp(\"\") != nil")
+	}
+}
+
+func TestParseLogDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{"", false, time.Time{}, false},
+		{"2024-01-31", false, time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), false},
+		{"2024-01-31", true, time.Date(2024, 1, 31, 23, 59, 59, 999999999, time.Local), false},
+		{"2024-01-31 18:00", true, time.Date(2024, 1, 31, 18, 0, 0, 0, time.Local), false},
+		{"2024-01-31T18:00:05", false, time.Date(2024, 1, 31, 18, 0, 5, 0, time.Local), false},
+		{"2024-01-31T18:00:00Z", false, time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC), false},
+		{"31/01/2024", false, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogDate(tt.value, tt.endOfDay)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseLogDate(%q, %v) = %v, want %v", tt.value, tt.endOfDay, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package svn
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// logStoreBatch 首次建立缓存时每次获取的日志条数
+	logStoreBatch = 1000
+	// maxLogStoreBatches 每次同步最多获取的批数，仓库很大时首次缓存分多次搜索完成，未缓存的部分在线搜索
+	maxLogStoreBatches = 20
+)
+
+// logStoreMeta 缓存的同步状态，保存在 meta.json 中
+// 缓存中的日志覆盖 [Oldest, Revision] 区间内所有涉及服务器地址的提交，Complete 为 true 时 Oldest 之前已没有更早的提交
+type logStoreMeta struct {
+	URL      string    `json:"url"`
+	UUID     string    `json:"uuid"`
+	RootURL  string    `json:"root_url"`
+	Path     string    `json:"path"` // 服务器地址相对仓库根的路径，如 /trunk
+	Revision int       `json:"revision"`
+	Oldest   int       `json:"oldest"`
+	Complete bool      `json:"complete"`
+	Updated  time.Time `json:"updated"`
+}
+
+// logStore 本地日志缓存
+// 每个仓库（UUID）一个目录，其中每个服务器地址一个子目录，保存已获取的日志（log.jsonl，每行一个 LogEntry）；
+// 同步时只获取上次缓存之后的新提交，搜索直接在内存中进行，服务器不可用时也能搜索已缓存的日志
+type logStore struct {
+	root string // 缓存根目录
+
+	mu      sync.Mutex
+	dir     string // 当前服务器地址对应的缓存目录，为空表示尚未加载
+	meta    logStoreMeta
+	entries []LogEntry       // 按版本从新到旧
+	byRev   map[int]int      // 版本号 → entries 下标
+	authors map[string][]int // 小写作者 → 版本号（从新到旧）
+	paths   map[string][]int // 变更路径及其所有上级目录 → 版本号（从新到旧）
+	copies  []int            // 包含复制操作的版本号（从新到旧），--stop-on-copy 时用于查找复制创建上级目录的提交
+}
+
+// SetLogCache 启用本地日志缓存，dir 为缓存根目录，为空时关闭
+func (c *Client) SetLogCache(dir string) {
+	if dir == "" {
+		c.store = nil
+		return
+	}
+	c.store = &logStore{root: dir}
+}
+
+// LogCacheAvailable 服务器无法连接时检查本地是否有该服务器地址的日志缓存
+func (c *Client) LogCacheAvailable() bool {
+	if c.store == nil {
+		return false
+	}
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.store.dir != "" || c.store.loadByURL(c.url) == nil
+}
+
+// sync 把缓存更新到服务器的最新版本，并在首次缓存未完成时继续向旧版本获取
+// 无法连接服务器但本地已有缓存时返回 offline 为 true，使用已缓存的日志
+func (s *logStore) sync(backend Backend, serverURL string) (offline bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := backend.Info()
+	if err != nil {
+		if s.dir != "" || s.loadByURL(serverURL) == nil {
+			return true, nil
+		}
+		return false, err
+	}
+	if err := s.open(info, serverURL); err != nil {
+		return false, err
+	}
+
+	batches := 0
+	if s.meta.Revision > 0 && info.Revision > s.meta.Revision {
+		// 增量更新：svn log -r HEAD:上次版本+1
+		entries, err := backend.Log(LogRequest{StartRev: info.Revision, EndRev: s.meta.Revision + 1})
+		if err != nil {
+			return false, fmt.Errorf("更新日志缓存失败: %w", err)
+		}
+		batches++
+		if err := s.append(entries, func(m *logStoreMeta) { m.Revision = info.Revision }); err != nil {
+			return false, err
+		}
+	}
+	if s.meta.Revision == 0 {
+		s.meta.Revision = info.Revision
+		s.meta.Oldest = info.Revision + 1
+	}
+
+	// 首次缓存：从已缓存的最旧版本继续向前分批获取
+	for ; !s.meta.Complete && batches < maxLogStoreBatches; batches++ {
+		from := s.meta.Oldest - 1
+		if from < 1 {
+			return false, s.append(nil, func(m *logStoreMeta) { m.Oldest, m.Complete = 1, true })
+		}
+		entries, err := backend.Log(LogRequest{StartRev: from, EndRev: 1, Limit: logStoreBatch})
+		if err != nil {
+			return false, fmt.Errorf("建立日志缓存失败: %w", err)
+		}
+		err = s.append(entries, func(m *logStoreMeta) {
+			if len(entries) < logStoreBatch {
+				m.Oldest, m.Complete = 1, true
+			} else {
+				m.Oldest = entries[len(entries)-1].Revision
+			}
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// open 加载服务器地址对应的缓存目录
+func (s *logStore) open(info *Info, serverURL string) error {
+	repoPath := repositoryPath(info)
+
+	// 同一个仓库路径通过不同协议或地址访问时共用缓存
+	sum := sha1.Sum([]byte(repoPath))
+	dir := filepath.Join(s.root, info.UUID, hex.EncodeToString(sum[:6]))
+	if dir == s.dir {
+		return nil
+	}
+
+	if err := s.load(dir); err != nil {
+		return err
+	}
+	s.meta.URL = serverURL
+	s.meta.UUID = info.UUID
+	s.meta.RootURL = info.RootURL
+	s.meta.Path = repoPath
+	return nil
+}
+
+// loadByURL 按服务器地址查找已有的缓存（离线时使用）
+func (s *logStore) loadByURL(serverURL string) error {
+	metas, _ := filepath.Glob(filepath.Join(s.root, "*", "*", "meta.json"))
+	for _, name := range metas {
+		var meta logStoreMeta
+		data, err := os.ReadFile(name)
+		if err != nil || json.Unmarshal(data, &meta) != nil {
+			continue
+		}
+		if strings.TrimSuffix(meta.URL, "/") == strings.TrimSuffix(serverURL, "/") {
+			return s.load(filepath.Dir(name))
+		}
+	}
+	return fmt.Errorf("没有找到 %s 的日志缓存", serverURL)
+}
+
+// load 读取缓存目录中的同步状态和日志
+func (s *logStore) load(dir string) error {
+	s.dir = dir
+	s.meta = logStoreMeta{}
+	s.entries = nil
+
+	data, err := os.ReadFile(filepath.Join(dir, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		s.index()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取日志缓存失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.meta); err != nil {
+		// 缓存损坏时重新建立
+		s.index()
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(dir, "log.jsonl"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("读取日志缓存失败: %w", err)
+	}
+	if f != nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			var entry LogEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Revision > 0 {
+				s.entries = append(s.entries, entry)
+			}
+		}
+	}
+	s.index()
+	return nil
+}
+
+// append 追加日志并更新同步状态
+// 先写日志再写状态，中途中断时重复获取的条目在加载时按版本号去重
+func (s *logStore) append(entries []LogEntry, update func(m *logStoreMeta)) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("创建日志缓存目录失败: %w", err)
+	}
+
+	if len(entries) > 0 {
+		f, err := os.OpenFile(filepath.Join(s.dir, "log.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("写入日志缓存失败: %w", err)
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			enc.Encode(entry)
+		}
+		err = w.Flush()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("写入日志缓存失败: %w", err)
+		}
+		s.entries = append(s.entries, entries...)
+		s.index()
+	}
+
+	update(&s.meta)
+	s.meta.Updated = time.Now()
+	data, err := json.MarshalIndent(s.meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, "meta.json.tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入日志缓存失败: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "meta.json"))
+}
+
+// index 按版本号排序去重，并重建作者和路径索引
+func (s *logStore) index() {
+	sort.SliceStable(s.entries, func(i, j int) bool { return s.entries[i].Revision > s.entries[j].Revision })
+	deduped := s.entries[:0]
+	for i, entry := range s.entries {
+		if i > 0 && entry.Revision == s.entries[i-1].Revision {
+			continue
+		}
+		deduped = append(deduped, entry)
+	}
+	s.entries = deduped
+
+	s.byRev = make(map[int]int, len(s.entries))
+	s.authors = make(map[string][]int)
+	s.paths = make(map[string][]int)
+	s.copies = nil
+	for i, entry := range s.entries {
+		s.byRev[entry.Revision] = i
+		author := strings.ToLower(entry.Author)
+		s.authors[author] = append(s.authors[author], entry.Revision)
+
+		seen := make(map[string]bool)
+		copied := false
+		for _, p := range entry.Paths {
+			copied = copied || p.CopyFromPath != ""
+			for dir := p.Path; dir != "/" && dir != "." && dir != "" && !seen[dir]; dir = path.Dir(dir) {
+				seen[dir] = true
+				s.paths[dir] = append(s.paths[dir], entry.Revision)
+			}
+		}
+		if copied {
+			s.copies = append(s.copies, entry.Revision)
+		}
+	}
+}
+
+// entry 返回缓存中的指定版本
+func (s *logStore) entry(revision int) (LogEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.byRev[revision]
+	if !ok {
+		return LogEntry{}, false
+	}
+	return s.entries[i], true
+}
+
+// search 在缓存中搜索，ok 为 false 表示要搜索的范围尚未缓存，需要在线搜索
+func (s *logStore) search(q LogQuery) (page *LogPage, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir == "" || s.meta.Revision == 0 {
+		return nil, false
+	}
+
+	upper, lower := q.StartRev, q.EndRev
+	if upper > 0 && lower > upper {
+		upper, lower = lower, upper
+	}
+	if upper <= 0 || upper > s.meta.Revision {
+		upper = s.meta.Revision
+	}
+	if q.Before > 0 && q.Before-1 < upper {
+		upper = q.Before - 1
+	}
+	if upper < s.meta.Oldest && !s.meta.Complete {
+		return nil, false
+	}
+
+	target := s.meta.Path
+	if q.Path != "" && q.Path != "/" {
+		target = strings.TrimSuffix(target, "/") + "/" + strings.Trim(q.Path, "/")
+	}
+	keyword := globRegexp(q.Keyword)
+	page = &LogPage{}
+
+	for _, rev := range s.candidates(q, target) {
+		if rev > upper {
+			continue
+		}
+		if rev < lower {
+			break
+		}
+		entry := s.entries[s.byRev[rev]]
+		if q.matches(entry, keyword) {
+			page.Entries = append(page.Entries, entry)
+			if len(page.Entries) > q.Limit {
+				page.Entries = page.Entries[:q.Limit]
+				page.HasMore = true
+				page.Next = page.Entries[q.Limit-1].Revision
+				return page, true
+			}
+		}
+		// 复制点不满足其他条件时也要停止，与服务器端 --stop-on-copy 一致
+		if q.StopOnCopy && copiedAt(entry, target) {
+			return page, true
+		}
+	}
+
+	// 缓存的范围之前还有未缓存的提交，下一页在线搜索
+	if !s.meta.Complete && lower < s.meta.Oldest {
+		page.HasMore = true
+		page.Next = s.meta.Oldest
+	}
+	return page, true
+}
+
+// candidates 根据索引返回可能匹配的版本号（从新到旧）
+// --stop-on-copy 时还包括复制创建 target 上级目录的版本，这些版本不一定修改了 target 或属于指定作者
+func (s *logStore) candidates(q LogQuery, target string) []int {
+	var revs []int
+	switch {
+	case len(q.Authors) > 0:
+		for _, author := range q.Authors {
+			revs = append(revs, s.authors[strings.ToLower(author)]...)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(revs)))
+		revs = s.filterByPath(revs, target)
+	case target != "" && target != "/" && target != s.meta.Path:
+		revs = append(revs, s.paths[target]...)
+	default:
+		revs = make([]int, len(s.entries))
+		for i, entry := range s.entries {
+			revs[i] = entry.Revision
+		}
+		return revs
+	}
+
+	if q.StopOnCopy {
+		added := false
+		for _, rev := range s.copies {
+			if copiedAt(s.entries[s.byRev[rev]], target) {
+				revs = append(revs, rev)
+				added = true
+			}
+		}
+		if added {
+			sort.Sort(sort.Reverse(sort.IntSlice(revs)))
+			revs = s.filterByPath(revs, "")
+		}
+	}
+	return revs
+}
+
+// filterByPath 去掉重复的版本，target 不为空且不是服务器地址本身时只保留涉及 target 的版本
+func (s *logStore) filterByPath(revs []int, target string) []int {
+	var touched map[int]bool
+	if target != "" && target != "/" && target != s.meta.Path {
+		touched = make(map[int]bool)
+		for _, rev := range s.paths[target] {
+			touched[rev] = true
+		}
+	}
+	filtered := revs[:0]
+	for i, rev := range revs {
+		if (touched == nil || touched[rev]) && (i == 0 || rev != revs[i-1]) {
+			filtered = append(filtered, rev)
+		}
+	}
+	return filtered
+}
+
+// copiedAt 该提交是否复制创建了 target 或其上级目录（--stop-on-copy 的停止点）
+func copiedAt(entry LogEntry, target string) bool {
+	for _, p := range entry.Paths {
+		if p.CopyFromPath != "" && (p.Path == target || strings.HasPrefix(target, strings.TrimSuffix(p.Path, "/")+"/")) {
+			return true
+		}
+	}
+	return false
+}
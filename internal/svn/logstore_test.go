@@ -0,0 +1,138 @@
+package svn
+
+import (
+	"testing"
+)
+
+const fakeServerURL = "svn://svn.example.com/repo/trunk"
+
+func TestLogStoreSync(t *testing.T) {
+	root := t.TempDir()
+	backend := newFakeBackend(10)
+
+	// 首次缓存：一次获取全部日志
+	s := &logStore{root: root}
+	if offline, err := s.sync(backend, fakeServerURL); offline || err != nil {
+		t.Fatalf("sync() = %v, %v, want false, nil", offline, err)
+	}
+	if len(backend.requests) != 1 || backend.requests[0].StartRev != 10 || backend.requests[0].Limit != logStoreBatch {
+		t.Errorf("first sync requests = %+v, want one batch from r10", backend.requests)
+	}
+	if !s.meta.Complete || s.meta.Revision != 10 || len(s.entries) != 10 {
+		t.Errorf("meta = %+v, entries = %d, want complete cache of r10", s.meta, len(s.entries))
+	}
+
+	// 增量更新：只获取新提交
+	more := newFakeBackend(12)
+	more.entries = append(more.entries[:2], backend.entries...)
+	backend.requests = nil
+	if _, err := s.sync(more, fakeServerURL); err != nil {
+		t.Fatalf("sync() error = %v", err)
+	}
+	if len(more.requests) != 1 || more.requests[0].StartRev != 12 || more.requests[0].EndRev != 11 {
+		t.Errorf("incremental sync requests = %+v, want r12:11", more.requests)
+	}
+	if got := revisions(s.entries); len(got) != 12 || got[0] != 12 || got[11] != 1 {
+		t.Errorf("entries = %v, want r12 ~ r1", got)
+	}
+
+	// 重新打开时从磁盘加载，服务器版本没有变化时不再请求日志
+	more.requests = nil
+	reopened := &logStore{root: root}
+	if _, err := reopened.sync(more, fakeServerURL); err != nil {
+		t.Fatalf("sync() error = %v", err)
+	}
+	if len(more.requests) != 0 {
+		t.Errorf("sync() after reload requested %+v, want none", more.requests)
+	}
+	if len(reopened.entries) != 12 {
+		t.Errorf("reloaded entries = %d, want 12", len(reopened.entries))
+	}
+
+	// 无法连接服务器时使用已有缓存
+	offlineStore := &logStore{root: root}
+	if offline, err := offlineStore.sync(&fakeBackend{offline: true}, fakeServerURL+"/"); !offline || err != nil {
+		t.Errorf("offline sync() = %v, %v, want true, nil", offline, err)
+	}
+	if _, err := (&logStore{root: t.TempDir()}).sync(&fakeBackend{offline: true}, fakeServerURL); err == nil {
+		t.Error("offline sync() without cache error = nil, want error")
+	}
+}
+
+func TestLogStoreSearch(t *testing.T) {
+	backend := newFakeBackend(10)
+	// r4 从分支复制创建了 /trunk
+	backend.entries[10-4].Paths = append(backend.entries[10-4].Paths, LogPath{Action: "A", Path: "/trunk", CopyFromPath: "/branches/dev", CopyFromRev: 3})
+	s := &logStore{root: t.TempDir()}
+	if _, err := s.sync(backend, fakeServerURL); err != nil {
+		t.Fatalf("sync() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    LogQuery
+		want     []int
+		wantMore bool
+		wantNext int
+	}{
+		{"分页", LogQuery{Limit: 3}, []int{10, 9, 8}, true, 8},
+		{"翻页游标", LogQuery{Limit: 3, Before: 8}, []int{7, 6, 5}, true, 5},
+		{"作者索引", LogQuery{Authors: []string{"LI.SI"}, Limit: 100}, []int{10, 8, 6, 4, 2}, false, 0},
+		{"作者和路径", LogQuery{Authors: []string{"zhang.san", "li.si"}, Path: "src", Limit: 100}, []int{9, 7, 5, 3, 1}, false, 0},
+		{"路径索引", LogQuery{Path: "/doc/", Limit: 2}, []int{10, 8}, true, 8},
+		{"版本范围", LogQuery{StartRev: 3, EndRev: 6, Limit: 100}, []int{6, 5, 4, 3}, false, 0},
+		{"关键词", LogQuery{Keyword: "文档 1?", Limit: 100}, []int{10}, false, 0},
+		{"遇到复制时停止", LogQuery{StopOnCopy: true, Limit: 100}, []int{10, 9, 8, 7, 6, 5, 4}, false, 0},
+		{"复制的上级目录", LogQuery{Path: "src", StopOnCopy: true, Limit: 100}, []int{9, 7, 5, 4}, false, 0},
+		{"复制点不属于指定作者", LogQuery{Authors: []string{"zhang.san"}, StopOnCopy: true, Limit: 100}, []int{9, 7, 5}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, ok := s.search(tt.query)
+			if !ok {
+				t.Fatal("search() ok = false, want true")
+			}
+			if got := revisions(page.Entries); !equalInts(got, tt.want) {
+				t.Errorf("revisions = %v, want %v", got, tt.want)
+			}
+			if page.HasMore != tt.wantMore || page.Next != tt.wantNext {
+				t.Errorf("HasMore, Next = %v, %d, want %v, %d", page.HasMore, page.Next, tt.wantMore, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestSearchLogOffline(t *testing.T) {
+	root := t.TempDir()
+	c := NewOnlineClient("svn", fakeServerURL, "", "")
+	c.SetLogCache(root)
+	c.backend = newFakeBackend(5)
+	if _, err := c.SearchLog(LogQuery{}); err != nil {
+		t.Fatalf("SearchLog() error = %v", err)
+	}
+
+	// 新的客户端无法连接服务器，仍能搜索缓存的日志
+	c = NewOnlineClient("svn", fakeServerURL, "", "")
+	c.SetLogCache(root)
+	c.backend = &fakeBackend{offline: true}
+	if !c.LogCacheAvailable() {
+		t.Fatal("LogCacheAvailable() = false, want true")
+	}
+	page, err := c.SearchLog(LogQuery{Authors: []string{"zhang.san"}})
+	if err != nil {
+		t.Fatalf("SearchLog() error = %v", err)
+	}
+	if !page.Offline {
+		t.Error("page.Offline = false, want true")
+	}
+	if got, want := revisions(page.Entries), []int{5, 3, 1}; !equalInts(got, want) {
+		t.Errorf("revisions = %v, want %v", got, want)
+	}
+
+	other := NewOnlineClient("svn", "svn://svn.example.com/other", "", "")
+	other.SetLogCache(root)
+	if other.LogCacheAvailable() {
+		t.Error("LogCacheAvailable() for another server = true, want false")
+	}
+}
@@ -0,0 +1,671 @@
+package svn
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 本文件实现 svnserve 使用的 ra_svn 协议（svn://）的客户端部分
+// 协议说明见 subversion/libsvn_ra_svn/protocol，只实现了审核需要的只读命令
+
+const (
+	raDialTimeout = 30 * time.Second
+	raCallTimeout = 5 * time.Minute
+	raClientName  = "svn-ai-reviewer"
+)
+
+// 读取服务器数据的上限，防止异常的服务器让客户端分配任意大小的内存
+const (
+	raMaxStringLength = 64 << 20 // 单个字符串（文件内容按块发送，每块远小于此值）
+	raMaxWordLength   = 256
+	raMaxListDepth    = 64
+)
+
+// raItem 协议中的一个数据项：数字、字符串、单词或列表
+type raItem struct {
+	kind byte // 'n' 数字, 's' 字符串, 'w' 单词, 'l' 列表
+	num  int
+	str  string
+	list []raItem
+}
+
+// text 返回字符串或单词的内容
+func (it raItem) text() string {
+	return it.str
+}
+
+// isWord 判断是否为指定的单词
+func (it raItem) isWord(w string) bool {
+	return it.kind == 'w' && it.str == w
+}
+
+// raWriter 构造要发送的命令
+type raWriter struct {
+	bytes.Buffer
+}
+
+func (w *raWriter) open()         { w.WriteString("( ") }
+func (w *raWriter) close()        { w.WriteString(") ") }
+func (w *raWriter) word(s string) { w.WriteString(s + " ") }
+func (w *raWriter) num(n int)     { fmt.Fprintf(w, "%d ", n) }
+func (w *raWriter) str(s string)  { fmt.Fprintf(w, "%d:%s ", len(s), s) }
+
+func (w *raWriter) boolean(b bool) {
+	if b {
+		w.word("true")
+	} else {
+		w.word("false")
+	}
+}
+
+// optRev 写入可选的版本号，0 表示不指定
+func (w *raWriter) optRev(rev int) {
+	w.open()
+	if rev > 0 {
+		w.num(rev)
+	}
+	w.close()
+}
+
+// raSession 一个 svn:// 连接
+type raSession struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	user   string
+	pass   string
+	uuid   string
+	root   string // 仓库根地址
+	prefix string // 服务器地址相对仓库根的路径，不含首尾斜杠
+}
+
+// dialRaSvn 连接 svnserve，完成握手和认证，并将会话切换到仓库根
+func dialRaSvn(rawURL, username, password string) (*raSession, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析地址失败: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "3690")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, raDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接 %s 失败: %w", host, err)
+	}
+
+	s := &raSession{conn: conn, r: bufio.NewReader(conn), user: username, pass: password}
+	conn.SetDeadline(time.Now().Add(raCallTimeout))
+	if err := s.handshake(strings.TrimSuffix(rawURL, "/")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// raRealm 连接 svnserve 并读取认证请求中的认证域，不进行认证
+func raRealm(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("解析地址失败: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "3690")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, raDialTimeout)
+	if err != nil {
+		return "", fmt.Errorf("连接 %s 失败: %w", host, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(raDialTimeout))
+
+	s := &raSession{conn: conn, r: bufio.NewReader(conn)}
+	if _, err := s.readResponse(); err != nil {
+		return "", fmt.Errorf("读取服务器问候失败: %w", err)
+	}
+	if err := s.write(clientGreeting(strings.TrimSuffix(rawURL, "/"))); err != nil {
+		return "", err
+	}
+	req, err := s.readResponse()
+	if err != nil {
+		return "", fmt.Errorf("读取认证请求失败: %w", err)
+	}
+	if len(req) < 2 {
+		return "", fmt.Errorf("服务器没有返回认证域")
+	}
+	return req[1].text(), nil
+}
+
+func (s *raSession) Close() error {
+	return s.conn.Close()
+}
+
+func (s *raSession) handshake(sessionURL string) error {
+	// 服务器问候: ( success ( minver maxver ( mechs ) ( caps ) ) )
+	greeting, err := s.readResponse()
+	if err != nil {
+		return fmt.Errorf("读取服务器问候失败: %w", err)
+	}
+	if len(greeting) < 2 || greeting[0].num > 2 || greeting[1].num < 2 {
+		return fmt.Errorf("服务器不支持协议版本 2")
+	}
+
+	if err := s.write(clientGreeting(sessionURL)); err != nil {
+		return err
+	}
+
+	if err := s.auth(); err != nil {
+		return err
+	}
+
+	// 仓库信息: ( success ( uuid repos-url ( caps ) ) )
+	info, err := s.readResponse()
+	if err != nil {
+		return fmt.Errorf("打开仓库失败: %w", err)
+	}
+	if len(info) < 2 {
+		return fmt.Errorf("服务器返回的仓库信息不完整")
+	}
+	s.uuid = info[0].text()
+	s.root = strings.TrimSuffix(info[1].text(), "/")
+	s.prefix = strings.Trim(strings.TrimPrefix(sessionURL, s.root), "/")
+
+	// 统一使用相对仓库根的路径
+	if s.prefix != "" {
+		var rw raWriter
+		rw.str(s.root)
+		if _, err := s.call("reparent", &rw); err != nil {
+			return fmt.Errorf("切换到仓库根失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// clientGreeting 客户端握手响应: ( version ( caps ) url ra-client ( ) )
+func clientGreeting(sessionURL string) *raWriter {
+	var w raWriter
+	w.open()
+	w.num(2)
+	w.open()
+	for _, c := range []string{"edit-pipeline", "svndiff1", "absent-entries", "depth", "mergeinfo", "log-revprops"} {
+		w.word(c)
+	}
+	w.close()
+	w.str(sessionURL)
+	w.str(raClientName)
+	w.open()
+	w.close()
+	w.close()
+	return &w
+}
+
+// auth 处理服务器的认证请求: ( success ( ( mech ... ) realm ) )
+// 机制列表为空表示不需要认证
+func (s *raSession) auth() error {
+	req, err := s.readResponse()
+	if err != nil {
+		return fmt.Errorf("读取认证请求失败: %w", err)
+	}
+	if len(req) == 0 || len(req[0].list) == 0 {
+		return nil
+	}
+
+	mechs := map[string]bool{}
+	for _, m := range req[0].list {
+		mechs[m.text()] = true
+	}
+
+	var w raWriter
+	switch {
+	case s.user != "" && mechs["CRAM-MD5"]:
+		w.open()
+		w.word("CRAM-MD5")
+		w.open()
+		w.close()
+		w.close()
+	case s.user != "" && mechs["PLAIN"]:
+		w.open()
+		w.word("PLAIN")
+		w.open()
+		w.str("\x00" + s.user + "\x00" + s.pass)
+		w.close()
+		w.close()
+	case mechs["ANONYMOUS"]:
+		w.open()
+		w.word("ANONYMOUS")
+		w.open()
+		w.str("")
+		w.close()
+		w.close()
+	default:
+		if s.user == "" {
+			return fmt.Errorf("服务器要求认证，请提供用户名和密码")
+		}
+		return fmt.Errorf("服务器不支持可用的认证方式: %v", req[0].list)
+	}
+	if err := s.write(&w); err != nil {
+		return err
+	}
+
+	for {
+		item, err := s.readItem()
+		if err != nil {
+			return fmt.Errorf("读取认证结果失败: %w", err)
+		}
+		if item.kind != 'l' || len(item.list) == 0 {
+			return fmt.Errorf("无法识别的认证响应")
+		}
+		switch item.list[0].text() {
+		case "success":
+			return nil
+		case "failure":
+			return fmt.Errorf("认证失败: %s", firstText(item.list[1:]))
+		case "step":
+			// CRAM-MD5 质询: ( step ( challenge ) )
+			challenge := firstText(item.list[1:])
+			mac := hmac.New(md5.New, []byte(s.pass))
+			mac.Write([]byte(challenge))
+			var rw raWriter
+			rw.str(s.user + " " + hex.EncodeToString(mac.Sum(nil)))
+			if err := s.write(&rw); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("无法识别的认证响应: %s", item.list[0].text())
+		}
+	}
+}
+
+// call 发送命令 ( name ( params ) ) 并读取响应参数
+func (s *raSession) call(name string, params *raWriter) ([]raItem, error) {
+	if err := s.send(name, params); err != nil {
+		return nil, err
+	}
+	return s.readResponse()
+}
+
+// send 发送命令并处理命令前的认证请求
+func (s *raSession) send(name string, params *raWriter) error {
+	s.conn.SetDeadline(time.Now().Add(raCallTimeout))
+
+	var w raWriter
+	w.open()
+	w.word(name)
+	w.open()
+	w.Write(params.Bytes())
+	w.close()
+	w.close()
+	if err := s.write(&w); err != nil {
+		return err
+	}
+	return s.auth()
+}
+
+func (s *raSession) write(w *raWriter) error {
+	if _, err := s.conn.Write(w.Bytes()); err != nil {
+		return fmt.Errorf("发送数据失败: %w", err)
+	}
+	return nil
+}
+
+// readResponse 读取 ( success ( params ) ) 或 ( failure ( ( apr-err message file line ) ... ) )
+func (s *raSession) readResponse() ([]raItem, error) {
+	item, err := s.readItem()
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(item)
+}
+
+func toResponse(item raItem) ([]raItem, error) {
+	if item.kind != 'l' || len(item.list) < 2 || item.list[1].kind != 'l' {
+		return nil, fmt.Errorf("无法识别的服务器响应")
+	}
+	switch item.list[0].text() {
+	case "success":
+		return item.list[1].list, nil
+	case "failure":
+		var msgs []string
+		for _, e := range item.list[1].list {
+			if len(e.list) >= 2 && e.list[1].text() != "" {
+				msgs = append(msgs, e.list[1].text())
+			}
+		}
+		if len(msgs) == 0 {
+			return nil, fmt.Errorf("服务器返回错误")
+		}
+		return nil, fmt.Errorf("服务器返回错误: %s", strings.Join(msgs, "; "))
+	default:
+		return nil, fmt.Errorf("无法识别的服务器响应: %s", item.list[0].text())
+	}
+}
+
+func firstText(items []raItem) string {
+	for _, it := range items {
+		if it.kind == 'l' {
+			return firstText(it.list)
+		}
+		return it.text()
+	}
+	return ""
+}
+
+// readItem 读取一个数据项
+func (s *raSession) readItem() (raItem, error) {
+	return s.readItemDepth(0)
+}
+
+func (s *raSession) readItemDepth(depth int) (raItem, error) {
+	c, err := s.skipSpace()
+	if err != nil {
+		return raItem{}, err
+	}
+
+	switch {
+	case c == '(':
+		if depth >= raMaxListDepth {
+			return raItem{}, fmt.Errorf("协议格式错误: 列表嵌套超过 %d 层", raMaxListDepth)
+		}
+		var it raItem
+		it.kind = 'l'
+		for {
+			c, err := s.skipSpace()
+			if err != nil {
+				return raItem{}, err
+			}
+			if c == ')' {
+				return it, nil
+			}
+			s.r.UnreadByte()
+			child, err := s.readItemDepth(depth + 1)
+			if err != nil {
+				return raItem{}, err
+			}
+			it.list = append(it.list, child)
+		}
+
+	case c >= '0' && c <= '9':
+		n := int(c - '0')
+		for {
+			c, err = s.r.ReadByte()
+			if err != nil {
+				return raItem{}, err
+			}
+			if c < '0' || c > '9' {
+				break
+			}
+			if n > (math.MaxInt32-9)/10 {
+				return raItem{}, fmt.Errorf("协议格式错误: 数字过大")
+			}
+			n = n*10 + int(c-'0')
+		}
+		if c != ':' {
+			s.r.UnreadByte()
+			return raItem{kind: 'n', num: n}, nil
+		}
+		if n > raMaxStringLength {
+			return raItem{}, fmt.Errorf("协议格式错误: 字符串长度 %d 超过上限 %d", n, raMaxStringLength)
+		}
+		// 按实际收到的数据增长缓冲区，不按声明的长度预先分配
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, s.r, int64(n)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return raItem{}, err
+		}
+		return raItem{kind: 's', str: buf.String()}, nil
+
+	case isAlpha(c):
+		word := []byte{c}
+		for {
+			c, err = s.r.ReadByte()
+			if err != nil {
+				return raItem{}, err
+			}
+			if !isAlpha(c) && !(c >= '0' && c <= '9') && c != '-' {
+				s.r.UnreadByte()
+				break
+			}
+			if len(word) >= raMaxWordLength {
+				return raItem{}, fmt.Errorf("协议格式错误: 单词长度超过上限 %d", raMaxWordLength)
+			}
+			word = append(word, c)
+		}
+		return raItem{kind: 'w', str: string(word)}, nil
+
+	default:
+		return raItem{}, fmt.Errorf("协议格式错误: 意外的字符 %q", c)
+	}
+}
+
+func (s *raSession) skipSpace() (byte, error) {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+			return c, nil
+		}
+	}
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// latestRev get-latest-rev
+func (s *raSession) latestRev() (int, error) {
+	resp, err := s.call("get-latest-rev", &raWriter{})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp) == 0 {
+		return 0, fmt.Errorf("服务器没有返回最新版本号")
+	}
+	return resp[0].num, nil
+}
+
+// datedRev get-dated-rev，返回指定时间点的最新版本号
+func (s *raSession) datedRev(t time.Time) (int, error) {
+	var w raWriter
+	w.str(t.UTC().Format("2006-01-02T15:04:05.000000Z"))
+	resp, err := s.call("get-dated-rev", &w)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp) == 0 {
+		return 0, fmt.Errorf("服务器没有返回版本号")
+	}
+	return resp[0].num, nil
+}
+
+// checkPath check-path，返回 file、dir 或 none
+func (s *raSession) checkPath(path string, rev int) (string, error) {
+	var w raWriter
+	w.str(strings.TrimPrefix(path, "/"))
+	w.optRev(rev)
+	resp, err := s.call("check-path", &w)
+	if err != nil {
+		return "", err
+	}
+	if len(resp) == 0 {
+		return "", fmt.Errorf("服务器没有返回节点类型")
+	}
+	return resp[0].text(), nil
+}
+
+// log 获取日志，paths 为相对仓库根的路径，strict 为 true 时遇到复制停止（--stop-on-copy）
+func (s *raSession) log(paths []string, start, end, limit int, strict bool) ([]LogEntry, error) {
+	var w raWriter
+	w.open()
+	for _, p := range paths {
+		w.str(strings.TrimPrefix(p, "/"))
+	}
+	w.close()
+	w.open()
+	w.num(start)
+	w.close()
+	w.open()
+	w.num(end)
+	w.close()
+	w.boolean(true)   // changed-paths
+	w.boolean(strict) // strict-node
+	w.num(limit)
+	w.boolean(false) // include-merged-revisions
+	w.word("revprops")
+	w.open()
+	w.str("svn:author")
+	w.str("svn:date")
+	w.str("svn:log")
+	w.close()
+
+	if err := s.send("log", &w); err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+	for {
+		item, err := s.readItem()
+		if err != nil {
+			return nil, err
+		}
+		if item.isWord("done") {
+			break
+		}
+		if item.kind != 'l' || len(item.list) == 0 || item.list[0].kind != 'l' {
+			// 命令失败时服务器直接返回错误响应
+			_, err := toResponse(item)
+			if err == nil {
+				err = fmt.Errorf("无法识别的日志条目")
+			}
+			return nil, err
+		}
+		entries = append(entries, parseRaLogEntry(item.list))
+	}
+
+	if _, err := s.readResponse(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseRaLogEntry ( ( changed-path ... ) rev ( ? author ) ( ? date ) ( ? message ) ... )
+func parseRaLogEntry(fields []raItem) LogEntry {
+	var entry LogEntry
+	if len(fields) > 1 {
+		entry.Revision = fields[1].num
+	}
+	if len(fields) > 2 {
+		entry.Author = firstText(fields[2].list)
+	}
+	if len(fields) > 3 {
+		entry.Date = firstText(fields[3].list)
+	}
+	if len(fields) > 4 {
+		entry.Message = firstText(fields[4].list)
+	}
+
+	// ( path action ( ? copy-path copy-rev ) ( ? ( ? kind ? text-mods prop-mods ) ) )
+	for _, cp := range fields[0].list {
+		if len(cp.list) < 2 {
+			continue
+		}
+		p := LogPath{
+			Path:   cp.list[0].text(),
+			Action: cp.list[1].text(),
+		}
+		if len(cp.list) > 2 && len(cp.list[2].list) >= 2 {
+			p.CopyFromPath = cp.list[2].list[0].text()
+			p.CopyFromRev = cp.list[2].list[1].num
+		}
+		if len(cp.list) > 3 {
+			extra := cp.list[3].list
+			if len(extra) > 0 {
+				p.Kind = extra[0].text()
+			}
+			if len(extra) > 2 {
+				p.TextMods = extra[1].isWord("true")
+				p.PropMods = extra[2].isWord("true")
+			}
+		}
+		entry.Paths = append(entry.Paths, p)
+	}
+	return entry
+}
+
+// getFile get-file，返回文件内容和属性
+func (s *raSession) getFile(path string, rev int) (string, map[string]string, error) {
+	return s.fetchFile(path, rev, true)
+}
+
+// fileProps get-file 只获取属性，不下载文件内容
+func (s *raSession) fileProps(path string, rev int) (map[string]string, error) {
+	_, props, err := s.fetchFile(path, rev, false)
+	return props, err
+}
+
+// propList 解析属性列表 ( ( name:string value:string ) ... )
+func propList(item raItem) map[string]string {
+	props := map[string]string{}
+	for _, p := range item.list {
+		if len(p.list) >= 2 {
+			props[p.list[0].text()] = p.list[1].text()
+		}
+	}
+	return props
+}
+
+func (s *raSession) fetchFile(path string, rev int, wantContents bool) (string, map[string]string, error) {
+	var w raWriter
+	w.str(strings.TrimPrefix(path, "/"))
+	w.optRev(rev)
+	w.boolean(true)         // want-props
+	w.boolean(wantContents) // want-contents
+
+	// ( success ( ( ? checksum ) rev props ) ) 之后是若干字符串，以空字符串结束
+	resp, err := s.call("get-file", &w)
+	if err != nil {
+		return "", nil, err
+	}
+	props := map[string]string{}
+	if len(resp) > 2 {
+		props = propList(resp[2])
+	}
+
+	var content strings.Builder
+	for wantContents {
+		item, err := s.readItem()
+		if err != nil {
+			return "", nil, err
+		}
+		if item.kind != 's' {
+			return "", nil, fmt.Errorf("读取文件内容失败: 协议格式错误")
+		}
+		if item.str == "" {
+			break
+		}
+		content.WriteString(item.str)
+	}
+
+	// 只有发送了文件内容时，服务器才会在内容之后再返回一次结果
+	if wantContents {
+		if _, err := s.readResponse(); err != nil {
+			return "", nil, err
+		}
+	}
+	return content.String(), props, nil
+}
@@ -0,0 +1,336 @@
+package svn
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// raStr 按协议格式编码字符串
+func raStr(s string) string {
+	return fmt.Sprintf("%d:%s", len(s), s)
+}
+
+// fakeSvnserve 按脚本与客户端对话的 svnserve
+type fakeSvnserve struct {
+	t    *testing.T
+	conn net.Conn
+	in   *raSession // 用客户端的解析器读取客户端发送的数据
+}
+
+func newFakeSvnserve(t *testing.T) (*raSession, *fakeSvnserve) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	deadline := time.Now().Add(10 * time.Second)
+	clientConn.SetDeadline(deadline)
+	serverConn.SetDeadline(deadline)
+
+	client := &raSession{conn: clientConn, r: bufio.NewReader(clientConn), user: "reviewer", pass: "s3cret"}
+	server := &fakeSvnserve{t: t, conn: serverConn, in: &raSession{conn: serverConn, r: bufio.NewReader(serverConn)}}
+	return client, server
+}
+
+func (f *fakeSvnserve) send(data string) error {
+	_, err := f.conn.Write([]byte(data + " "))
+	return err
+}
+
+func (f *fakeSvnserve) read() (raItem, error) {
+	return f.in.readItem()
+}
+
+// expectCommand 读取一条命令并检查命令名称，返回参数
+func (f *fakeSvnserve) expectCommand(name string) ([]raItem, error) {
+	item, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	if item.kind != 'l' || len(item.list) != 2 || !item.list[0].isWord(name) {
+		return nil, fmt.Errorf("expected command %s, got %+v", name, item)
+	}
+	return item.list[1].list, nil
+}
+
+// noAuth 命令前的认证请求：不需要认证
+func (f *fakeSvnserve) noAuth() error {
+	return f.send("( success ( ( ) " + raStr("") + " ) )")
+}
+
+// serve 在后台运行脚本，脚本的错误在测试结束前报告
+func (f *fakeSvnserve) serve(script func() error) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		err := script()
+		if err != nil {
+			f.conn.Close()
+		}
+		done <- err
+	}()
+	return done
+}
+
+func wait(t *testing.T, done <-chan error) {
+	t.Helper()
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+// handshakeScript 问候、CRAM-MD5 认证、打开仓库，会话地址在仓库根之下时处理 reparent
+func (f *fakeSvnserve) handshakeScript(root string, reparent bool) error {
+	if err := f.send("( success ( 2 2 ( ) ( edit-pipeline svndiff1 absent-entries depth mergeinfo log-revprops ) ) )"); err != nil {
+		return err
+	}
+	greeting, err := f.read()
+	if err != nil {
+		return err
+	}
+	if len(greeting.list) < 4 || greeting.list[0].num != 2 {
+		return fmt.Errorf("bad client greeting %+v", greeting)
+	}
+
+	if err := f.send("( success ( ( CRAM-MD5 ANONYMOUS ) " + raStr("<svn://svn.example.com:3690> repo") + " ) )"); err != nil {
+		return err
+	}
+	mech, err := f.read()
+	if err != nil {
+		return err
+	}
+	if len(mech.list) == 0 || !mech.list[0].isWord("CRAM-MD5") {
+		return fmt.Errorf("expected CRAM-MD5, got %+v", mech)
+	}
+	challenge := "<1234.5678@svn.example.com>"
+	if err := f.send("( step ( " + raStr(challenge) + " ) )"); err != nil {
+		return err
+	}
+	answer, err := f.read()
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(md5.New, []byte("s3cret"))
+	mac.Write([]byte(challenge))
+	if want := "reviewer " + hex.EncodeToString(mac.Sum(nil)); answer.str != want {
+		return fmt.Errorf("CRAM-MD5 answer = %q, want %q", answer.str, want)
+	}
+	if err := f.send("( success ( ) )"); err != nil {
+		return err
+	}
+
+	if err := f.send("( success ( " + raStr("0a1b2c3d-uuid") + " " + raStr(root) + " ( mergeinfo ) ) )"); err != nil {
+		return err
+	}
+	if !reparent {
+		return nil
+	}
+	params, err := f.expectCommand("reparent")
+	if err != nil {
+		return err
+	}
+	if len(params) != 1 || params[0].str != root {
+		return fmt.Errorf("reparent to %+v, want %s", params, root)
+	}
+	if err := f.noAuth(); err != nil {
+		return err
+	}
+	return f.send("( success ( ) )")
+}
+
+func TestRaSvnHandshake(t *testing.T) {
+	client, server := newFakeSvnserve(t)
+	done := server.serve(func() error {
+		return server.handshakeScript("svn://svn.example.com/repo", true)
+	})
+
+	if err := client.handshake("svn://svn.example.com/repo/trunk"); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, done)
+	if client.uuid != "0a1b2c3d-uuid" || client.root != "svn://svn.example.com/repo" || client.prefix != "trunk" {
+		t.Errorf("session = uuid %q root %q prefix %q", client.uuid, client.root, client.prefix)
+	}
+}
+
+func TestRaSvnAuthFailure(t *testing.T) {
+	client, server := newFakeSvnserve(t)
+	done := server.serve(func() error {
+		if err := server.send("( success ( 2 2 ( ) ( ) ) )"); err != nil {
+			return err
+		}
+		if _, err := server.read(); err != nil {
+			return err
+		}
+		if err := server.send("( success ( ( PLAIN ) " + raStr("realm") + " ) )"); err != nil {
+			return err
+		}
+		mech, err := server.read()
+		if err != nil {
+			return err
+		}
+		if len(mech.list) < 2 || !mech.list[0].isWord("PLAIN") || firstText(mech.list[1:]) != "\x00reviewer\x00s3cret" {
+			return fmt.Errorf("bad PLAIN auth %+v", mech)
+		}
+		return server.send("( failure ( " + raStr("Username not found") + " ) )")
+	})
+
+	err := client.handshake("svn://svn.example.com/repo")
+	if err == nil || !strings.Contains(err.Error(), "Username not found") {
+		t.Fatalf("err = %v, want auth failure", err)
+	}
+	wait(t, done)
+}
+
+func TestRaSvnCommands(t *testing.T) {
+	client, server := newFakeSvnserve(t)
+	done := server.serve(func() error {
+		if err := server.handshakeScript("svn://svn.example.com/repo", false); err != nil {
+			return err
+		}
+
+		// get-latest-rev
+		if _, err := server.expectCommand("get-latest-rev"); err != nil {
+			return err
+		}
+		if err := server.noAuth(); err != nil {
+			return err
+		}
+		if err := server.send("( success ( 1042 ) )"); err != nil {
+			return err
+		}
+
+		// log
+		params, err := server.expectCommand("log")
+		if err != nil {
+			return err
+		}
+		if len(params) < 3 || firstText(params[0].list) != "trunk/src" || params[1].list[0].num != 1042 || params[2].list[0].num != 1 {
+			return fmt.Errorf("bad log params %+v", params)
+		}
+		if err := server.noAuth(); err != nil {
+			return err
+		}
+		entry := "( ( ( " + raStr("/trunk/src/a.go") + " M ( ) ( " + raStr("file") + " true false ) ) " +
+			"( " + raStr("/trunk/src/b.go") + " A ( " + raStr("/trunk/src/old.go") + " 1040 ) ( " + raStr("file") + " false false ) ) ) " +
+			"1042 ( " + raStr("zhang.san") + " ) ( " + raStr("2024-03-18T08:15:42.123456Z") + " ) ( " + raStr("修复 (括号) 问题") + " ) )"
+		if err := server.send(entry); err != nil {
+			return err
+		}
+		if err := server.send("done ( success ( ) )"); err != nil {
+			return err
+		}
+
+		// get-file：内容分多块发送，以空字符串结束
+		params, err = server.expectCommand("get-file")
+		if err != nil {
+			return err
+		}
+		if len(params) < 4 || params[0].str != "trunk/src/a.go" || params[1].list[0].num != 1042 || !params[3].isWord("true") {
+			return fmt.Errorf("bad get-file params %+v", params)
+		}
+		if err := server.noAuth(); err != nil {
+			return err
+		}
+		if err := server.send("( success ( ( " + raStr("d41d8cd98f00b204e9800998ecf8427e") + " ) 1042 ( ( " + raStr("svn:mime-type") + " " + raStr("text/plain") + " ) ) ) )"); err != nil {
+			return err
+		}
+		if err := server.send(raStr("package main\n") + " " + raStr("func main() {}\n") + " " + raStr("")); err != nil {
+			return err
+		}
+		if err := server.send("( success ( ) )"); err != nil {
+			return err
+		}
+
+		// 命令失败
+		if _, err := server.expectCommand("check-path"); err != nil {
+			return err
+		}
+		if err := server.noAuth(); err != nil {
+			return err
+		}
+		return server.send("( failure ( ( 160013 " + raStr("path not found") + " " + raStr("") + " 0 ) ) )")
+	})
+
+	if err := client.handshake("svn://svn.example.com/repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rev, err := client.latestRev()
+	if err != nil || rev != 1042 {
+		t.Fatalf("latestRev = %d, %v", rev, err)
+	}
+
+	entries, err := client.log([]string{"/trunk/src"}, 1042, 1, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("len(entries) = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Revision != 1042 || e.Author != "zhang.san" || e.Message != "修复 (括号) 问题" || len(e.Paths) != 2 {
+		t.Fatalf("entry = %+v", e)
+	}
+	if p := e.Paths[0]; p != (LogPath{Action: "M", Path: "/trunk/src/a.go", Kind: "file", TextMods: true}) {
+		t.Errorf("Paths[0] = %+v", p)
+	}
+	if p := e.Paths[1]; p != (LogPath{Action: "A", Path: "/trunk/src/b.go", Kind: "file", CopyFromPath: "/trunk/src/old.go", CopyFromRev: 1040}) {
+		t.Errorf("Paths[1] = %+v", p)
+	}
+
+	content, props, err := client.getFile("/trunk/src/a.go", 1042)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "package main\nfunc main() {}\n" || props["svn:mime-type"] != "text/plain" {
+		t.Errorf("getFile = %q, %v", content, props)
+	}
+
+	_, err = client.checkPath("/trunk/missing", 1042)
+	if err == nil || !strings.Contains(err.Error(), "path not found") {
+		t.Errorf("checkPath err = %v, want path not found", err)
+	}
+	wait(t, done)
+}
+
+func TestRaSvnReadItemLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"string too long", fmt.Sprintf("%d:abc", raMaxStringLength+1), "字符串长度"},
+		{"number overflow", "99999999999999999999999 ", "数字过大"},
+		{"word too long", strings.Repeat("a", raMaxWordLength+1) + " ", "单词长度"},
+		{"nesting too deep", strings.Repeat("( ", raMaxListDepth+1), "嵌套"},
+		{"truncated string", "10:abc", "unexpected EOF"},
+		{"bad character", "#", "意外的字符"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &raSession{r: bufio.NewReader(strings.NewReader(tt.data))}
+			_, err := s.readItem()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// 上限以内的数据正常解析
+	s := &raSession{r: bufio.NewReader(strings.NewReader("( word 12 5:hello ( ) ) "))}
+	item, err := s.readItem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.list) != 4 || !item.list[0].isWord("word") || item.list[1].num != 12 || item.list[2].str != "hello" || item.list[3].kind != 'l' {
+		t.Errorf("item = %+v", item)
+	}
+}
@@ -7,13 +7,61 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"svn-ai-reviewer/internal/charset"
 )
 
 type FileChange struct {
 	Path     string
-	Status   string // A=新增, M=修改, D=删除
+	Status   string // A=新增, M=修改, D=删除, R=替换, C=冲突, !=缺失, ~=阻塞, ?=未受控
 	Diff     string
 	Revision int    // 版本号（在线模式使用）
+
+	Action       Action // 变更类型
+	NodeKind     string // file 或 dir，未知时为空
+	TextMods     bool   // 内容是否有修改
+	PropMods     bool   // 属性是否有修改
+	CopyFromPath string // 复制/重命名的来源路径
+	CopyFromRev  int    // 复制/重命名的来源版本
+	Encoding     string // 读取内容时检测到的原始编码（如 GB18030），转换为 UTF-8 后再审核和显示
+
+	// 以下字段仅本地模式（svn status）使用
+	Changelist     string // 所属变更列表
+	Conflicted     bool   // 内容、属性或树冲突
+	TreeConflicted bool   // 树冲突
+}
+
+// Action 文件变更类型
+type Action string
+
+const (
+	ActionAdded       Action = "added"
+	ActionModified    Action = "modified"
+	ActionDeleted     Action = "deleted"
+	ActionReplaced    Action = "replaced"
+	ActionUnversioned Action = "unversioned"
+	ActionConflicted  Action = "conflicted"
+	ActionMissing     Action = "missing"
+	ActionObstructed  Action = "obstructed"
+)
+
+// actionFromCode 将 svn log 的单字母动作转换为 Action
+func actionFromCode(code string) Action {
+	switch code {
+	case "A":
+		return ActionAdded
+	case "M":
+		return ActionModified
+	case "D":
+		return ActionDeleted
+	case "R":
+		return ActionReplaced
+	case "?":
+		return ActionUnversioned
+	default:
+		return Action(code)
+	}
 }
 
 type LogEntry struct {
@@ -21,7 +69,32 @@ type LogEntry struct {
 	Author   string
 	Date     string
 	Message  string
-	Paths    []string
+	Paths    []LogPath
+}
+
+// LogPath svn log --verbose 中的一条变更路径
+type LogPath struct {
+	Action       string // A, M, D, R
+	Path         string // 相对仓库根的路径
+	Kind         string // file, dir
+	TextMods     bool
+	PropMods     bool
+	CopyFromPath string
+	CopyFromRev  int
+}
+
+// Info svn info 的结果
+type Info struct {
+	Path           string
+	Kind           string
+	Revision       int
+	URL            string
+	RelativeURL    string
+	RootURL        string
+	UUID           string
+	LastChangedRev int
+	LastAuthor     string
+	LastDate       string
 }
 
 type Client struct {
@@ -30,6 +103,14 @@ type Client struct {
 	url      string
 	username string
 	password string
+	rootURL  string  // 仓库根地址（按需获取后缓存）
+	backend  Backend // 在线模式使用的后端，默认为 svn 命令行
+
+	configDir string   // svn 配置目录，为空时使用默认目录
+	auth      *cliAuth // 命令行认证参数（按需创建后缓存）
+	logs      logCache  // 已获取的日志页
+	store     *logStore // 本地日志缓存，为空表示未启用
+	encoding  string    // 内容不是 UTF-8 且 svn:mime-type 未指定 charset 时优先尝试的编码
 }
 
 func NewClient(command, workDir string) *Client {
@@ -49,112 +130,184 @@ func NewOnlineClient(command, url, username, password string) *Client {
 }
 
 // GetChangedFiles 获取所有变更的文件（包括未受控文件）
-func (c *Client) GetChangedFiles(ignorePatterns []string) ([]FileChange, error) {
-	// 执行 svn status，包括未受控文件
-	cmd := exec.Command(c.command, "status")
-	cmd.Dir = c.workDir
-	
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("执行 svn status 失败: %w", err)
+// 基于 svn status --xml，可以正确处理带空格的路径、替换、冲突、缺失和仅属性修改的条目
+// 指定 changelists 时只返回属于这些变更列表（svn changelist）的文件
+func (c *Client) GetChangedFiles(ignorePatterns []string, changelists ...string) ([]FileChange, error) {
+	doc, err := c.status(changelists)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := c.IgnoreMatcher(ignorePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("读取忽略规则失败: %w", err)
 	}
 
 	var changes []FileChange
-	lines := strings.Split(out.String(), "\n")
-	
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// SVN status 格式: 状态码 文件路径
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
-			continue
-		}
+	collect := func(entries []statusEntryXML, changelist string) {
+		for _, entry := range entries {
+			change, ok := statusToChange(entry)
+			if !ok {
+				continue
+			}
+			change.Changelist = changelist
 
-		status := parts[0]
-		path := parts[len(parts)-1]
+			isDir := false
+			if fileInfo, err := os.Stat(filepath.Join(c.workDir, change.Path)); err == nil {
+				isDir = fileInfo.IsDir()
+			}
 
-		// 处理 A(新增), M(修改), D(删除), ?(未受控) 状态
-		if status == "A" || status == "M" || status == "D" || status == "?" {
-			// 检查是否应该忽略
-			if shouldIgnore(path, ignorePatterns) {
+			// 检查是否应该忽略，svn:ignore 等属性只作用于未受控文件
+			ignored := matcher.MatchVersioned(change.Path, isDir)
+			if change.Status == "?" {
+				ignored = matcher.Match(change.Path, isDir)
+			}
+			if ignored {
 				continue
 			}
-			
-			// 检查是否是目录（对于非删除的文件）
-			if status != "D" {
-				fullPath := filepath.Join(c.workDir, path)
-				if fileInfo, err := os.Stat(fullPath); err == nil && fileInfo.IsDir() {
-					// 是目录，跳过
-					continue
-				}
+
+			// 跳过目录（冲突的目录仍然需要报告）
+			if isDir && change.Status != "D" && change.Status != "!" && !change.Conflicted {
+				continue
 			}
-			
-			changes = append(changes, FileChange{
-				Path:   path,
-				Status: status,
-			})
+
+			changes = append(changes, change)
 		}
 	}
 
+	for _, target := range doc.Targets {
+		collect(target.Entries, "")
+	}
+	for _, cl := range doc.Changelists {
+		collect(cl.Entries, cl.Name)
+	}
+
 	return changes, nil
 }
 
-// shouldIgnore 检查文件路径是否匹配忽略模式
-func shouldIgnore(path string, patterns []string) bool {
-	for _, pattern := range patterns {
-		// 简单的通配符匹配
-		if matchPattern(path, pattern) {
-			return true
-		}
+// ListChangelists 返回工作副本中所有变更列表的名称
+func (c *Client) ListChangelists() ([]string, error) {
+	doc, err := c.status(nil)
+	if err != nil {
+		return nil, err
 	}
-	return false
-}
 
-// matchPattern 简单的通配符匹配（支持 * 和目录匹配）
-func matchPattern(path, pattern string) bool {
-	// 精确匹配
-	if path == pattern {
-		return true
+	names := make([]string, 0, len(doc.Changelists))
+	for _, cl := range doc.Changelists {
+		names = append(names, cl.Name)
 	}
-	
-	// 目录匹配（如果模式以 / 结尾）
-	if strings.HasSuffix(pattern, "/") {
-		if strings.HasPrefix(path, pattern) || strings.HasPrefix(path, strings.TrimSuffix(pattern, "/")) {
-			return true
+	return names, nil
+}
+
+// status 执行 svn status --xml，可按变更列表过滤
+func (c *Client) status(changelists []string) (*statusXML, error) {
+	args := []string{"status", "--xml"}
+	for _, name := range changelists {
+		if name = strings.TrimSpace(name); name != "" {
+			args = append(args, "--changelist", name)
 		}
 	}
-	
-	// 通配符匹配
-	if strings.Contains(pattern, "*") {
-		// 简单实现：将 * 替换为正则表达式
-		regexPattern := strings.ReplaceAll(pattern, "*", ".*")
-		regexPattern = "^" + regexPattern + "$"
-		matched, _ := filepath.Match(pattern, filepath.Base(path))
-		if matched {
-			return true
-		}
-		// 检查完整路径
-		if strings.Contains(path, strings.ReplaceAll(pattern, "*", "")) {
-			return true
+
+	cmd := exec.Command(c.command, args...)
+	cmd.Dir = c.workDir
+
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("执行 svn status 失败: %w, 错误信息: %s", err, errOut.String())
+	}
+
+	return parseStatus(out.Bytes())
+}
+
+// statusToChange 将 svn status 的条目转换为 FileChange，不需要审核的条目返回 false
+func statusToChange(entry statusEntryXML) (FileChange, bool) {
+	wc := entry.WCStatus
+	change := FileChange{
+		Path:           entry.Path,
+		Action:         Action(wc.Item),
+		TextMods:       wc.Item == "modified" || wc.Item == "added" || wc.Item == "replaced",
+		PropMods:       wc.Props == "modified",
+		TreeConflicted: wc.TreeConflicted,
+		Conflicted:     wc.Item == "conflicted" || wc.Props == "conflicted" || wc.TreeConflicted,
+	}
+	if wc.MovedFrom != "" {
+		change.CopyFromPath = wc.MovedFrom
+	}
+
+	switch wc.Item {
+	case "added":
+		change.Status = "A"
+	case "modified":
+		change.Status = "M"
+	case "deleted":
+		change.Status = "D"
+	case "replaced":
+		change.Status = "R"
+	case "conflicted":
+		change.Status = "C"
+	case "missing":
+		change.Status = "!"
+	case "obstructed":
+		change.Status = "~"
+	case "unversioned":
+		change.Status = "?"
+	case "normal", "none":
+		// 内容未修改，但属性有修改或存在树冲突
+		switch {
+		case change.Conflicted:
+			change.Status = "C"
+			change.Action = ActionConflicted
+		case change.PropMods:
+			change.Status = "M"
+			change.Action = ActionModified
+		default:
+			return change, false
 		}
+	default:
+		// ignored, external, incomplete 等不需要审核
+		return change, false
 	}
-	
-	// 检查路径中是否包含该模式
-	if strings.Contains(path, pattern) {
-		return true
+
+	if change.Conflicted {
+		change.Status = "C"
 	}
-	
-	return false
+	return change, true
+}
+
+// Problem 返回工作副本条目无法审核的原因（冲突、缺失、被阻塞），正常条目返回 nil
+func (f FileChange) Problem() error {
+	switch {
+	case f.TreeConflicted:
+		return fmt.Errorf("存在树冲突，请先解决冲突（svn resolve）再提交")
+	case f.Conflicted:
+		return fmt.Errorf("存在冲突，请先解决冲突（svn resolve）再提交")
+	case f.Status == "!":
+		return fmt.Errorf("文件缺失：已从磁盘删除但未执行 svn delete")
+	case f.Status == "~":
+		return fmt.Errorf("文件被阻塞：版本控制的对象类型与磁盘上的不一致")
+	}
+	return nil
+}
+
+// SetEncoding 设置默认编码（如 GBK）：内容不是 UTF-8、且 svn:mime-type 没有指定 charset 时按该编码转换
+// 为空时自动检测
+func (c *Client) SetEncoding(name string) {
+	c.encoding = name
+}
+
+// charsetHint 转换编码时使用的提示：svn:mime-type 中的 charset，没有时使用默认编码
+func (c *Client) charsetHint(mime string) string {
+	if cs := charset.FromMimeType(mime); cs != "" {
+		return cs
+	}
+	return c.encoding
 }
 
-// GetFileDiff 获取文件的差异内容
-func (c *Client) GetFileDiff(filePath string) (string, error) {
+// GetFileDiff 获取文件的差异内容，转换为 UTF-8 后返回，同时返回原始编码
+func (c *Client) GetFileDiff(filePath string) (string, string, error) {
 	absPath := filepath.Join(c.workDir, filePath)
 	
 	cmd := exec.Command(c.command, "diff", absPath)
@@ -165,509 +318,238 @@ func (c *Client) GetFileDiff(filePath string) (string, error) {
 	
 	// diff 命令在没有差异时返回非零退出码，这是正常的
 	_ = cmd.Run()
-	
-	return out.String(), nil
+
+	diff := out.String()
+	if utf8.ValidString(diff) {
+		return diff, charset.UTF8, nil
+	}
+	// 只有内容不是 UTF-8 时才读取 svn:mime-type 中的 charset
+	diff, enc := charset.DecodeDiff(diff, c.charsetHint(c.localMimeType(absPath)))
+	return diff, enc, nil
 }
 
-// GetFileContent 获取文件内容（用于新增文件和未受控文件）
-func (c *Client) GetFileContent(filePath string) (string, error) {
+// GetFileContent 获取文件内容（用于新增文件和未受控文件），转换为 UTF-8 后返回，同时返回原始编码
+// svn:mime-type 标记为二进制的文件返回与 svn diff 相同的二进制提示
+func (c *Client) GetFileContent(filePath string) (string, string, error) {
 	absPath := filepath.Join(c.workDir, filePath)
 	
 	// 检查文件信息
 	fileInfo, err := os.Stat(absPath)
 	if err != nil {
-		return "", fmt.Errorf("获取文件信息失败: %w", err)
+		return "", "", fmt.Errorf("获取文件信息失败: %w", err)
 	}
 	
 	// 检查是否是目录
 	if fileInfo.IsDir() {
-		return "", fmt.Errorf("路径是目录，不是文件")
+		return "", "", fmt.Errorf("路径是目录，不是文件")
 	}
 	
 	// 检查文件大小（限制 10MB）
 	const maxFileSize = 10 * 1024 * 1024
 	if fileInfo.Size() > maxFileSize {
-		return "", fmt.Errorf("文件过大 (%d 字节)，超过 10MB 限制", fileInfo.Size())
+		return "", "", fmt.Errorf("文件过大 (%d 字节)，超过 10MB 限制", fileInfo.Size())
+	}
+
+	mime := c.localMimeType(absPath)
+	if isBinaryMimeType(mime) {
+		return binaryNotice(mime), "", nil
 	}
 	
 	// 使用 Go 标准库读取文件，更可靠
 	content, err := os.ReadFile(absPath)
 	if err != nil {
-		return "", fmt.Errorf("读取文件失败: %w", err)
+		return "", "", fmt.Errorf("读取文件失败: %w", err)
 	}
-	
-	return string(content), nil
+
+	text, enc := charset.Decode(content, c.charsetHint(mime))
+	return text, enc, nil
 }
 
+// localMimeType 获取工作副本中文件的 svn:mime-type 属性
+// 未受控文件或获取失败时返回空字符串，由调用方按内容判断
+func (c *Client) localMimeType(absPath string) string {
+	cmd := exec.Command(c.command, "proplist", "--xml", "-v", absPath)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	props, err := parseProplist(out)
+	if err != nil {
+		return ""
+	}
+	return props["svn:mime-type"]
+}
 
 // TestConnection 测试SVN服务器连接
 func (c *Client) TestConnection() error {
-	args := []string{"info", c.url}
-	// 只有在提供了用户名时才添加认证参数
-	if c.username != "" {
-		args = append(args, "--username", c.username)
-		if c.password != "" {
-			args = append(args, "--password", c.password)
-		}
-		args = append(args, "--non-interactive")
-	}
-	
-	cmd := exec.Command(c.command, args...)
-	var out bytes.Buffer
-	var errOut bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errOut
-	
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("连接失败: %w, 错误信息: %s", err, errOut.String())
+	if _, err := c.online().Info(); err != nil {
+		return fmt.Errorf("连接失败: %w", err)
 	}
-	
 	return nil
 }
 
-// SearchLog 搜索SVN日志
-// keyword 参数用于搜索提交信息和作者
-// 返回日志条目列表和是否有更多记录的标志
-// 
-// 逻辑说明（类似 TortoiseSVN）：
-// 1. 每次从 SVN 获取 offset+limit 条记录（从头开始）
-// 2. 只对后面的 limit 条记录（即 offset 到 offset+limit）进行关键词过滤
-// 3. 返回过滤后的结果，由前端追加显示
-// 4. 多获取一条记录来判断是否还有更多数据
-func (c *Client) SearchLog(path, keyword string, limit, offset int) ([]LogEntry, bool, error) {
-	args := []string{"log", c.url}
-	
-	if path != "" && path != "/" {
-		args[1] = c.url + "/" + strings.TrimPrefix(path, "/")
-	}
-	
-	// 只有在提供了用户名时才添加认证信息
-	if c.username != "" {
-		args = append(args, "--username", c.username)
-		if c.password != "" {
-			args = append(args, "--password", c.password)
-		}
-		args = append(args, "--non-interactive")
-	}
-	
-	// 获取 offset+limit+1 条记录
-	// 多获取一条用于判断是否还有更多数据
-	fetchLimit := offset + limit + 1
-	
-	args = append(args, "--limit", fmt.Sprintf("%d", fetchLimit))
-	args = append(args, "--verbose")
-	args = append(args, "--xml")
-	
-	cmd := exec.Command(c.command, args...)
-	var out bytes.Buffer
-	var errOut bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errOut
-	
-	if err := cmd.Run(); err != nil {
-		return nil, false, fmt.Errorf("搜索日志失败: %w, 错误信息: %s", err, errOut.String())
-	}
-	
-	entries, err := parseLogXML(out.String())
+// GetRevisionDiff 获取指定版本的差异，转换为 UTF-8 后返回，同时返回原始编码
+// 如果指定了path（相对仓库根的路径），则只返回该文件的差异；否则返回整个版本的差异
+func (c *Client) GetRevisionDiff(revision int, path string) (string, string, error) {
+	diff, err := c.online().Diff(revision, path)
 	if err != nil {
-		return nil, false, fmt.Errorf("解析日志失败: %w", err)
-	}
-	
-	// 判断是否还有更多数据（在过滤之前）
-	hasMore := len(entries) > offset+limit
-	
-	// 只处理从 offset 开始的 limit 条记录
-	if offset >= len(entries) {
-		// 如果 offset 超出范围，返回空结果
-		return []LogEntry{}, false, nil
-	}
-	
-	// 提取需要处理的记录段
-	end := offset + limit
-	if end > len(entries) {
-		end = len(entries)
-	}
-	targetEntries := entries[offset:end]
-	
-	// 如果有关键词，只对这一段记录进行过滤
-	if keyword != "" {
-		var filtered []LogEntry
-		lowerKeyword := strings.ToLower(keyword)
-		for _, entry := range targetEntries {
-			if strings.Contains(strings.ToLower(entry.Message), lowerKeyword) || 
-			   strings.Contains(strings.ToLower(entry.Author), lowerKeyword) {
-				filtered = append(filtered, entry)
-			}
-		}
-		targetEntries = filtered
-	}
-	
-	return targetEntries, hasMore, nil
-}
-
-// GetRevisionDiff 获取指定版本的差异
-// 如果指定了path，则只返回该文件的差异；否则返回整个版本的差异
-func (c *Client) GetRevisionDiff(revision int, path string) (string, error) {
-	// 获取整个版本的diff
-	args := []string{"diff", "-c", fmt.Sprintf("%d", revision), c.url}
-	
-	// 只有在提供了用户名时才添加认证信息
-	if c.username != "" {
-		args = append(args, "--username", c.username)
-		if c.password != "" {
-			args = append(args, "--password", c.password)
-		}
-		args = append(args, "--non-interactive")
+		return "", "", err
 	}
-	
-	cmd := exec.Command(c.command, args...)
-	var out bytes.Buffer
-	var errOut bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errOut
-	
-	if err := cmd.Run(); err != nil {
-		// diff命令在有差异时可能返回非零，这是正常的
-		if out.Len() > 0 {
-			fullDiff := out.String()
-			// 如果指定了文件路径，提取该文件的diff
-			if path != "" {
-				extracted := extractFileDiff(fullDiff, path)
-				// 调试：如果提取失败，打印信息
-				if extracted == "" {
-					fmt.Printf("[调试] 未能从diff中提取文件: %s\n", path)
-					fmt.Printf("[调试] 完整diff前200字符: %s\n", fullDiff[:min(200, len(fullDiff))])
-				}
-				return extracted, nil
-			}
-			return fullDiff, nil
-		}
-		return "", fmt.Errorf("获取版本差异失败: %w, 错误信息: %s", err, errOut.String())
+	if utf8.ValidString(diff) {
+		return diff, charset.UTF8, nil
 	}
-	
-	fullDiff := out.String()
-	// 如果指定了文件路径，提取该文件的diff
+	// 只有内容不是 UTF-8 时才读取 svn:mime-type 中的 charset
+	hint := c.encoding
 	if path != "" {
-		extracted := extractFileDiff(fullDiff, path)
-		// 调试：如果提取失败，打印信息
-		if extracted == "" && fullDiff != "" {
-			fmt.Printf("[调试] 未能从diff中提取文件: %s\n", path)
-			fmt.Printf("[调试] 完整diff前200字符: %s\n", fullDiff[:min(200, len(fullDiff))])
+		if mime, err := c.online().MimeType(path, revision); err == nil {
+			hint = c.charsetHint(mime)
 		}
-		return extracted, nil
-	}
-	return fullDiff, nil
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
 	}
-	return b
+	diff, enc := charset.DecodeDiff(diff, hint)
+	return diff, enc, nil
 }
 
 // GetRevisionFiles 获取指定版本修改的文件列表
 func (c *Client) GetRevisionFiles(revision int) ([]FileChange, error) {
-	args := []string{"log", c.url, "-r", fmt.Sprintf("%d", revision), "--verbose", "--xml"}
-	
-	// 只有在提供了用户名时才添加认证信息
-	if c.username != "" {
-		args = append(args, "--username", c.username)
-		if c.password != "" {
-			args = append(args, "--password", c.password)
-		}
-		args = append(args, "--non-interactive")
-	}
-	
-	cmd := exec.Command(c.command, args...)
-	var out bytes.Buffer
-	var errOut bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errOut
-	
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("获取版本文件列表失败: %w, 错误信息: %s", err, errOut.String())
-	}
-	
-	entries, err := parseLogXML(out.String())
+	entry, err := c.revisionEntry(revision)
 	if err != nil {
-		return nil, fmt.Errorf("解析日志失败: %w", err)
-	}
-	
-	if len(entries) == 0 {
-		return nil, fmt.Errorf("未找到版本 %d", revision)
+		return nil, err
 	}
 	
 	var changes []FileChange
-	for _, path := range entries[0].Paths {
-		// 解析路径格式: "A /path/to/file" 或 "M /path/to/file"
-		parts := strings.Fields(path)
-		if len(parts) >= 2 {
-			filePath := parts[1]
-			// 过滤目录：SVN中目录路径通常以 / 结尾
-			if strings.HasSuffix(filePath, "/") {
-				continue
-			}
-			
-			changes = append(changes, FileChange{
-				Path:     filePath,
-				Status:   parts[0],
-				Revision: revision,
-			})
+	for _, p := range entry.Paths {
+		// 过滤目录
+		if p.Kind == "dir" {
+			continue
 		}
+
+		changes = append(changes, FileChange{
+			Path:         p.Path,
+			Status:       p.Action,
+			Revision:     revision,
+			Action:       actionFromCode(p.Action),
+			NodeKind:     p.Kind,
+			TextMods:     p.TextMods,
+			PropMods:     p.PropMods,
+			CopyFromPath: p.CopyFromPath,
+			CopyFromRev:  p.CopyFromRev,
+		})
 	}
 	
 	return changes, nil
 }
 
-// parseLogXML 解析SVN log的XML输出
-func parseLogXML(xmlData string) ([]LogEntry, error) {
-	// 简单的XML解析实现
-	var entries []LogEntry
-	
-	// 按<logentry>标签分割
-	logEntries := strings.Split(xmlData, "<logentry")
-	
-	for _, entryStr := range logEntries[1:] { // 跳过第一个空元素
-		var entry LogEntry
-		
-		// 提取revision
-		if revMatch := extractXMLAttr(entryStr, "revision"); revMatch != "" {
-			fmt.Sscanf(revMatch, "%d", &entry.Revision)
-		}
-		
-		// 提取author
-		entry.Author = extractXMLTag(entryStr, "author")
-		
-		// 提取date
-		entry.Date = extractXMLTag(entryStr, "date")
-		
-		// 提取message
-		entry.Message = extractXMLTag(entryStr, "msg")
-		
-		// 提取paths
-		pathsSection := extractXMLSection(entryStr, "paths")
-		pathEntries := strings.Split(pathsSection, "<path")
-		for _, pathStr := range pathEntries[1:] {
-			action := extractXMLAttr(pathStr, "action")
-			path := extractXMLTagContent(pathStr, "path")
-			if path != "" {
-				entry.Paths = append(entry.Paths, action+" "+path)
-			}
-		}
-		
-		entries = append(entries, entry)
+// Info 获取目标的信息，target 为空时获取服务器地址对应的仓库信息
+// 指定 target（工作副本路径或其他 URL）时总是使用 svn 命令行
+func (c *Client) Info(target string) (*Info, error) {
+	if target == "" {
+		return c.online().Info()
 	}
-	
-	return entries, nil
-}
 
-func extractXMLAttr(str, attrName string) string {
-	start := strings.Index(str, attrName+"=\"")
-	if start == -1 {
-		return ""
-	}
-	start += len(attrName) + 2
-	end := strings.Index(str[start:], "\"")
-	if end == -1 {
-		return ""
-	}
-	return str[start : start+end]
+	cli := c.cli()
+	cli.url = target
+	return cli.Info()
 }
 
-func extractXMLTag(str, tagName string) string {
-	startTag := "<" + tagName + ">"
-	endTag := "</" + tagName + ">"
-	start := strings.Index(str, startTag)
-	if start == -1 {
-		return ""
-	}
-	start += len(startTag)
-	end := strings.Index(str[start:], endTag)
-	if end == -1 {
-		return ""
+// revisionEntry 获取指定版本的日志，优先使用本地日志缓存
+func (c *Client) revisionEntry(revision int) (LogEntry, error) {
+	if c.store != nil {
+		if entry, ok := c.store.entry(revision); ok {
+			return entry, nil
+		}
 	}
-	return strings.TrimSpace(str[start : start+end])
-}
 
-func extractXMLSection(str, tagName string) string {
-	startTag := "<" + tagName + ">"
-	endTag := "</" + tagName + ">"
-	start := strings.Index(str, startTag)
-	if start == -1 {
-		return ""
+	entries, err := c.online().Log(LogRequest{StartRev: revision, EndRev: revision})
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("获取版本文件列表失败: %w", err)
 	}
-	end := strings.Index(str[start:], endTag)
-	if end == -1 {
-		return ""
+	if len(entries) == 0 {
+		return LogEntry{}, fmt.Errorf("未找到版本 %d", revision)
 	}
-	return str[start : start+end+len(endTag)]
+	return entries[0], nil
 }
 
-func extractXMLTagContent(str, tagName string) string {
-	start := strings.Index(str, ">")
-	if start == -1 {
-		return ""
+// RepositoryRoot 获取仓库根地址
+// svn log 返回的路径是相对仓库根的（如 /trunk/src/a.go），拼接文件 URL 时需要用到
+func (c *Client) RepositoryRoot() (string, error) {
+	if c.rootURL != "" {
+		return c.rootURL, nil
 	}
-	start++
-	end := strings.Index(str[start:], "</"+tagName)
-	if end == -1 {
-		return ""
+
+	info, err := c.Info("")
+	if err != nil {
+		return "", fmt.Errorf("获取仓库根地址失败: %w", err)
 	}
-	return strings.TrimSpace(str[start : start+end])
+
+	c.rootURL = strings.TrimSuffix(info.RootURL, "/")
+	return c.rootURL, nil
 }
 
+// Cat 获取指定版本的文件内容
+// path 为相对仓库根的路径（svn log 输出的格式）
+func (c *Client) Cat(revision int, path string) (string, error) {
+	return c.online().Cat(path, revision)
+}
 
-// extractFileDiff 从完整的diff中提取特定文件的diff
-func extractFileDiff(fullDiff, filePath string) string {
-	if fullDiff == "" {
-		return ""
+// GetFileContentAtRevision 获取文件在指定版本的完整内容（svn cat -r N ROOT/path@N），转换为 UTF-8 后返回，同时返回原始编码
+// path 为相对仓库根的路径
+// svn:mime-type 标记为二进制的文件不下载内容，返回与 svn diff 相同的二进制提示
+func (c *Client) GetFileContentAtRevision(revision int, path string) (string, string, error) {
+	mime, err := c.online().MimeType(path, revision)
+	if err != nil {
+		return "", "", err
 	}
-	
-	lines := strings.Split(fullDiff, "\n")
-	var result []string
-	inFile := false
-	
-	// 规范化文件路径（移除开头的斜杠）
-	targetPath := strings.TrimPrefix(filePath, "/")
-	
-	// 尝试多种匹配方式
-	// 1. 完整路径匹配
-	// 2. 文件名匹配（如果路径中包含文件名）
-	fileName := ""
-	if idx := strings.LastIndex(targetPath, "/"); idx >= 0 {
-		fileName = targetPath[idx+1:]
-	} else {
-		fileName = targetPath
+	if isBinaryMimeType(mime) {
+		return binaryNotice(mime), "", nil
 	}
-	
-	for i, line := range lines {
-		if strings.HasPrefix(line, "Index: ") {
-			// 提取Index行中的路径
-			indexPath := strings.TrimPrefix(line, "Index: ")
-			indexPath = strings.TrimSpace(indexPath)
-			
-			// 检查是否匹配（支持多种匹配方式）
-			matched := false
-			if strings.Contains(indexPath, targetPath) {
-				matched = true
-			} else if strings.HasSuffix(indexPath, fileName) {
-				matched = true
-			} else if strings.HasSuffix(indexPath, targetPath) {
-				matched = true
-			}
-			
-			if matched {
-				inFile = true
-				result = append(result, line)
-			} else {
-				inFile = false
-			}
-		} else if inFile {
-			result = append(result, line)
-			// 如果遇到下一个文件的Index标记，停止
-			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "Index: ") {
-				break
-			}
-		}
+	content, err := c.online().Cat(path, revision)
+	if err != nil {
+		return "", "", err
 	}
-	
-	return strings.Join(result, "\n")
+	text, enc := charset.Decode([]byte(content), c.charsetHint(mime))
+	return text, enc, nil
 }
 
-
-// GetFileContentAtRevision 获取指定版本的文件完整内容
-// 对于新增文件，从diff中提取内容更可靠
-func (c *Client) GetFileContentAtRevision(revision int, path string) (string, error) {
-	// 获取该版本的完整diff
-	fullDiff, err := c.GetRevisionDiff(revision, "")
-	if err != nil {
-		return "", fmt.Errorf("获取版本diff失败: %w", err)
-	}
-	
-	// 从diff中提取新增文件的内容
-	content := extractNewFileContent(fullDiff, path)
-	if content == "" {
-		return "", fmt.Errorf("未能从diff中提取文件内容")
-	}
-	
-	return content, nil
+// FileVersions 文件在某个版本修改前后的内容，用于并排对比
+type FileVersions struct {
+	OldPath     string // 修改前的路径，复制或重命名时为来源路径
+	OldRevision int    // 修改前的版本，0 表示修改前不存在（新增文件）
+	OldContent  string
+	OldEncoding string // 修改前内容的原始编码
+	NewPath     string
+	NewRevision int // 修改后的版本，0 表示该版本中已删除
+	NewContent  string
+	NewEncoding string // 修改后内容的原始编码
 }
 
-// extractNewFileContent 从diff中提取新增文件的完整内容
-func extractNewFileContent(fullDiff, filePath string) string {
-	if fullDiff == "" {
-		fmt.Printf("[调试] fullDiff为空\n")
-		return ""
-	}
-	
-	lines := strings.Split(fullDiff, "\n")
-	var result []string
-	inFile := false
-	inContent := false
-	
-	// 规范化文件路径
-	targetPath := strings.TrimPrefix(filePath, "/")
-	fileName := ""
-	if idx := strings.LastIndex(targetPath, "/"); idx >= 0 {
-		fileName = targetPath[idx+1:]
-	} else {
-		fileName = targetPath
-	}
-	
-	fmt.Printf("[调试] 提取新增文件内容: %s (文件名: %s)\n", targetPath, fileName)
-	
-	foundFile := false
-	for _, line := range lines {
-		if strings.HasPrefix(line, "Index: ") {
-			// 检查是否是目标文件
-			indexPath := strings.TrimPrefix(line, "Index: ")
-			indexPath = strings.TrimSpace(indexPath)
-			
-			matched := false
-			if strings.Contains(indexPath, targetPath) {
-				matched = true
-			} else if strings.HasSuffix(indexPath, fileName) {
-				matched = true
-			} else if strings.HasSuffix(indexPath, targetPath) {
-				matched = true
-			}
-			
-			if matched {
-				inFile = true
-				inContent = false
-				foundFile = true
-				fmt.Printf("[调试] 找到目标文件: %s\n", indexPath)
-			} else {
-				inFile = false
-				inContent = false
-			}
-		} else if inFile {
-			// 跳过diff头部信息，直到遇到 @@ 行
-			if strings.HasPrefix(line, "@@") {
-				inContent = true
-				fmt.Printf("[调试] 开始提取内容\n")
-				continue
-			}
-			
-			// 提取以 + 开头的行（新增内容）
-			if inContent && strings.HasPrefix(line, "+") {
-				// 移除开头的 +
-				content := strings.TrimPrefix(line, "+")
-				result = append(result, content)
-			}
+// GetFileVersions 获取变更文件修改前后的完整内容
+// 修改前的内容：复制或替换时取复制来源，修改和删除时取上一个版本，新增文件为空
+func (c *Client) GetFileVersions(change FileChange) (*FileVersions, error) {
+	v := &FileVersions{NewPath: change.Path}
+	switch {
+	case (change.Status == "A" || change.Status == "R") && change.CopyFromPath != "":
+		v.OldPath, v.OldRevision = change.CopyFromPath, change.CopyFromRev
+	case change.Status == "A":
+		// 新增的文件没有修改前的内容
+	default:
+		// 没有复制来源的替换（R）与修改相同，修改前的内容是同一路径的上一个版本
+		v.OldPath, v.OldRevision = change.Path, change.Revision-1
+	}
+	if change.Status != "D" {
+		v.NewRevision = change.Revision
+	}
+
+	var err error
+	if v.OldRevision > 0 {
+		if v.OldContent, v.OldEncoding, err = c.GetFileContentAtRevision(v.OldRevision, v.OldPath); err != nil {
+			return nil, fmt.Errorf("获取修改前的内容失败: %w", err)
 		}
 	}
-	
-	if !foundFile {
-		fmt.Printf("[调试] 未找到目标文件，diff前500字符:\n%s\n", fullDiff[:min(500, len(fullDiff))])
-	}
-	
-	if len(result) == 0 {
-		fmt.Printf("[调试] 未提取到任何内容\n")
-	} else {
-		fmt.Printf("[调试] 提取到 %d 行内容\n", len(result))
+	if v.NewRevision > 0 {
+		if v.NewContent, v.NewEncoding, err = c.GetFileContentAtRevision(v.NewRevision, v.NewPath); err != nil {
+			return nil, fmt.Errorf("获取修改后的内容失败: %w", err)
+		}
 	}
-	
-	return strings.Join(result, "\n")
+	return v, nil
 }
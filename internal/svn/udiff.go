@@ -0,0 +1,236 @@
+package svn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 原生后端没有 svn diff 可用，这里用 Myers 算法生成与 svn diff 相同格式的 unified diff
+
+const (
+	diffContextLines = 3
+	// maxDiffEdits 编辑距离超过该值时不再寻找最短路径，直接按整段替换输出
+	maxDiffEdits = 2000
+)
+
+type diffOp struct {
+	kind byte // ' ' 相同, '-' 删除, '+' 新增
+	line string
+}
+
+// splitLines 按行切分文本，末尾的换行不产生空行
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n")
+}
+
+// unifiedDiff 生成 old 到 new 的 hunk 部分（不含文件头），内容相同时返回空字符串
+func unifiedDiff(oldText, newText string) string {
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	// oldPos[i]/newPos[i] 为第 i 个操作之前旧/新文件已经经过的行数
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1] = oldPos[i]
+		newPos[i+1] = newPos[i]
+		if op.kind != '+' {
+			oldPos[i+1]++
+		}
+		if op.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i >= len(ops) {
+			break
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		// 两处修改之间的相同行不超过两倍上下文时合并为一个 hunk
+		end := i
+		j := i
+		for j < len(ops) {
+			if ops[j].kind != ' ' {
+				j++
+				end = j
+				continue
+			}
+			r := j
+			for r < len(ops) && ops[r].kind == ' ' {
+				r++
+			}
+			if r >= len(ops) || r-j > 2*diffContextLines {
+				break
+			}
+			j = r
+		}
+		stop := end + diffContextLines
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		oldStart, oldCount := oldPos[start]+1, oldPos[stop]-oldPos[start]
+		newStart, newCount := newPos[start]+1, newPos[stop]-newPos[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[start:stop] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+// diffLines 计算两组行之间的编辑序列
+func diffLines(a, b []string) []diffOp {
+	// 先去掉相同的前缀和后缀，减少计算量
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:pre] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myers Myers O(ND) 差异算法
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	offset := max
+	v := make([]int, 2*max+2)
+	// trace[d] 保存第 d 轮开始前 k ∈ [-d, d] 范围内的 v，用于回溯
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(a, b)
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+func backtrack(trace [][]int, a, b []string) []diffOp {
+	x, y := len(a), len(b)
+	var rev []diffOp
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		if d == 0 {
+			for x > 0 && y > 0 {
+				x--
+				y--
+				rev = append(rev, diffOp{' ', a[x]})
+			}
+			break
+		}
+
+		v := trace[d]
+		get := func(k int) int { return v[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, diffOp{' ', a[x]})
+		}
+		if x == prevX {
+			rev = append(rev, diffOp{'+', b[prevY]})
+		} else {
+			rev = append(rev, diffOp{'-', a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]diffOp, len(rev))
+	for i, op := range rev {
+		ops[len(rev)-1-i] = op
+	}
+	return ops
+}
+
+// replaceAll 将 a 整体删除、b 整体新增
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
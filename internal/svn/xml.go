@@ -0,0 +1,198 @@
+package svn
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// 本文件定义 svn 各命令 --xml 输出对应的结构体
+
+// svn log --xml [--verbose]
+type logXML struct {
+	XMLName xml.Name      `xml:"log"`
+	Entries []logEntryXML `xml:"logentry"`
+}
+
+type logEntryXML struct {
+	Revision int          `xml:"revision,attr"`
+	Author   string       `xml:"author"`
+	Date     string       `xml:"date"`
+	Msg      string       `xml:"msg"`
+	Paths    []logPathXML `xml:"paths>path"`
+}
+
+type logPathXML struct {
+	Action       string `xml:"action,attr"` // A, M, D, R
+	Kind         string `xml:"kind,attr"`   // file, dir（旧版本服务器可能为空）
+	TextMods     bool   `xml:"text-mods,attr"`
+	PropMods     bool   `xml:"prop-mods,attr"`
+	CopyFromPath string `xml:"copyfrom-path,attr"`
+	CopyFromRev  int    `xml:"copyfrom-rev,attr"`
+	Path         string `xml:",chardata"`
+}
+
+// svn status --xml
+type statusXML struct {
+	XMLName     xml.Name        `xml:"status"`
+	Targets     []statusTarget  `xml:"target"`
+	Changelists []changelistXML `xml:"changelist"`
+}
+
+type statusTarget struct {
+	Path    string           `xml:"path,attr"`
+	Entries []statusEntryXML `xml:"entry"`
+}
+
+type changelistXML struct {
+	Name    string           `xml:"name,attr"`
+	Entries []statusEntryXML `xml:"entry"`
+}
+
+type statusEntryXML struct {
+	Path     string      `xml:"path,attr"`
+	WCStatus wcStatusXML `xml:"wc-status"`
+}
+
+type wcStatusXML struct {
+	Item           string `xml:"item,attr"`  // added, modified, deleted, replaced, conflicted, missing, obstructed, unversioned ...
+	Props          string `xml:"props,attr"` // none, normal, modified, conflicted
+	Revision       int    `xml:"revision,attr"`
+	Copied         bool   `xml:"copied,attr"`
+	Switched       bool   `xml:"switched,attr"`
+	TreeConflicted bool   `xml:"tree-conflicted,attr"`
+	MovedFrom      string `xml:"moved-from,attr"`
+	MovedTo        string `xml:"moved-to,attr"`
+}
+
+// svn info --xml
+type infoXML struct {
+	XMLName xml.Name       `xml:"info"`
+	Entries []infoEntryXML `xml:"entry"`
+}
+
+type infoEntryXML struct {
+	Kind        string `xml:"kind,attr"`
+	Path        string `xml:"path,attr"`
+	Revision    int    `xml:"revision,attr"`
+	URL         string `xml:"url"`
+	RelativeURL string `xml:"relative-url"`
+	Repository  struct {
+		Root string `xml:"root"`
+		UUID string `xml:"uuid"`
+	} `xml:"repository"`
+	Commit struct {
+		Revision int    `xml:"revision,attr"`
+		Author   string `xml:"author"`
+		Date     string `xml:"date"`
+	} `xml:"commit"`
+}
+
+// svn proplist --xml -v、svn propget --xml
+type proplistXML struct {
+	XMLName xml.Name `xml:"properties"`
+	Targets []struct {
+		Path       string `xml:"path,attr"`
+		Properties []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"property"`
+	} `xml:"target"`
+}
+
+// parseLog 解析 svn log --xml 的输出
+func parseLog(data []byte) ([]LogEntry, error) {
+	var doc logXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 svn log XML 失败: %w", err)
+	}
+
+	entries := make([]LogEntry, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		entry := LogEntry{
+			Revision: e.Revision,
+			Author:   e.Author,
+			Date:     e.Date,
+			Message:  e.Msg,
+		}
+		for _, p := range e.Paths {
+			entry.Paths = append(entry.Paths, LogPath{
+				Action:       p.Action,
+				Path:         p.Path,
+				Kind:         p.Kind,
+				TextMods:     p.TextMods,
+				PropMods:     p.PropMods,
+				CopyFromPath: p.CopyFromPath,
+				CopyFromRev:  p.CopyFromRev,
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseStatus 解析 svn status --xml 的输出
+func parseStatus(data []byte) (*statusXML, error) {
+	var doc statusXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 svn status XML 失败: %w", err)
+	}
+	return &doc, nil
+}
+
+// parseInfo 解析 svn info --xml 的输出
+func parseInfo(data []byte) ([]Info, error) {
+	var doc infoXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 svn info XML 失败: %w", err)
+	}
+
+	infos := make([]Info, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		infos = append(infos, Info{
+			Path:           e.Path,
+			Kind:           e.Kind,
+			Revision:       e.Revision,
+			URL:            e.URL,
+			RelativeURL:    e.RelativeURL,
+			RootURL:        e.Repository.Root,
+			UUID:           e.Repository.UUID,
+			LastChangedRev: e.Commit.Revision,
+			LastAuthor:     e.Commit.Author,
+			LastDate:       e.Commit.Date,
+		})
+	}
+	return infos, nil
+}
+
+// parseProplist 解析 svn proplist --xml -v 的输出，返回第一个目标的属性
+func parseProplist(data []byte) (map[string]string, error) {
+	var doc proplistXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 svn proplist XML 失败: %w", err)
+	}
+	props := map[string]string{}
+	if len(doc.Targets) > 0 {
+		for _, p := range doc.Targets[0].Properties {
+			props[p.Name] = p.Value
+		}
+	}
+	return props, nil
+}
+
+// parsePropTargets 解析 svn propget/proplist --xml 的输出，返回每个目标的指定属性值
+// 使用 --show-inherited-props 时，继承自上级的属性也以目标的形式出现（路径为上级目录的 URL）
+func parsePropTargets(data []byte, name string) (map[string]string, error) {
+	var doc proplistXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 svn propget XML 失败: %w", err)
+	}
+	values := map[string]string{}
+	for _, t := range doc.Targets {
+		for _, p := range t.Properties {
+			if p.Name == name {
+				values[t.Path] = p.Value
+			}
+		}
+	}
+	return values, nil
+}
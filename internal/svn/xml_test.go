@@ -0,0 +1,154 @@
+package svn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseLog(t *testing.T) {
+	entries, err := parseLog(readFixture(t, "log.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.Revision != 1042 || e.Author != "zhang.san" || e.Date != "2024-03-18T08:15:42.123456Z" {
+		t.Errorf("entry = %+v", e)
+	}
+	if want := "修复 <Order> 金额计算 & 增加 \"校验\"\n第二行说明"; e.Message != want {
+		t.Errorf("Message = %q, want %q", e.Message, want)
+	}
+	if len(e.Paths) != 4 {
+		t.Fatalf("len(Paths) = %d, want 4", len(e.Paths))
+	}
+
+	tests := []LogPath{
+		{Action: "M", Path: "/trunk/src/main/java/com/example/OrderService.java", Kind: "file", TextMods: true},
+		{Action: "A", Path: "/trunk/src/util/StringUtils.java", Kind: "file", CopyFromPath: "/trunk/src/util/Strings.java", CopyFromRev: 1040},
+		{Action: "M", Path: "/trunk/build.xml", Kind: "file", PropMods: true},
+		{Action: "D", Path: "/trunk/src/util/Strings.java", Kind: "file"},
+	}
+	for i, want := range tests {
+		if e.Paths[i] != want {
+			t.Errorf("Paths[%d] = %+v, want %+v", i, e.Paths[i], want)
+		}
+	}
+
+	// 旧版本服务器没有 kind、text-mods 等属性，提交说明为空
+	e = entries[1]
+	if e.Message != "" || len(e.Paths) != 1 {
+		t.Fatalf("entry = %+v", e)
+	}
+	want := LogPath{Action: "R", Path: "/trunk/a.go", CopyFromPath: "/branches/feature/a.go", CopyFromRev: 1039}
+	if e.Paths[0] != want {
+		t.Errorf("Paths[0] = %+v, want %+v", e.Paths[0], want)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	doc, err := parseStatus(readFixture(t, "status.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Targets) != 1 || len(doc.Changelists) != 1 {
+		t.Fatalf("targets = %d, changelists = %d", len(doc.Targets), len(doc.Changelists))
+	}
+
+	type result struct {
+		status     string
+		action     Action
+		textMods   bool
+		propMods   bool
+		conflicted bool
+		tree       bool
+		copyFrom   string
+	}
+	tests := map[string]*result{
+		"src/Order&Item.java": {status: "M", action: ActionModified, textMods: true},
+		// 只修改了属性
+		"build.xml":      {status: "M", action: ActionModified, propMods: true},
+		"src/Moved.java": {status: "A", action: ActionAdded, textMods: true, copyFrom: "src/Old.java"},
+		"src/Old.java":   {status: "D", action: ActionDeleted},
+		// 缺失且有树冲突
+		"src/Gone.java":     {status: "C", action: ActionMissing, conflicted: true, tree: true},
+		"src/Conflict.java": {status: "C", action: ActionConflicted, conflicted: true},
+		// 内容未修改的目录上的树冲突
+		"lib":       {status: "C", action: ActionConflicted, conflicted: true, tree: true},
+		"notes.txt": {status: "?", action: ActionUnversioned},
+		// 外部定义不需要审核
+		"vendor/ext": nil,
+	}
+
+	entries := doc.Targets[0].Entries
+	if len(entries) != len(tests) {
+		t.Fatalf("len(entries) = %d, want %d", len(entries), len(tests))
+	}
+	for _, entry := range entries {
+		want, ok := tests[entry.Path]
+		if !ok {
+			t.Errorf("unexpected entry %q", entry.Path)
+			continue
+		}
+		change, review := statusToChange(entry)
+		if want == nil {
+			if review {
+				t.Errorf("%s: should not be reviewed, got %+v", entry.Path, change)
+			}
+			continue
+		}
+		if !review {
+			t.Errorf("%s: should be reviewed", entry.Path)
+			continue
+		}
+		got := result{change.Status, change.Action, change.TextMods, change.PropMods, change.Conflicted, change.TreeConflicted, change.CopyFromPath}
+		if got != *want {
+			t.Errorf("%s: got %+v, want %+v", entry.Path, got, *want)
+		}
+	}
+
+	cl := doc.Changelists[0]
+	if cl.Name != "fix-123" || len(cl.Entries) != 1 || cl.Entries[0].Path != "src/Fix.java" {
+		t.Errorf("changelist = %+v", cl)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	infos, err := parseInfo(readFixture(t, "info.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("len(infos) = %d, want 1", len(infos))
+	}
+	want := Info{
+		Path:           ".",
+		Kind:           "dir",
+		Revision:       1042,
+		URL:            "https://svn.example.com/repos/project/trunk/R%26D",
+		RelativeURL:    "^/trunk/R%26D",
+		RootURL:        "https://svn.example.com/repos/project",
+		UUID:           "0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+		LastChangedRev: 1040,
+		LastAuthor:     "wang.wu & co",
+		LastDate:       "2024-03-16T10:00:00.000000Z",
+	}
+	if infos[0] != want {
+		t.Errorf("info = %+v, want %+v", infos[0], want)
+	}
+	if got := repositoryPath(&infos[0]); got != "/trunk/R&D" {
+		t.Errorf("repositoryPath = %q, want /trunk/R&D", got)
+	}
+}
@@ -19,7 +19,7 @@ func main() {
 	}
 
 	// 否则启动 Web GUI 模式
-	server := gui.NewServer()
+	server := gui.NewServer(gui.Options{OpenBrowser: true})
 	if err := server.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "启动服务器失败: %v\n", err)
 		os.Exit(1)